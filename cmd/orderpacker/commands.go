@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	log "github.com/obalunenko/logger"
+
+	"github.com/obalunenko/orderpacker/internal/config"
+	"github.com/obalunenko/orderpacker/internal/packer"
+)
+
+// command is run instead of the server when its name is the first argument, e.g. "orderpacker table".
+// Commands load the same config as the server and write their output to stdout.
+type command func(ctx context.Context, cfg *config.Config, args []string, out io.Writer) error
+
+var commands = map[string]command{
+	"table":     tableCommand,
+	"recommend": recommendCommand,
+	"simulate":  simulateCommand,
+}
+
+// runCommand runs the command and returns the exit code.
+func runCommand(cmd command, args []string) int {
+	ctx := log.ContextWithLogger(context.Background(), log.FromContext(context.Background()))
+
+	cfg, err := config.Load(ctx)
+	if err != nil {
+		log.WithError(ctx, err).Error("Failed to load config")
+
+		return 1
+	}
+
+	l := log.Init(ctx, log.Params{
+		Writer: os.Stderr,
+		Level:  cfg.Log.Level,
+		Format: cfg.Log.Format,
+	})
+
+	ctx = log.ContextWithLogger(ctx, l)
+
+	if err = cmd(ctx, cfg, args, os.Stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+
+		log.WithError(ctx, err).Error("Command failed")
+
+		return 1
+	}
+
+	return 0
+}
+
+// familyPacker returns the packer of the named box family, the default box set if the name is empty.
+func familyPacker(ctx context.Context, cfg *config.Config, family string) (*packer.Packer, error) {
+	boxes := cfg.Pack.Boxes
+
+	if family != "" {
+		var ok bool
+
+		boxes, ok = cfg.Pack.Families[family]
+		if !ok {
+			return nil, fmt.Errorf("unknown box family %q", family)
+		}
+	}
+
+	return packer.NewPacker(ctx, packerOptions(cfg, boxes)...)
+}
@@ -15,7 +15,9 @@ import (
 	_ "github.com/swaggo/swag"
 
 	"github.com/obalunenko/orderpacker/internal/config"
+	"github.com/obalunenko/orderpacker/internal/inventory"
 	"github.com/obalunenko/orderpacker/internal/packer"
+	"github.com/obalunenko/orderpacker/internal/packer/geometry"
 	"github.com/obalunenko/orderpacker/internal/service"
 )
 
@@ -34,7 +36,18 @@ var errSignal = errors.New("received signal")
 //
 // @externalDocs.description	OpenAPI
 // @externalDocs.url			https://swagger.io/resources/open-api/
+//
+// @securityDefinitions.apikey	AdminToken
+// @in							header
+// @name						Authorization
+// @description				Admin token as "Bearer <token>", set by ADMIN_TOKEN
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			os.Exit(runCommand(cmd, os.Args[2:]))
+		}
+	}
+
 	signals := make(chan os.Signal, 1)
 
 	l := log.FromContext(context.Background())
@@ -95,13 +108,79 @@ func main() {
 	port := cfg.HTTP.Port
 	host := cfg.HTTP.Host
 
-	p, err := packer.NewPacker(ctx, packer.WithBoxes(cfg.Pack.Boxes))
+	inv := inventory.New(cfg.Pack.Stock)
+
+	p, err := packer.NewPacker(ctx, append(packerOptions(cfg, cfg.Pack.Boxes), packer.WithInventory(inv))...)
 	if err != nil {
 		cancel(fmt.Errorf("failed to create packer: %w", err))
 
 		return
 	}
 
+	logAnalysis(ctx, "default", p)
+
+	hup := make(chan os.Signal, 1)
+
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go watchBoxes(ctx, p, cfg.Pack.BoxesFile, cfg.Pack.BoxesPoll, hup)
+
+	families := make(map[string]*packer.Packer, len(cfg.Pack.Families))
+
+	for name, boxes := range cfg.Pack.Families {
+		families[name], err = packer.NewPacker(ctx, append(packerOptions(cfg, boxes), packer.WithInventory(inv))...)
+		if err != nil {
+			cancel(fmt.Errorf("failed to create packer of box family %q: %w", name, err))
+
+			return
+		}
+
+		logAnalysis(ctx, name, families[name])
+	}
+
+	named := make(map[string]*packer.Packer, len(cfg.Pack.Profiles))
+
+	// Every warehouse has a stock of its own, the configured one is of the default profile and its families.
+	stocks := map[string]*inventory.Inventory{packer.DefaultProfile: inv}
+
+	for name, boxes := range cfg.Pack.Profiles {
+		stocks[name] = inventory.New(nil)
+
+		named[name], err = packer.NewPacker(ctx, append(packerOptions(cfg, boxes), packer.WithInventory(stocks[name]))...)
+		if err != nil {
+			cancel(fmt.Errorf("failed to create packer of box profile %q: %w", name, err))
+
+			return
+		}
+
+		logAnalysis(ctx, name, named[name])
+	}
+
+	profiles, err := packer.NewProfiles(ctx, p, named)
+	if err != nil {
+		cancel(fmt.Errorf("failed to create box profiles: %w", err))
+
+		return
+	}
+
+	catalog, err := packer.NewCatalog(ctx, families, cfg.Pack.SKUs, inv)
+	if err != nil {
+		cancel(fmt.Errorf("failed to create catalog: %w", err))
+
+		return
+	}
+
+	var g *geometry.Packer
+
+	if len(cfg.Pack.GeometryBoxes) != 0 {
+		g, err = geometry.NewPacker(ctx, cfg.Pack.GeometryBoxes)
+		if err != nil {
+			cancel(fmt.Errorf("failed to create geometry packer: %w", err))
+
+			return
+		}
+	}
+
 	log.WithFields(ctx, log.Fields{
 		"host": host,
 		"port": port,
@@ -109,7 +188,7 @@ func main() {
 
 	server := &http.Server{
 		Addr:    net.JoinHostPort(host, port),
-		Handler: service.NewRouter(p),
+		Handler: service.NewRouter(profiles, catalog, g, stocks, cfg.HTTP.AdminToken),
 	}
 
 	var wg sync.WaitGroup
@@ -141,3 +220,44 @@ func main() {
 
 	wg.Wait()
 }
+
+// packerOptions returns the options of a packer of the box set configured by cfg.
+func packerOptions(cfg *config.Config, boxes []packer.Box) []packer.PackerOption {
+	return []packer.PackerOption{
+		packer.WithBoxSet(boxes),
+		packer.WithStrategy(cfg.Pack.Strategy),
+		packer.WithObjective(cfg.Pack.Objective),
+		packer.WithOvershootPenalty(cfg.Pack.OvershootPenalty),
+		packer.WithTimeout(cfg.Pack.Timeout),
+		packer.WithAnytime(cfg.Pack.Anytime),
+		packer.WithLevels(cfg.Pack.Levels...),
+		packer.WithShipmentLimits(cfg.Pack.Shipment),
+		packer.WithExactFitOnly(cfg.Pack.ExactFit),
+		packer.WithFillLimits(cfg.Pack.Fill),
+	}
+}
+
+// logAnalysis logs the analysis of the box set of a family and warns about likely typos in it.
+func logAnalysis(ctx context.Context, family string, p *packer.Packer) {
+	a := p.Analyze()
+
+	fields := log.Fields{
+		"family":    family,
+		"sizes":     a.Sizes,
+		"gcd":       a.GCD,
+		"dominated": a.Dominated,
+	}
+
+	if a.Frobenius != nil {
+		fields["frobenius"] = *a.Frobenius
+	}
+
+	log.WithFields(ctx, fields).Info("Box set analyzed")
+
+	for _, w := range a.Warnings {
+		log.WithFields(ctx, log.Fields{
+			"family":  family,
+			"warning": w,
+		}).Warn("Box set warning")
+	}
+}
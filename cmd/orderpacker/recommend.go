@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	log "github.com/obalunenko/logger"
+
+	"github.com/obalunenko/orderpacker/internal/config"
+	"github.com/obalunenko/orderpacker/internal/recommend"
+)
+
+// recommendCommand searches for a box set that packs past orders better than the configured one:
+//
+//	orderpacker recommend -in orders.csv -sizes 5 -step 50
+func recommendCommand(ctx context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("recommend", flag.ContinueOnError)
+
+	in := fs.String("in", "-", "histogram of past orders: CSV of items,count or JSONL of {\"items\": n, \"count\": c}, - for stdin")
+	inFormat := fs.String("in-format", "", "format of the histogram: csv or jsonl, by the file extension if not set")
+	sizes := fs.Int("sizes", 5, "number of box sizes to recommend")
+	metric := fs.String("metric", string(recommend.MetricOvershoot), "what to minimize: overshoot or cost")
+	step := fs.Uint("step", 1, "round box sizes up to multiples of the step")
+	boxCost := fs.Float64("box-cost", 0, "cost of a recommended box")
+	itemCost := fs.Float64("item-cost", 0, "cost of a recommended box per item it holds")
+	format := fs.String("format", "table", "output format: table or json")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	h, err := readHistogram(*in, *inFormat)
+	if err != nil {
+		return err
+	}
+
+	opts := recommend.Options{
+		Sizes:            *sizes,
+		Metric:           recommend.Metric(*metric),
+		Step:             *step,
+		BoxCost:          *boxCost,
+		ItemCost:         *itemCost,
+		OvershootPenalty: cfg.Pack.OvershootPenalty,
+		Strategy:         cfg.Pack.Strategy,
+		// Packers of evaluated box sets are many, their logs would bury the result. Init replaces
+		// the default logger too, the command itself logs with the one of its context.
+		Logger: log.Init(ctx, log.Params{
+			Writer: nopCloser{Writer: io.Discard},
+			Level:  cfg.Log.Level,
+			Format: cfg.Log.Format,
+		}),
+	}
+
+	current, err := recommend.Evaluate(ctx, cfg.Pack.Boxes, h, opts)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate configured boxes: %w", err)
+	}
+
+	best, err := recommend.Recommend(ctx, h, opts)
+	if err != nil {
+		return fmt.Errorf("failed to recommend boxes: %w", err)
+	}
+
+	switch *format {
+	case "table":
+		return writeEvaluations(out, []string{"current", "recommended"}, []recommend.Evaluation{current, best})
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(struct {
+			Current     evaluation `json:"current"`
+			Recommended evaluation `json:"recommended"`
+		}{
+			Current:     toEvaluation(current),
+			Recommended: toEvaluation(best),
+		})
+	default:
+		return fmt.Errorf("unknown output format %q", *format)
+	}
+}
+
+func readHistogram(path, format string) (recommend.Histogram, error) {
+	r := io.Reader(os.Stdin)
+
+	if path != "-" {
+		f, err := os.Open(filepath.Clean(path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open histogram: %w", err)
+		}
+
+		defer func() {
+			_ = f.Close()
+		}()
+
+		r = f
+
+		if format == "" {
+			format = strings.TrimPrefix(filepath.Ext(path), ".")
+		}
+	}
+
+	switch strings.ToLower(format) {
+	case "", "csv":
+		return recommend.ParseCSV(r)
+	case "jsonl":
+		return recommend.ParseJSONL(r)
+	default:
+		return nil, fmt.Errorf("unknown histogram format %q", format)
+	}
+}
+
+// evaluation is the JSON output of a box set evaluation.
+type evaluation struct {
+	Boxes     []uint  `json:"boxes"`
+	Orders    uint    `json:"orders"`
+	Items     uint    `json:"items"`
+	Shipped   uint    `json:"shipped"`
+	Overshoot uint    `json:"overshoot"`
+	Packs     uint    `json:"packs"`
+	Cost      float64 `json:"cost"`
+}
+
+func toEvaluation(e recommend.Evaluation) evaluation {
+	res := evaluation{
+		Boxes:     make([]uint, 0, len(e.Boxes)),
+		Orders:    e.Orders,
+		Items:     e.Items,
+		Shipped:   e.Shipped,
+		Overshoot: e.Overshoot,
+		Packs:     e.Packs,
+		Cost:      e.Cost,
+	}
+
+	for _, b := range e.Boxes {
+		res.Boxes = append(res.Boxes, b.Size)
+	}
+
+	return res
+}
+
+// writeEvaluations writes evaluations of box sets as a table with a row per named set.
+func writeEvaluations(out io.Writer, names []string, evals []recommend.Evaluation) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	if _, err := fmt.Fprintln(w, "set\tboxes\torders\titems\tshipped\tovershoot\tpacks\tcost"); err != nil {
+		return err
+	}
+
+	for i, e := range evals {
+		ev := toEvaluation(e)
+
+		boxes := make([]string, 0, len(ev.Boxes))
+		for _, b := range ev.Boxes {
+			boxes = append(boxes, fmt.Sprint(b))
+		}
+
+		_, err := fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%.2f\n",
+			names[i], strings.Join(boxes, ","), ev.Orders, ev.Items, ev.Shipped, ev.Overshoot, ev.Packs, ev.Cost)
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+// nopCloser is a writer that does nothing on close.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
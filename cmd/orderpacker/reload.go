@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/obalunenko/logger"
+
+	"github.com/obalunenko/orderpacker/internal/config"
+	"github.com/obalunenko/orderpacker/internal/packer"
+)
+
+// watchBoxes replaces the box set of the packer with the one of the file on SIGHUP and, when poll is set,
+// when the modification time or size of the file changes. An invalid file leaves the box set as it is.
+// It returns when the context is done.
+func watchBoxes(ctx context.Context, p *packer.Packer, path string, poll time.Duration, hup <-chan os.Signal) {
+	var (
+		tick <-chan time.Time
+		last os.FileInfo
+	)
+
+	if path != "" && poll > 0 {
+		t := time.NewTicker(poll)
+		defer t.Stop()
+
+		tick = t.C
+		last, _ = os.Stat(filepath.Clean(path))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if path == "" {
+				log.Warn(ctx, "SIGHUP received, but there is no boxes file to reload")
+
+				continue
+			}
+
+			log.WithField(ctx, "file", path).Info("SIGHUP received, reloading boxes")
+
+			reloadBoxes(ctx, p, path)
+		case <-tick:
+			fi, err := os.Stat(filepath.Clean(path))
+			if err != nil {
+				log.WithError(ctx, err).Warn("Failed to check boxes file")
+
+				continue
+			}
+
+			if last != nil && fi.ModTime().Equal(last.ModTime()) && fi.Size() == last.Size() {
+				continue
+			}
+
+			last = fi
+
+			log.WithField(ctx, "file", path).Info("Boxes file changed, reloading boxes")
+
+			reloadBoxes(ctx, p, path)
+		}
+	}
+}
+
+func reloadBoxes(ctx context.Context, p *packer.Packer, path string) {
+	boxes, err := config.LoadBoxesFile(path)
+	if err == nil {
+		err = p.SetBoxes(ctx, boxes)
+	}
+
+	if err != nil {
+		log.WithError(ctx, err).Error("Failed to reload boxes, keeping the current box set")
+
+		return
+	}
+
+	logAnalysis(ctx, "default", p)
+}
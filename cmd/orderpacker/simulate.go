@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/obalunenko/orderpacker/internal/config"
+	"github.com/obalunenko/orderpacker/internal/packer"
+	"github.com/obalunenko/orderpacker/internal/recommend"
+	"github.com/obalunenko/orderpacker/internal/simulation"
+)
+
+// simulateCommand replays orders through strategies and box sets and compares them:
+//
+//	orderpacker simulate -orders 10000 -max 20000 -boxes "current=250,500,1000,2000,5000;round=300,600,1500"
+func simulateCommand(ctx context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+
+	in := fs.String("in", "", "recorded orders: CSV of items,count or JSONL of {\"items\": n, \"count\": c}, - for stdin; synthetic orders if not set")
+	inFormat := fs.String("in-format", "", "format of recorded orders: csv or jsonl, by the file extension if not set")
+	orders := fs.Int("orders", 10000, "number of synthetic orders")
+	minItems := fs.Uint("min", 1, "the least number of items of a synthetic order")
+	maxItems := fs.Uint("max", 20000, "the largest number of items of a synthetic order")
+	seed := fs.Uint64("seed", 1, "seed of synthetic orders")
+	strategies := fs.String("strategies", "", "comma separated strategies, all registered if not set")
+	boxes := fs.String("boxes", "", "box sets as PACK_FAMILIES, e.g. \"a=250,500;b=300,600\", configured boxes and families if not set")
+	format := fs.String("format", "table", "output format: table or json")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var (
+		stream []uint
+		err    error
+	)
+
+	if *in != "" {
+		h, err := readHistogram(*in, *inFormat)
+		if err != nil {
+			return err
+		}
+
+		stream, err = recordedOrders(h)
+		if err != nil {
+			return err
+		}
+	} else {
+		stream, err = simulation.Synthetic(*orders, *minItems, *maxItems, *seed)
+		if err != nil {
+			return err
+		}
+	}
+
+	opts := simulation.Options{
+		Objective:        cfg.Pack.Objective,
+		OvershootPenalty: cfg.Pack.OvershootPenalty,
+	}
+
+	if *strategies != "" {
+		for _, s := range strings.Split(*strategies, ",") {
+			opts.Strategies = append(opts.Strategies, strings.TrimSpace(s))
+		}
+	}
+
+	opts.BoxSets, err = boxSets(cfg, *boxes)
+	if err != nil {
+		return err
+	}
+
+	rep, err := simulation.Run(ctx, stream, opts)
+	if err != nil {
+		return fmt.Errorf("failed to simulate: %w", err)
+	}
+
+	switch *format {
+	case "table":
+		return writeReport(out, rep)
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(toReport(rep))
+	default:
+		return fmt.Errorf("unknown output format %q", *format)
+	}
+}
+
+// recordedOrders returns the stream of recorded orders, the orders of each size in turn. The number of orders
+// is checked before the stream is allocated, as a histogram holds many of them in a line.
+func recordedOrders(h recommend.Histogram) ([]uint, error) {
+	var total uint
+
+	for _, b := range h {
+		if b.Count > simulation.MaxOrders-total {
+			return nil, fmt.Errorf("recorded orders are more than %d, simulate a sample of them", simulation.MaxOrders)
+		}
+
+		total += b.Count
+	}
+
+	stream := make([]uint, 0, total)
+
+	for _, b := range h {
+		for range b.Count {
+			stream = append(stream, b.Items)
+		}
+	}
+
+	return stream, nil
+}
+
+// boxSets returns the box sets of the flag value, the configured boxes and families if it is empty.
+func boxSets(cfg *config.Config, value string) ([]simulation.BoxSet, error) {
+	if value != "" {
+		families, err := packer.ParseFamilies(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid box sets: %w", err)
+		}
+
+		sets := make([]simulation.BoxSet, 0, len(families))
+
+		for _, name := range slices.Sorted(maps.Keys(families)) {
+			sets = append(sets, simulation.BoxSet{Name: name, Boxes: families[name]})
+		}
+
+		return sets, nil
+	}
+
+	sets := []simulation.BoxSet{{Name: "configured", Boxes: cfg.Pack.Boxes}}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Pack.Families)) {
+		sets = append(sets, simulation.BoxSet{Name: name, Boxes: cfg.Pack.Families[name]})
+	}
+
+	return sets, nil
+}
+
+// report is the JSON output of a simulation.
+type report struct {
+	Orders        uint           `json:"orders"`
+	Results       []result       `json:"results"`
+	Disagreements []disagreement `json:"disagreements"`
+}
+
+type result struct {
+	BoxSet   string  `json:"box_set"`
+	Strategy string  `json:"strategy"`
+	Orders   uint    `json:"orders"`
+	Failed   uint    `json:"failed"`
+	Items    uint    `json:"items"`
+	Shipped  uint    `json:"shipped"`
+	Waste    uint    `json:"waste"`
+	Packs    uint    `json:"packs"`
+	Cost     float64 `json:"cost"`
+	Latency  latency `json:"latency"`
+}
+
+type latency struct {
+	P50 string `json:"p50"`
+	P90 string `json:"p90"`
+	P99 string `json:"p99"`
+	Max string `json:"max"`
+}
+
+type disagreement struct {
+	BoxSet   string `json:"box_set"`
+	A        string `json:"a"`
+	B        string `json:"b"`
+	Orders   uint   `json:"orders"`
+	Worse    uint   `json:"worse"`
+	Better   uint   `json:"better"`
+	Examples []uint `json:"examples"`
+}
+
+func toReport(rep simulation.Report) report {
+	res := report{
+		Orders:        rep.Orders,
+		Results:       make([]result, 0, len(rep.Results)),
+		Disagreements: make([]disagreement, 0, len(rep.Disagreements)),
+	}
+
+	for _, r := range rep.Results {
+		res.Results = append(res.Results, result{
+			BoxSet:   r.BoxSet,
+			Strategy: r.Strategy,
+			Orders:   r.Orders,
+			Failed:   r.Failed,
+			Items:    r.Items,
+			Shipped:  r.Shipped,
+			Waste:    r.Waste,
+			Packs:    r.Packs,
+			Cost:     r.Cost,
+			Latency: latency{
+				P50: r.Latency.P50.String(),
+				P90: r.Latency.P90.String(),
+				P99: r.Latency.P99.String(),
+				Max: r.Latency.Max.String(),
+			},
+		})
+	}
+
+	for _, d := range rep.Disagreements {
+		res.Disagreements = append(res.Disagreements, disagreement{
+			BoxSet:   d.BoxSet,
+			A:        d.A,
+			B:        d.B,
+			Orders:   d.Orders,
+			Worse:    d.Worse,
+			Better:   d.Better,
+			Examples: d.Examples,
+		})
+	}
+
+	return res
+}
+
+// writeReport writes results and disagreements of a simulation as tables.
+func writeReport(out io.Writer, rep simulation.Report) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	if _, err := fmt.Fprintln(w, "box set\tstrategy\torders\tfailed\titems\tshipped\twaste\tpacks\tcost\tp50\tp90\tp99\tmax"); err != nil {
+		return err
+	}
+
+	for _, r := range rep.Results {
+		_, err := fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%.2f\t%s\t%s\t%s\t%s\n",
+			r.BoxSet, r.Strategy, r.Orders, r.Failed, r.Items, r.Shipped, r.Waste, r.Packs, r.Cost,
+			r.Latency.P50, r.Latency.P90, r.Latency.P99, r.Latency.Max)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintln(w, "\nbox set\ta\tb\tdisagree\tb worse\tb better\texamples"); err != nil {
+		return err
+	}
+
+	for _, d := range rep.Disagreements {
+		examples := make([]string, 0, len(d.Examples))
+		for _, items := range d.Examples {
+			examples = append(examples, fmt.Sprint(items))
+		}
+
+		_, err := fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%s\n",
+			d.BoxSet, d.A, d.B, d.Orders, d.Worse, d.Better, strings.Join(examples, ","))
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/obalunenko/orderpacker/internal/chart"
+	"github.com/obalunenko/orderpacker/internal/config"
+	"github.com/obalunenko/orderpacker/internal/packer"
+)
+
+// tableCommand prints the packing table of the configured box set:
+//
+//	orderpacker table -from 1 -to 20000 -format html > chart.html
+func tableCommand(ctx context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("table", flag.ContinueOnError)
+
+	from := fs.Uint("from", 1, "the least number of items")
+	to := fs.Uint("to", 0, "the largest number of items, four largest boxes if not set")
+	format := fs.String("format", string(chart.FormatCSV), "format of the table: csv, json or html")
+	family := fs.String("family", "", "box family, the default box set if not set")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	f, err := chart.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	p, err := familyPacker(ctx, cfg, *family)
+	if err != nil {
+		return err
+	}
+
+	boxes := p.Boxes()
+
+	if *to == 0 {
+		*to = p.TableTo(*from, 4, packer.MaxTableItems)
+	}
+
+	table, err := p.Table(ctx, *from, *to)
+	if err != nil {
+		return fmt.Errorf("failed to compute packing table: %w", err)
+	}
+
+	title := fmt.Sprintf("Packing table for %d-%d items", *from, *to)
+	if *family != "" {
+		title = fmt.Sprintf("Packing table of %s boxes for %d-%d items", *family, *from, *to)
+	}
+
+	return chart.New(title, boxes, table).Write(out, f)
+}
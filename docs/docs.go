@@ -23,9 +23,290 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/api/v1/boxes": {
+            "get": {
+                "description": "Returns the box set orders are packed with and the one it replaced",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "boxes"
+                ],
+                "summary": "Get the box set",
+                "operationId": "orderpacker-boxes-get\tget",
+                "responses": {
+                    "200": {
+                        "description": "Box set",
+                        "schema": {
+                            "$ref": "#/definitions/service.BoxSet"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/service.methodNotAllowedError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Replaces the box set without restart. Orders being packed finish with the boxes they started with.\nThe new set is validated before it goes live, the replaced one is kept for rollback",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "boxes"
+                ],
+                "summary": "Replace the box set",
+                "operationId": "orderpacker-boxes-put\tput",
+                "parameters": [
+                    {
+                        "description": "Box set, previous is ignored",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.BoxSet"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Box set",
+                        "schema": {
+                            "$ref": "#/definitions/service.BoxSet"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request data",
+                        "schema": {
+                            "$ref": "#/definitions/service.badRequestError"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/service.unauthorizedError"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin endpoints are disabled",
+                        "schema": {
+                            "$ref": "#/definitions/service.forbiddenError"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/service.methodNotAllowedError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/boxes/analysis": {
+            "get": {
+                "description": "Returns the gcd of box sizes, the largest order that cannot be packed exactly,\nsizes the optimal solver never uses and warnings about sizes that are likely typos",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "boxes"
+                ],
+                "summary": "Analyze the box set",
+                "operationId": "orderpacker-boxes-analysis\tget",
+                "responses": {
+                    "200": {
+                        "description": "Analysis of the box set",
+                        "schema": {
+                            "$ref": "#/definitions/service.BoxAnalysis"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/service.methodNotAllowedError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/boxes/rollback": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Swaps the box set with the one it replaced, rolling back twice restores it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "boxes"
+                ],
+                "summary": "Roll back the box set",
+                "operationId": "orderpacker-boxes-rollback\tpost",
+                "responses": {
+                    "200": {
+                        "description": "Box set",
+                        "schema": {
+                            "$ref": "#/definitions/service.BoxSet"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/service.unauthorizedError"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin endpoints are disabled",
+                        "schema": {
+                            "$ref": "#/definitions/service.forbiddenError"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/service.methodNotAllowedError"
+                        }
+                    },
+                    "409": {
+                        "description": "The box set was never replaced",
+                        "schema": {
+                            "$ref": "#/definitions/service.conflictError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/inventory": {
+            "get": {
+                "description": "Returns the number of boxes in stock per box size of the box profile, each profile has its own stock",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "inventory"
+                ],
+                "summary": "Get the stock of boxes",
+                "operationId": "orderpacker-inventory-get\tget",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Box profile, the default one if not set",
+                        "name": "X-Box-Profile",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stock of boxes",
+                        "schema": {
+                            "$ref": "#/definitions/service.Inventory"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown box profile",
+                        "schema": {
+                            "$ref": "#/definitions/service.notFoundError"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/service.methodNotAllowedError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Replaces the number of boxes in stock per box size of the box profile. Box sizes left out are in unlimited supply",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "inventory"
+                ],
+                "summary": "Set the stock of boxes",
+                "operationId": "orderpacker-inventory-put\tput",
+                "parameters": [
+                    {
+                        "description": "Stock of boxes",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.Inventory"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Box profile, the default one if not set",
+                        "name": "X-Box-Profile",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stock of boxes",
+                        "schema": {
+                            "$ref": "#/definitions/service.Inventory"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request data",
+                        "schema": {
+                            "$ref": "#/definitions/service.badRequestError"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/service.unauthorizedError"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin endpoints are disabled",
+                        "schema": {
+                            "$ref": "#/definitions/service.forbiddenError"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown box profile",
+                        "schema": {
+                            "$ref": "#/definitions/service.notFoundError"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/service.methodNotAllowedError"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/pack": {
             "post": {
-                "description": "Calculates the number of packs needed to ship to a customer",
+                "description": "Calculates the number of packs needed to ship to a customer.\nAn order of several products is packed per line item in boxes of the product family, or of the selected box profile when it is not the default one.\nWith unit weight or volume boxes hold no more items than their limits allow.\nThe policy down ships only full boxes and backorders the rest, nearest ships whichever is closer to the order.\nWith shipment limits, requested or configured, packs are split into the fewest shipments balanced by load",
                 "consumes": [
                     "application/json"
                 ],
@@ -46,38 +327,1071 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/service.PackRequest"
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Explain why the packing was chosen",
+                        "name": "explain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Box profile, the default one if not set",
+                        "name": "X-Box-Profile",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response with packs data",
+                        "schema": {
+                            "$ref": "#/definitions/service.PackResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request data",
+                        "schema": {
+                            "$ref": "#/definitions/service.badRequestError"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown box profile",
+                        "schema": {
+                            "$ref": "#/definitions/service.notFoundError"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/service.methodNotAllowedError"
+                        }
+                    },
+                    "409": {
+                        "description": "Not enough boxes in stock",
+                        "schema": {
+                            "$ref": "#/definitions/service.conflictError"
+                        }
+                    },
+                    "422": {
+                        "description": "No exact fit of the order or no packing within fill limits",
+                        "schema": {
+                            "$ref": "#/definitions/service.unprocessableEntityError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/service.internalServerError"
+                        }
+                    },
+                    "503": {
+                        "description": "Solving ran out of time",
+                        "schema": {
+                            "$ref": "#/definitions/service.serviceUnavailableError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pack/alternatives": {
+            "post": {
+                "description": "Returns up to n best distinct packings ranked by the objective, the best first, so that an operator can pick a near-optimal one",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pack"
+                ],
+                "summary": "Get the best distinct packings of an order",
+                "operationId": "orderpacker-pack-alternatives\tpost",
+                "parameters": [
+                    {
+                        "description": "Request data",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.PackRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of alternatives, 5 by default, at most 20",
+                        "name": "n",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response with alternatives",
+                        "schema": {
+                            "$ref": "#/definitions/service.AlternativesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request data",
+                        "schema": {
+                            "$ref": "#/definitions/service.badRequestError"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/service.methodNotAllowedError"
+                        }
+                    },
+                    "409": {
+                        "description": "Not enough boxes in stock",
+                        "schema": {
+                            "$ref": "#/definitions/service.conflictError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/service.internalServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pack/mixed": {
+            "post": {
+                "description": "Consolidates line items into shared boxes of the default box set and returns contents of each box",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pack"
+                ],
+                "summary": "Pack items of several products into shared boxes",
+                "operationId": "orderpacker-pack-mixed\tpost",
+                "parameters": [
+                    {
+                        "description": "Line items",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.PackRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response with boxes contents",
+                        "schema": {
+                            "$ref": "#/definitions/service.MixedPackResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request data",
+                        "schema": {
+                            "$ref": "#/definitions/service.badRequestError"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/service.methodNotAllowedError"
+                        }
+                    },
+                    "409": {
+                        "description": "Not enough boxes in stock",
+                        "schema": {
+                            "$ref": "#/definitions/service.conflictError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/service.internalServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pack/nested": {
+            "post": {
+                "description": "Packs items in boxes, boxes in packs of the next packaging level and so on, e.g. cartons and pallets.\nReturns packings of each level and the tree of the outermost packs with their contents",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pack"
+                ],
+                "summary": "Pack an order in nested packaging levels",
+                "operationId": "orderpacker-pack-nested\tpost",
+                "parameters": [
+                    {
+                        "description": "Request data",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.PackRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Box profile, the default one if not set",
+                        "name": "X-Box-Profile",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response with packs of each level",
+                        "schema": {
+                            "$ref": "#/definitions/service.NestedPackResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request data",
+                        "schema": {
+                            "$ref": "#/definitions/service.badRequestError"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown box profile or no packaging levels",
+                        "schema": {
+                            "$ref": "#/definitions/service.notFoundError"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/service.methodNotAllowedError"
+                        }
+                    },
+                    "409": {
+                        "description": "Not enough boxes in stock",
+                        "schema": {
+                            "$ref": "#/definitions/service.conflictError"
+                        }
+                    },
+                    "422": {
+                        "description": "No exact fit of the order or no packing within fill limits",
+                        "schema": {
+                            "$ref": "#/definitions/service.unprocessableEntityError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/service.internalServerError"
+                        }
+                    },
+                    "503": {
+                        "description": "Solving ran out of time",
+                        "schema": {
+                            "$ref": "#/definitions/service.serviceUnavailableError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pack/table": {
+            "get": {
+                "description": "Returns ranges of orders packed the same way, computed by the same solver as orders, to print for the floor.\nBoxes are in unlimited supply, so the table does not change with stock levels.\nA table covers at most 10000 orders and is computed within the packer timeout",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "text/html"
+                ],
+                "tags": [
+                    "pack"
+                ],
+                "summary": "Get the packing table",
+                "operationId": "orderpacker-pack-table\tget",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "The least number of items, 1 by default",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "The largest number of items, four largest boxes but at most 10000 orders by default",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Format of the table: json (default), csv or html",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Packing table",
+                        "schema": {
+                            "$ref": "#/definitions/chart.Chart"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request data",
+                        "schema": {
+                            "$ref": "#/definitions/service.badRequestError"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/service.methodNotAllowedError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/service.internalServerError"
+                        }
+                    },
+                    "503": {
+                        "description": "Computing ran out of time",
+                        "schema": {
+                            "$ref": "#/definitions/service.serviceUnavailableError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/profiles": {
+            "get": {
+                "description": "Returns names of box profiles requests select by the api/v1/profiles/{name} path or the X-Box-Profile header",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profiles"
+                ],
+                "summary": "Get box profiles",
+                "operationId": "orderpacker-profiles\tget",
+                "responses": {
+                    "200": {
+                        "description": "Box profiles",
+                        "schema": {
+                            "$ref": "#/definitions/service.ProfilesResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/service.methodNotAllowedError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/pack": {
+            "post": {
+                "description": "Places units of items into 3D boxes respecting rotation rules and returns coordinates of each unit",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pack"
+                ],
+                "summary": "Pack items into boxes by their dimensions",
+                "operationId": "orderpacker-pack-v2\tpost",
+                "parameters": [
+                    {
+                        "description": "Items with dimensions",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.GeometryPackRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response with placements",
+                        "schema": {
+                            "$ref": "#/definitions/service.GeometryPackResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request data",
+                        "schema": {
+                            "$ref": "#/definitions/service.badRequestError"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/service.methodNotAllowedError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/service.internalServerError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "chart.Chart": {
+            "type": "object",
+            "properties": {
+                "boxes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer",
+                        "format": "uint"
+                    },
+                    "example": [
+                        250,
+                        500,
+                        1000,
+                        2000,
+                        5000
+                    ]
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/chart.Row"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Packing table"
+                }
+            }
+        },
+        "chart.Pack": {
+            "type": "object",
+            "properties": {
+                "box": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 500
+                },
+                "quantity": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 1
+                }
+            }
+        },
+        "chart.Row": {
+            "type": "object",
+            "properties": {
+                "cost": {
+                    "type": "number",
+                    "example": 0.65
+                },
+                "from": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 251
+                },
+                "pack_count": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 1
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/chart.Pack"
+                    }
+                },
+                "shipped": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 500
+                },
+                "to": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 500
+                }
+            }
+        },
+        "service.AlternativesResponse": {
+            "type": "object",
+            "properties": {
+                "alternatives": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.PackResponse"
+                    }
+                }
+            }
+        },
+        "service.BoxAnalysis": {
+            "type": "object",
+            "properties": {
+                "dominated": {
+                    "description": "Dominated are sizes the optimal solver never uses.",
+                    "type": "array",
+                    "items": {
+                        "type": "integer",
+                        "format": "uint"
+                    }
+                },
+                "frobenius": {
+                    "description": "Frobenius is the largest multiple of gcd that cannot be packed exactly, zero when every multiple can.\nIt is left out when the set is too large to compute it.",
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 0
+                },
+                "gcd": {
+                    "description": "GCD is the greatest common divisor of sizes, orders that are not its multiples are never packed exactly.",
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 250
+                },
+                "sizes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer",
+                        "format": "uint"
+                    },
+                    "example": [
+                        250,
+                        500,
+                        1000,
+                        2000,
+                        5000
+                    ]
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "service.BoxDefinition": {
+            "type": "object",
+            "properties": {
+                "cost": {
+                    "type": "number",
+                    "example": 0.4
+                },
+                "max_order": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 0
+                },
+                "max_quantity": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 1
+                },
+                "max_volume": {
+                    "type": "number",
+                    "example": 0.05
+                },
+                "max_weight": {
+                    "type": "number",
+                    "example": 20
+                },
+                "min_order": {
+                    "description": "MinOrder and MaxOrder bound the orders the box is used for, zero means no bound.",
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 1000
+                },
+                "min_quantity": {
+                    "description": "MinQuantity and MaxQuantity bound the number of boxes of the size per order, zero means no bound.",
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 0
+                },
+                "size": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 250
+                }
+            }
+        },
+        "service.BoxSet": {
+            "type": "object",
+            "properties": {
+                "boxes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.BoxDefinition"
+                    }
+                },
+                "previous": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.BoxDefinition"
+                    }
+                }
+            }
+        },
+        "service.Candidate": {
+            "type": "object",
+            "properties": {
+                "chosen": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "cost": {
+                    "type": "number",
+                    "example": 0
+                },
+                "overshoot": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 499
+                },
+                "pack_count": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 4
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.Pack"
+                    }
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "ships 250 more items"
+                },
+                "shipped": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 12500
+                }
+            }
+        },
+        "service.Explanation": {
+            "type": "object",
+            "properties": {
+                "candidates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.Candidate"
+                    }
+                },
+                "considered": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 20
+                },
+                "decision": {
+                    "type": "string",
+                    "example": "Ships 12250 items, the fewest of 20 reachable totals not less than the order (overshoot 249); no packing of 12250 items uses fewer than 4 packs."
+                },
+                "objective": {
+                    "type": "string",
+                    "example": "packs"
+                },
+                "strategy": {
+                    "type": "string",
+                    "example": "exact"
+                }
+            }
+        },
+        "service.GeometryBox": {
+            "type": "object",
+            "properties": {
+                "box": {
+                    "type": "string",
+                    "example": "large"
+                },
+                "fill": {
+                    "type": "number",
+                    "example": 0.69
+                },
+                "height": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 800
+                },
+                "length": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 1300
+                },
+                "placements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.Placement"
+                    }
+                },
+                "width": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 500
+                }
+            }
+        },
+        "service.GeometryItem": {
+            "type": "object",
+            "properties": {
+                "height": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 750
+                },
+                "id": {
+                    "type": "string",
+                    "example": "tv"
+                },
+                "length": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 1200
+                },
+                "quantity": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 2
+                },
+                "rotation": {
+                    "type": "string",
+                    "example": "upright"
+                },
+                "width": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 200
+                }
+            }
+        },
+        "service.GeometryPackRequest": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.GeometryItem"
+                    }
+                }
+            }
+        },
+        "service.GeometryPackResponse": {
+            "type": "object",
+            "properties": {
+                "boxes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.GeometryBox"
+                    }
+                },
+                "pack_count": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 1
+                },
+                "units": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 2
+                }
+            }
+        },
+        "service.Inventory": {
+            "type": "object",
+            "properties": {
+                "stock": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.StockLevel"
+                    }
+                }
+            }
+        },
+        "service.LevelPacks": {
+            "type": "object",
+            "properties": {
+                "backordered": {
+                    "description": "Backordered is the number of ordered items the policy leaves unshipped.",
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 0
+                },
+                "cost": {
+                    "type": "number",
+                    "example": 1.05
+                },
+                "explanation": {
+                    "description": "Explanation is returned when the request asks for it with explain=true query parameter.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.Explanation"
+                        }
+                    ]
+                },
+                "interrupted": {
+                    "description": "Interrupted is set when solving ran out of time and the packing is the best found by then.",
+                    "type": "boolean",
+                    "example": false
+                },
+                "items": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 543
+                },
+                "level": {
+                    "type": "string",
+                    "example": "carton"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.LinePacks"
+                    }
+                },
+                "overshoot": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 207
+                },
+                "pack_count": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 2
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.Pack"
+                    }
+                },
+                "shipments": {
+                    "description": "Shipments split the packs within the shipment limits, returned only when limits are set.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.Shipment"
+                    }
+                },
+                "shipped": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 750
+                },
+                "strategy": {
+                    "type": "string",
+                    "example": "exact"
+                }
+            }
+        },
+        "service.LineItem": {
+            "type": "object",
+            "properties": {
+                "quantity": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 3
+                },
+                "sku": {
+                    "type": "string",
+                    "example": "tv"
+                },
+                "unit_volume": {
+                    "type": "number",
+                    "example": 0.15
+                },
+                "unit_weight": {
+                    "type": "number",
+                    "example": 12.5
+                }
+            }
+        },
+        "service.LinePacks": {
+            "type": "object",
+            "properties": {
+                "backordered": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 0
+                },
+                "cost": {
+                    "type": "number",
+                    "example": 2.5
+                },
+                "explanation": {
+                    "description": "Explanation is returned when the request asks for it with explain=true query parameter.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.Explanation"
+                        }
+                    ]
+                },
+                "family": {
+                    "type": "string",
+                    "example": "large"
+                },
+                "items": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 3
+                },
+                "overshoot": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 1
+                },
+                "pack_count": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 1
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.Pack"
+                    }
+                },
+                "shipments": {
+                    "description": "Shipments split the packs within the shipment limits, returned only when limits are set.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.Shipment"
+                    }
+                },
+                "shipped": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 4
+                },
+                "sku": {
+                    "type": "string",
+                    "example": "tv"
+                },
+                "strategy": {
+                    "type": "string",
+                    "example": "exact"
+                }
+            }
+        },
+        "service.LineQuantity": {
+            "type": "object",
+            "properties": {
+                "quantity": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 15
+                },
+                "sku": {
+                    "type": "string",
+                    "example": "apple"
+                }
+            }
+        },
+        "service.MixedBox": {
+            "type": "object",
+            "properties": {
+                "box": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 50
+                },
+                "contents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.LineQuantity"
+                    }
+                },
+                "fill": {
+                    "$ref": "#/definitions/service.PackFill"
+                }
+            }
+        },
+        "service.MixedPackResponse": {
+            "type": "object",
+            "properties": {
+                "boxes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.MixedBox"
+                    }
+                },
+                "cost": {
+                    "type": "number",
+                    "example": 0.65
+                },
+                "items": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 42
+                },
+                "pack_count": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 1
+                }
+            }
+        },
+        "service.NestedPack": {
+            "type": "object",
+            "properties": {
+                "box": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 20
+                },
+                "contents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.NestedPack"
+                    }
+                },
+                "level": {
+                    "type": "string",
+                    "example": "pallet"
+                },
+                "quantity": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 1
+                }
+            }
+        },
+        "service.NestedPackResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 12001
+                },
+                "levels": {
+                    "description": "Levels hold packs of each level, the box level first. Items of a level are packs of the level below.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.LevelPacks"
                     }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Successful response with packs data",
-                        "schema": {
-                            "$ref": "#/definitions/service.PackResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid request data",
-                        "schema": {
-                            "$ref": "#/definitions/service.badRequestError"
-                        }
-                    },
-                    "405": {
-                        "description": "Method not allowed",
-                        "schema": {
-                            "$ref": "#/definitions/service.methodNotAllowedError"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal server error",
-                        "schema": {
-                            "$ref": "#/definitions/service.internalServerError"
-                        }
+                },
+                "packs": {
+                    "description": "Packs are the packs of the outermost level with their contents.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.NestedPack"
                     }
                 }
             }
-        }
-    },
-    "definitions": {
+        },
         "service.Pack": {
             "type": "object",
             "properties": {
@@ -86,6 +1400,9 @@ const docTemplate = `{
                     "format": "uint",
                     "example": 50
                 },
+                "fill": {
+                    "$ref": "#/definitions/service.PackFill"
+                },
                 "quantity": {
                     "type": "integer",
                     "format": "uint",
@@ -93,24 +1410,276 @@ const docTemplate = `{
                 }
             }
         },
+        "service.PackFill": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 40
+                },
+                "volume": {
+                    "type": "number",
+                    "example": 0.08
+                },
+                "volume_ratio": {
+                    "type": "number",
+                    "example": 0.8
+                },
+                "weight": {
+                    "type": "number",
+                    "example": 20
+                },
+                "weight_ratio": {
+                    "type": "number",
+                    "example": 1
+                }
+            }
+        },
         "service.PackRequest": {
             "type": "object",
             "properties": {
+                "commit": {
+                    "description": "Commit takes the packs off the inventory stock.",
+                    "type": "boolean",
+                    "example": false
+                },
+                "exact_fit": {
+                    "description": "ExactFit ships exactly the ordered items instead of the configured mode, an order boxes do not hold exactly\nis rejected with the nearest totals they hold.",
+                    "type": "boolean",
+                    "example": true
+                },
                 "items": {
                     "type": "integer",
                     "format": "uint",
                     "example": 543
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.LineItem"
+                    }
+                },
+                "policy": {
+                    "description": "Policy is how much of the order ships: up ships the whole order, down ships only full boxes\nand backorders the rest, nearest ships whichever is closer to the order.",
+                    "type": "string",
+                    "enum": [
+                        "up",
+                        "down",
+                        "nearest"
+                    ],
+                    "example": "up"
+                },
+                "shipment": {
+                    "description": "Shipment limits split the packing into shipments instead of the configured ones.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.ShipmentLimits"
+                        }
+                    ]
+                },
+                "unit_volume": {
+                    "type": "number",
+                    "example": 0.002
+                },
+                "unit_weight": {
+                    "description": "UnitWeight and UnitVolume measure a single item, boxes hold no more items than their limits allow.",
+                    "type": "number",
+                    "example": 0.5
                 }
             }
         },
         "service.PackResponse": {
             "type": "object",
             "properties": {
+                "backordered": {
+                    "description": "Backordered is the number of ordered items the policy leaves unshipped.",
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 0
+                },
+                "cost": {
+                    "type": "number",
+                    "example": 1.05
+                },
+                "explanation": {
+                    "description": "Explanation is returned when the request asks for it with explain=true query parameter.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.Explanation"
+                        }
+                    ]
+                },
+                "interrupted": {
+                    "description": "Interrupted is set when solving ran out of time and the packing is the best found by then.",
+                    "type": "boolean",
+                    "example": false
+                },
+                "items": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 543
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.LinePacks"
+                    }
+                },
+                "overshoot": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 207
+                },
+                "pack_count": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 2
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.Pack"
+                    }
+                },
+                "shipments": {
+                    "description": "Shipments split the packs within the shipment limits, returned only when limits are set.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.Shipment"
+                    }
+                },
+                "shipped": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 750
+                },
+                "strategy": {
+                    "type": "string",
+                    "example": "exact"
+                }
+            }
+        },
+        "service.Placement": {
+            "type": "object",
+            "properties": {
+                "height": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 750
+                },
+                "item": {
+                    "type": "string",
+                    "example": "tv"
+                },
+                "length": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 1200
+                },
+                "width": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 200
+                },
+                "x": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 0
+                },
+                "y": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 200
+                },
+                "z": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 0
+                }
+            }
+        },
+        "service.ProfilesResponse": {
+            "type": "object",
+            "properties": {
+                "default": {
+                    "type": "string",
+                    "example": "default"
+                },
+                "profiles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "default",
+                        "east"
+                    ]
+                }
+            }
+        },
+        "service.Shipment": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 5000
+                },
+                "pack_count": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 1
+                },
                 "packs": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/service.Pack"
                     }
+                },
+                "quantity": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 2
+                },
+                "weight": {
+                    "type": "number",
+                    "example": 2500
+                }
+            }
+        },
+        "service.ShipmentLimits": {
+            "type": "object",
+            "properties": {
+                "max_items": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 50000
+                },
+                "max_packs": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 20
+                },
+                "max_weight": {
+                    "description": "MaxWeight binds only when the request sets the unit weight.",
+                    "type": "number",
+                    "example": 1000
+                }
+            }
+        },
+        "service.StockLevel": {
+            "type": "object",
+            "properties": {
+                "box": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 250
+                },
+                "quantity": {
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 100
                 }
             }
         },
@@ -127,6 +1696,32 @@ const docTemplate = `{
                 }
             }
         },
+        "service.conflictError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 409
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Conflict"
+                }
+            }
+        },
+        "service.forbiddenError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 403
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Forbidden"
+                }
+            }
+        },
         "service.internalServerError": {
             "type": "object",
             "properties": {
@@ -152,6 +1747,100 @@ const docTemplate = `{
                     "example": "Method not allowed"
                 }
             }
+        },
+        "service.notFoundError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Not found"
+                }
+            }
+        },
+        "service.serviceUnavailableError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 503
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Service unavailable"
+                }
+            }
+        },
+        "service.unauthorizedError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 401
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Unauthorized"
+                }
+            }
+        },
+        "service.unprocessableEntityError": {
+            "type": "object",
+            "properties": {
+                "above": {
+                    "description": "Above is the smallest total over the order boxes hold, omitted when they hold none.",
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 11000
+                },
+                "below": {
+                    "description": "Below is the largest total under the order boxes hold, omitted when they hold none.",
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 10750
+                },
+                "box": {
+                    "description": "Box is the size of the least filled box of a packing that breaks the fill limits.",
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 250
+                },
+                "code": {
+                    "type": "integer",
+                    "example": 422
+                },
+                "fill": {
+                    "description": "Fill is the share of the least filled box the items in it take.",
+                    "type": "number",
+                    "example": 0.4
+                },
+                "items": {
+                    "description": "Items is the number of ordered items that have no exact fit.",
+                    "type": "integer",
+                    "format": "uint",
+                    "example": 10999
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Unprocessable entity"
+                },
+                "waste": {
+                    "description": "Waste is the share of the shipped capacity left empty.",
+                    "type": "number",
+                    "example": 0.04
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "AdminToken": {
+            "description": "Admin token as \"Bearer \u003ctoken\u003e\", set by ADMIN_TOKEN",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     },
     "externalDocs": {
@@ -0,0 +1,174 @@
+// Package chart renders packing tables for the floor as CSV, JSON or printable HTML.
+package chart
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/obalunenko/orderpacker/internal/packer"
+)
+
+// ErrUnknownFormat is returned for a format charts are not rendered in.
+var ErrUnknownFormat = errors.New("unknown chart format")
+
+// Format is how a chart is rendered.
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+	FormatHTML Format = "html"
+)
+
+// ParseFormat returns the format by its name.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatCSV, FormatJSON, FormatHTML:
+		return f, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownFormat, s)
+	}
+}
+
+// ContentType returns the media type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv"
+	case FormatHTML:
+		return "text/html"
+	default:
+		return "application/json"
+	}
+}
+
+//go:embed templates/chart.gohtml
+var htmlChart string
+
+var htmlTmpl = template.Must(template.New("chart").Parse(htmlChart))
+
+// Chart is a packing table of a box set.
+type Chart struct {
+	Title string `json:"title" example:"Packing table"`
+	Boxes []uint `json:"boxes" format:"uint" example:"250,500,1000,2000,5000"`
+	Rows  []Row  `json:"rows"`
+}
+
+// Row is a range of orders packed the same way.
+type Row struct {
+	From      uint    `json:"from" format:"uint" example:"251"`
+	To        uint    `json:"to" format:"uint" example:"500"`
+	Packs     []Pack  `json:"packs"`
+	Shipped   uint    `json:"shipped" format:"uint" example:"500"`
+	PackCount uint    `json:"pack_count" format:"uint" example:"1"`
+	Cost      float64 `json:"cost" example:"0.65"`
+}
+
+// Pack is the number of boxes of a size.
+type Pack struct {
+	Box      uint `json:"box" format:"uint" example:"500"`
+	Quantity uint `json:"quantity" format:"uint" example:"1"`
+}
+
+// New returns the chart of the packing table.
+func New(title string, boxes []packer.Box, table []packer.Breakpoint) Chart {
+	c := Chart{
+		Title: title,
+		Boxes: make([]uint, 0, len(boxes)),
+		Rows:  make([]Row, 0, len(table)),
+	}
+
+	for _, b := range boxes {
+		c.Boxes = append(c.Boxes, b.Size)
+	}
+
+	for _, bp := range table {
+		r := Row{
+			From:      bp.From,
+			To:        bp.To,
+			Packs:     make([]Pack, 0, len(bp.Packs)),
+			Shipped:   bp.Shipped,
+			PackCount: bp.PackCount,
+			Cost:      bp.Cost,
+		}
+
+		for _, q := range bp.Packs {
+			r.Packs = append(r.Packs, Pack{Box: q.Box, Quantity: q.Quantity})
+		}
+
+		c.Rows = append(c.Rows, r)
+	}
+
+	return c
+}
+
+// Range returns the range of orders, e.g. "251-500".
+func (r Row) Range() string {
+	if r.From == r.To {
+		return strconv.FormatUint(uint64(r.From), 10)
+	}
+
+	return fmt.Sprintf("%d-%d", r.From, r.To)
+}
+
+// Label returns the packs, e.g. "1x500 + 1x250".
+func (r Row) Label() string {
+	if len(r.Packs) == 0 {
+		return "-"
+	}
+
+	parts := make([]string, 0, len(r.Packs))
+
+	for _, p := range r.Packs {
+		parts = append(parts, fmt.Sprintf("%dx%d", p.Quantity, p.Box))
+	}
+
+	return strings.Join(parts, " + ")
+}
+
+// Write renders the chart in the format.
+func (c Chart) Write(w io.Writer, f Format) error {
+	switch f {
+	case FormatCSV:
+		return c.writeCSV(w)
+	case FormatJSON:
+		return json.NewEncoder(w).Encode(c)
+	case FormatHTML:
+		return htmlTmpl.Execute(w, c)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownFormat, f)
+	}
+}
+
+func (c Chart) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write([]string{"from", "to", "packs", "shipped", "pack_count", "cost"}); err != nil {
+		return err
+	}
+
+	for _, r := range c.Rows {
+		err := cw.Write([]string{
+			strconv.FormatUint(uint64(r.From), 10),
+			strconv.FormatUint(uint64(r.To), 10),
+			r.Label(),
+			strconv.FormatUint(uint64(r.Shipped), 10),
+			strconv.FormatUint(uint64(r.PackCount), 10),
+			strconv.FormatFloat(r.Cost, 'f', -1, 64),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
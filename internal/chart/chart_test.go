@@ -0,0 +1,88 @@
+package chart
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obalunenko/orderpacker/internal/packer"
+)
+
+func testChart() Chart {
+	return New("Packing table", packer.SizedBoxes([]uint{250, 500}), []packer.Breakpoint{
+		{From: 1, To: 250, Packs: []packer.BoxQuantity{{Box: 250, Quantity: 1}}, Shipped: 250, PackCount: 1},
+		{From: 251, To: 500, Packs: []packer.BoxQuantity{{Box: 500, Quantity: 1}}, Shipped: 500, PackCount: 1},
+		{From: 501, To: 501, Packs: []packer.BoxQuantity{{Box: 500, Quantity: 1}, {Box: 250, Quantity: 1}}, Shipped: 750, PackCount: 2, Cost: 0.5},
+	})
+}
+
+func TestChart_Write(t *testing.T) {
+	c := testChart()
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		require.NoError(t, c.Write(&buf, FormatCSV))
+
+		assert.Equal(t, `from,to,packs,shipped,pack_count,cost
+1,250,1x250,250,1,0
+251,500,1x500,500,1,0
+501,501,1x500 + 1x250,750,2,0.5
+`, buf.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		require.NoError(t, c.Write(&buf, FormatJSON))
+
+		var got Chart
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+
+		assert.Equal(t, c, got)
+	})
+
+	t.Run("html", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		require.NoError(t, c.Write(&buf, FormatHTML))
+
+		assert.Contains(t, buf.String(), "<td>251-500</td>")
+		assert.Contains(t, buf.String(), `<td class="packs">1x500 &#43; 1x250</td>`)
+		assert.Contains(t, buf.String(), "<td>501</td>")
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		assert.ErrorIs(t, c.Write(&buf, "pdf"), ErrUnknownFormat)
+	})
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    Format
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{name: "csv", s: "csv", want: FormatCSV, wantErr: assert.NoError},
+		{name: "upper case", s: "HTML", want: FormatHTML, wantErr: assert.NoError},
+		{name: "json", s: "json", want: FormatJSON, wantErr: assert.NoError},
+		{name: "unknown", s: "pdf", wantErr: assert.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFormat(tt.s)
+			if !tt.wantErr(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
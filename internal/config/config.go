@@ -3,29 +3,89 @@ package config
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/obalunenko/getenv"
 	"github.com/obalunenko/getenv/option"
 	log "github.com/obalunenko/logger"
 
+	"github.com/obalunenko/orderpacker/internal/inventory"
 	"github.com/obalunenko/orderpacker/internal/packer"
+	"github.com/obalunenko/orderpacker/internal/packer/geometry"
 )
 
 const (
-	portEnv   = "PORT"
-	hostEnv   = "HOST"
-	boxesEnv  = "PACK_BOXES"
-	levelEnv  = "LOG_LEVEL"
-	formatEnv = "LOG_FORMAT"
+	portEnv      = "PORT"
+	hostEnv      = "HOST"
+	adminEnv     = "ADMIN_TOKEN"
+	boxesEnv     = "PACK_BOXES"
+	boxesFileEnv = "PACK_BOXES_FILE"
+	boxesPollEnv = "PACK_BOXES_POLL"
+	strategyEnv  = "PACK_STRATEGY"
+	objectiveEnv = "PACK_OBJECTIVE"
+	penaltyEnv   = "PACK_OVERSHOOT_PENALTY"
+	stockEnv     = "PACK_STOCK"
+	familiesEnv  = "PACK_FAMILIES"
+	profilesEnv  = "PACK_PROFILES"
+	packLevelEnv = "PACK_LEVELS"
+	maxPacksEnv  = "PACK_SHIPMENT_MAX_PACKS"
+	maxItemsEnv  = "PACK_SHIPMENT_MAX_ITEMS"
+	maxWeightEnv = "PACK_SHIPMENT_MAX_WEIGHT"
+	minFillEnv   = "PACK_MIN_FILL"
+	maxWasteEnv  = "PACK_MAX_WASTE"
+	skusEnv      = "PACK_SKUS"
+	geometryEnv  = "PACK_GEOMETRY_BOXES"
+	timeoutEnv   = "PACK_TIMEOUT"
+	anytimeEnv   = "PACK_ANYTIME"
+	exactFitEnv  = "PACK_EXACT_FIT"
+	levelEnv     = "LOG_LEVEL"
+	formatEnv    = "LOG_FORMAT"
 )
 
 type httpConfig struct {
 	Port string `yaml:"port" json:"port"`
 	Host string `yaml:"host" json:"host"`
+	// AdminToken is the bearer token of endpoints changing the box set, they are refused when it is empty.
+	AdminToken string `yaml:"admin_token" json:"-"`
 }
 
 type packConfig struct {
-	Boxes []uint `yaml:"boxes" json:"boxes"`
+	Boxes []packer.Box `yaml:"boxes" json:"boxes"`
+	// BoxesFile holds the box set instead of Boxes, it is reloaded on SIGHUP and when it changes.
+	BoxesFile string `yaml:"boxes_file" json:"boxes_file"`
+	// BoxesPoll is how often BoxesFile is checked for changes, zero disables the checks.
+	BoxesPoll        time.Duration    `yaml:"boxes_poll" json:"boxes_poll"`
+	Strategy         string           `yaml:"strategy" json:"strategy"`
+	Objective        packer.Objective `yaml:"objective" json:"objective"`
+	OvershootPenalty float64          `yaml:"overshoot_penalty" json:"overshoot_penalty"`
+	// Stock is the number of boxes in stock per box size, sizes left out are in unlimited supply.
+	Stock map[uint]uint `yaml:"stock" json:"stock"`
+	// Families are named box sets for products that do not fit the default boxes.
+	Families map[string][]packer.Box `yaml:"families" json:"families"`
+	// Profiles are named box sets of orders that select them, e.g. of warehouses with different cartons.
+	// Boxes are the default profile.
+	Profiles map[string][]packer.Box `yaml:"profiles" json:"profiles"`
+	// Levels are packaging levels that nest boxes, innermost first, e.g. cartons of boxes and pallets of cartons.
+	Levels []packer.Level `yaml:"levels" json:"levels"`
+	// Shipment limits split packings into shipments, packings are not split when none is set.
+	Shipment packer.ShipmentLimits `yaml:"shipment" json:"shipment"`
+	// Fill limits reject packings with underfilled boxes or too much empty capacity, none is set by default.
+	Fill packer.FillLimits `yaml:"fill" json:"fill"`
+	// SKUs maps products to the box families they are packed in.
+	SKUs map[string]string `yaml:"skus" json:"skus"`
+	// GeometryBoxes are box types of 3D packing, it is not served when empty.
+	GeometryBoxes []geometry.Box `yaml:"geometry_boxes" json:"geometry_boxes"`
+	// Timeout limits the time solving an order takes, zero means no limit.
+	Timeout time.Duration `yaml:"timeout" json:"timeout"`
+	// Anytime makes solving that runs out of time return the best packing found by then instead of an error.
+	Anytime bool `yaml:"anytime" json:"anytime"`
+	// ExactFit makes orders ship exactly the ordered items unless the order sets otherwise.
+	ExactFit bool `yaml:"exact_fit" json:"exact_fit"`
 }
 
 type logConfig struct {
@@ -46,7 +106,10 @@ func DefaultConfig() *Config {
 			Host: "0.0.0.0",
 		},
 		Pack: packConfig{
-			Boxes: packer.DefaultBoxes,
+			Boxes:     packer.SizedBoxes(packer.DefaultBoxes),
+			BoxesPoll: 10 * time.Second,
+			Strategy:  packer.DefaultStrategy,
+			Objective: packer.DefaultObjective,
 		},
 		Log: logConfig{
 			Level:  "INFO",
@@ -59,7 +122,7 @@ func Load(ctx context.Context) (*Config, error) {
 	return loadFromEnv(ctx)
 }
 
-func loadEnv[T string | []uint](ctx context.Context, key string, defaultVal T, opts ...option.Option) (T, error) {
+func loadEnv[T string | float64](ctx context.Context, key string, defaultVal T, opts ...option.Option) (T, error) {
 	val, err := getenv.Env[T](key, opts...)
 	if err != nil {
 		if !errors.Is(err, getenv.ErrNotSet) {
@@ -77,6 +140,31 @@ func loadEnv[T string | []uint](ctx context.Context, key string, defaultVal T, o
 	return val, nil
 }
 
+// loadParsed loads env value and parses it with parse, e.g. box definitions "250:0.40,500:0.65"
+// with packer.ParseBoxes or stock levels "250:100,500:20" with inventory.Parse.
+func loadParsed[T any](ctx context.Context, key string, defaultVal T, parse func(string) (T, error)) (T, error) {
+	val, err := getenv.Env[string](key)
+	if err != nil {
+		if !errors.Is(err, getenv.ErrNotSet) {
+			return defaultVal, err
+		}
+
+		log.WithFields(ctx, log.Fields{
+			"env":     key,
+			"default": defaultVal,
+		}).Warn("Env not set - using default")
+
+		return defaultVal, nil
+	}
+
+	parsed, err := parse(val)
+	if err != nil {
+		return defaultVal, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return parsed, nil
+}
+
 func loadFromEnv(ctx context.Context) (*Config, error) {
 	var errs error
 
@@ -92,7 +180,118 @@ func loadFromEnv(ctx context.Context) (*Config, error) {
 		errs = errors.Join(errs, err)
 	}
 
-	boxes, err := loadEnv[[]uint](ctx, boxesEnv, dflt.Pack.Boxes, option.WithSeparator(","))
+	adminToken, err := loadEnv[string](ctx, adminEnv, dflt.HTTP.AdminToken)
+	if err != nil {
+		errs = errors.Join(errs, err)
+	}
+
+	boxes, err := loadParsed(ctx, boxesEnv, dflt.Pack.Boxes, packer.ParseBoxes)
+	if err != nil {
+		errs = errors.Join(errs, err)
+	}
+
+	boxesFile, err := loadEnv[string](ctx, boxesFileEnv, dflt.Pack.BoxesFile)
+	if err != nil {
+		errs = errors.Join(errs, err)
+	}
+
+	if boxesFile != "" {
+		boxes, err = LoadBoxesFile(boxesFile)
+		if err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+
+	boxesPoll, err := loadParsed(ctx, boxesPollEnv, dflt.Pack.BoxesPoll, time.ParseDuration)
+	if err != nil {
+		errs = errors.Join(errs, err)
+	}
+
+	strategy, err := loadEnv[string](ctx, strategyEnv, dflt.Pack.Strategy)
+	if err != nil {
+		errs = errors.Join(errs, err)
+	}
+
+	objective, err := loadEnv[string](ctx, objectiveEnv, string(dflt.Pack.Objective))
+	if err != nil {
+		errs = errors.Join(errs, err)
+	}
+
+	penalty, err := loadEnv[float64](ctx, penaltyEnv, dflt.Pack.OvershootPenalty)
+	if err != nil {
+		errs = errors.Join(errs, err)
+	}
+
+	stock, err := loadParsed(ctx, stockEnv, dflt.Pack.Stock, inventory.Parse)
+	if err != nil {
+		errs = errors.Join(errs, err)
+	}
+
+	families, err := loadParsed(ctx, familiesEnv, dflt.Pack.Families, packer.ParseFamilies)
+	if err != nil {
+		errs = errors.Join(errs, err)
+	}
+
+	profiles, err := loadParsed(ctx, profilesEnv, dflt.Pack.Profiles, packer.ParseFamilies)
+	if err != nil {
+		errs = errors.Join(errs, err)
+	}
+
+	if _, ok := profiles[packer.DefaultProfile]; ok {
+		errs = errors.Join(errs, fmt.Errorf("invalid %s: %q profile is set by %s", profilesEnv, packer.DefaultProfile, boxesEnv))
+	}
+
+	levels, err := loadParsed(ctx, packLevelEnv, dflt.Pack.Levels, packer.ParseLevels)
+	if err != nil {
+		errs = errors.Join(errs, err)
+	}
+
+	maxPacks, err := loadParsed(ctx, maxPacksEnv, dflt.Pack.Shipment.MaxPacks, parseUint)
+	if err != nil {
+		errs = errors.Join(errs, err)
+	}
+
+	maxItems, err := loadParsed(ctx, maxItemsEnv, dflt.Pack.Shipment.MaxItems, parseUint)
+	if err != nil {
+		errs = errors.Join(errs, err)
+	}
+
+	maxWeight, err := loadEnv[float64](ctx, maxWeightEnv, dflt.Pack.Shipment.MaxWeight)
+	if err != nil {
+		errs = errors.Join(errs, err)
+	}
+
+	minFill, err := loadEnv[float64](ctx, minFillEnv, dflt.Pack.Fill.MinFill)
+	if err != nil {
+		errs = errors.Join(errs, err)
+	}
+
+	maxWaste, err := loadEnv[float64](ctx, maxWasteEnv, dflt.Pack.Fill.MaxWaste)
+	if err != nil {
+		errs = errors.Join(errs, err)
+	}
+
+	skus, err := loadParsed(ctx, skusEnv, dflt.Pack.SKUs, packer.ParseSKUs)
+	if err != nil {
+		errs = errors.Join(errs, err)
+	}
+
+	geometryBoxes, err := loadParsed(ctx, geometryEnv, dflt.Pack.GeometryBoxes, geometry.ParseBoxes)
+	if err != nil {
+		errs = errors.Join(errs, err)
+	}
+
+	timeout, err := loadParsed(ctx, timeoutEnv, dflt.Pack.Timeout, time.ParseDuration)
+	if err != nil {
+		errs = errors.Join(errs, err)
+	}
+
+	anytime, err := loadParsed(ctx, anytimeEnv, dflt.Pack.Anytime, strconv.ParseBool)
+	if err != nil {
+		errs = errors.Join(errs, err)
+	}
+
+	exactFit, err := loadParsed(ctx, exactFitEnv, dflt.Pack.ExactFit, strconv.ParseBool)
 	if err != nil {
 		errs = errors.Join(errs, err)
 	}
@@ -113,11 +312,35 @@ func loadFromEnv(ctx context.Context) (*Config, error) {
 
 	return &Config{
 		HTTP: httpConfig{
-			Port: port,
-			Host: host,
+			Port:       port,
+			Host:       host,
+			AdminToken: adminToken,
 		},
 		Pack: packConfig{
-			Boxes: boxes,
+			Boxes:            boxes,
+			BoxesFile:        boxesFile,
+			BoxesPoll:        boxesPoll,
+			Strategy:         strategy,
+			Objective:        packer.Objective(objective),
+			OvershootPenalty: penalty,
+			Stock:            stock,
+			Families:         families,
+			Profiles:         profiles,
+			Levels:           levels,
+			Shipment: packer.ShipmentLimits{
+				MaxPacks:  maxPacks,
+				MaxItems:  maxItems,
+				MaxWeight: maxWeight,
+			},
+			Fill: packer.FillLimits{
+				MinFill:  minFill,
+				MaxWaste: maxWaste,
+			},
+			SKUs:          skus,
+			GeometryBoxes: geometryBoxes,
+			Timeout:       timeout,
+			Anytime:       anytime,
+			ExactFit:      exactFit,
 		},
 		Log: logConfig{
 			Level:  level,
@@ -125,3 +348,27 @@ func loadFromEnv(ctx context.Context) (*Config, error) {
 		},
 	}, nil
 }
+
+func parseUint(s string) (uint, error) {
+	n, err := strconv.ParseUint(s, 10, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint(n), nil
+}
+
+// LoadBoxesFile reads box definitions in PACK_BOXES format from the file, one or several per line.
+func LoadBoxesFile(path string) ([]packer.Box, error) {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read boxes file: %w", err)
+	}
+
+	boxes, err := packer.ParseBoxes(strings.ReplaceAll(string(b), "\n", ","))
+	if err != nil {
+		return nil, fmt.Errorf("invalid boxes file %s: %w", path, err)
+	}
+
+	return boxes, nil
+}
@@ -2,11 +2,16 @@ package config
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/obalunenko/orderpacker/internal/packer"
+	"github.com/obalunenko/orderpacker/internal/packer/geometry"
 	"github.com/obalunenko/orderpacker/internal/testlogger"
 )
 
@@ -15,7 +20,24 @@ func unsetEnv(tb testing.TB) {
 
 	tb.Setenv(portEnv, "")
 	tb.Setenv(hostEnv, "")
+	tb.Setenv(adminEnv, "")
 	tb.Setenv(boxesEnv, "")
+	tb.Setenv(boxesFileEnv, "")
+	tb.Setenv(boxesPollEnv, "")
+	tb.Setenv(strategyEnv, "")
+	tb.Setenv(objectiveEnv, "")
+	tb.Setenv(penaltyEnv, "")
+	tb.Setenv(stockEnv, "")
+	tb.Setenv(familiesEnv, "")
+	tb.Setenv(profilesEnv, "")
+	tb.Setenv(packLevelEnv, "")
+	tb.Setenv(maxPacksEnv, "")
+	tb.Setenv(maxItemsEnv, "")
+	tb.Setenv(maxWeightEnv, "")
+	tb.Setenv(skusEnv, "")
+	tb.Setenv(geometryEnv, "")
+	tb.Setenv(timeoutEnv, "")
+	tb.Setenv(anytimeEnv, "")
 	tb.Setenv(levelEnv, "")
 	tb.Setenv(formatEnv, "")
 }
@@ -55,6 +77,17 @@ func TestLoadDefault(t *testing.T) {
 
 			assert.Equal(t, expected, cfg)
 		})
+		t.Run("admin token", func(t *testing.T) {
+			t.Setenv(adminEnv, "secret")
+
+			cfg, err := Load(ctx)
+			require.NoError(t, err)
+
+			expected := DefaultConfig()
+			expected.HTTP.AdminToken = "secret"
+
+			assert.Equal(t, expected, cfg)
+		})
 		t.Run("boxes", func(t *testing.T) {
 			t.Setenv(boxesEnv, "1,2,3")
 
@@ -62,7 +95,18 @@ func TestLoadDefault(t *testing.T) {
 			require.NoError(t, err)
 
 			expected := DefaultConfig()
-			expected.Pack.Boxes = []uint{1, 2, 3}
+			expected.Pack.Boxes = packer.SizedBoxes([]uint{1, 2, 3})
+
+			assert.Equal(t, expected, cfg)
+		})
+		t.Run("boxes with costs", func(t *testing.T) {
+			t.Setenv(boxesEnv, "250:0.40,500:0.65")
+
+			cfg, err := Load(ctx)
+			require.NoError(t, err)
+
+			expected := DefaultConfig()
+			expected.Pack.Boxes = []packer.Box{{Size: 250, Cost: 0.4}, {Size: 500, Cost: 0.65}}
 
 			assert.Equal(t, expected, cfg)
 		})
@@ -74,6 +118,270 @@ func TestLoadDefault(t *testing.T) {
 
 			assert.Nil(t, cfg)
 		})
+		t.Run("boxes file", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "boxes")
+			require.NoError(t, os.WriteFile(path, []byte("250:0.40\n500:0.65,1000\n"), 0o600))
+
+			t.Setenv(boxesEnv, "1,2,3")
+			t.Setenv(boxesFileEnv, path)
+			t.Setenv(boxesPollEnv, "1m")
+
+			cfg, err := Load(ctx)
+			require.NoError(t, err)
+
+			expected := DefaultConfig()
+			expected.Pack.Boxes = []packer.Box{{Size: 250, Cost: 0.4}, {Size: 500, Cost: 0.65}, {Size: 1000}}
+			expected.Pack.BoxesFile = path
+			expected.Pack.BoxesPoll = time.Minute
+
+			assert.Equal(t, expected, cfg)
+		})
+		t.Run("boxes file - missing", func(t *testing.T) {
+			t.Setenv(boxesFileEnv, filepath.Join(t.TempDir(), "boxes"))
+
+			cfg, err := Load(ctx)
+			assert.Error(t, err)
+
+			assert.Nil(t, cfg)
+		})
+		t.Run("strategy", func(t *testing.T) {
+			t.Setenv(strategyEnv, "greedy")
+
+			cfg, err := Load(ctx)
+			require.NoError(t, err)
+
+			expected := DefaultConfig()
+			expected.Pack.Strategy = "greedy"
+
+			assert.Equal(t, expected, cfg)
+		})
+		t.Run("objective", func(t *testing.T) {
+			t.Setenv(objectiveEnv, "cost")
+
+			cfg, err := Load(ctx)
+			require.NoError(t, err)
+
+			expected := DefaultConfig()
+			expected.Pack.Objective = packer.ObjectiveCost
+
+			assert.Equal(t, expected, cfg)
+		})
+		t.Run("overshoot penalty", func(t *testing.T) {
+			t.Setenv(penaltyEnv, "0.002")
+
+			cfg, err := Load(ctx)
+			require.NoError(t, err)
+
+			expected := DefaultConfig()
+			expected.Pack.OvershootPenalty = 0.002
+
+			assert.Equal(t, expected, cfg)
+		})
+		t.Run("overshoot penalty - invalid value", func(t *testing.T) {
+			t.Setenv(penaltyEnv, "free")
+
+			cfg, err := Load(ctx)
+			assert.Error(t, err)
+
+			assert.Nil(t, cfg)
+		})
+		t.Run("stock", func(t *testing.T) {
+			t.Setenv(stockEnv, "250:100,5000:2")
+
+			cfg, err := Load(ctx)
+			require.NoError(t, err)
+
+			expected := DefaultConfig()
+			expected.Pack.Stock = map[uint]uint{250: 100, 5000: 2}
+
+			assert.Equal(t, expected, cfg)
+		})
+		t.Run("stock - invalid value", func(t *testing.T) {
+			t.Setenv(stockEnv, "250")
+
+			cfg, err := Load(ctx)
+			assert.Error(t, err)
+
+			assert.Nil(t, cfg)
+		})
+		t.Run("families", func(t *testing.T) {
+			t.Setenv(familiesEnv, "small=10,20;large=250:0.40")
+			t.Setenv(skusEnv, "apple=small,tv=large")
+
+			cfg, err := Load(ctx)
+			require.NoError(t, err)
+
+			expected := DefaultConfig()
+			expected.Pack.Families = map[string][]packer.Box{
+				"small": packer.SizedBoxes([]uint{10, 20}),
+				"large": {{Size: 250, Cost: 0.4}},
+			}
+			expected.Pack.SKUs = map[string]string{"apple": "small", "tv": "large"}
+
+			assert.Equal(t, expected, cfg)
+		})
+		t.Run("families - invalid value", func(t *testing.T) {
+			t.Setenv(familiesEnv, "small")
+
+			cfg, err := Load(ctx)
+			assert.Error(t, err)
+
+			assert.Nil(t, cfg)
+		})
+		t.Run("profiles", func(t *testing.T) {
+			t.Setenv(profilesEnv, "east=300,600;west=250:0.40")
+
+			cfg, err := Load(ctx)
+			require.NoError(t, err)
+
+			expected := DefaultConfig()
+			expected.Pack.Profiles = map[string][]packer.Box{
+				"east": packer.SizedBoxes([]uint{300, 600}),
+				"west": {{Size: 250, Cost: 0.4}},
+			}
+
+			assert.Equal(t, expected, cfg)
+		})
+		t.Run("profiles - default", func(t *testing.T) {
+			t.Setenv(profilesEnv, "default=300,600")
+
+			cfg, err := Load(ctx)
+			assert.Error(t, err)
+
+			assert.Nil(t, cfg)
+		})
+		t.Run("levels", func(t *testing.T) {
+			t.Setenv(packLevelEnv, "carton=4,8;pallet=10:12.5")
+
+			cfg, err := Load(ctx)
+			require.NoError(t, err)
+
+			expected := DefaultConfig()
+			expected.Pack.Levels = []packer.Level{
+				{Name: "carton", Boxes: packer.SizedBoxes([]uint{4, 8})},
+				{Name: "pallet", Boxes: []packer.Box{{Size: 10, Cost: 12.5}}},
+			}
+
+			assert.Equal(t, expected, cfg)
+		})
+		t.Run("levels - invalid value", func(t *testing.T) {
+			t.Setenv(packLevelEnv, "carton")
+
+			cfg, err := Load(ctx)
+			assert.Error(t, err)
+
+			assert.Nil(t, cfg)
+		})
+		t.Run("shipment limits", func(t *testing.T) {
+			t.Setenv(maxPacksEnv, "20")
+			t.Setenv(maxItemsEnv, "50000")
+			t.Setenv(maxWeightEnv, "1000.5")
+
+			cfg, err := Load(ctx)
+			require.NoError(t, err)
+
+			expected := DefaultConfig()
+			expected.Pack.Shipment = packer.ShipmentLimits{MaxPacks: 20, MaxItems: 50000, MaxWeight: 1000.5}
+
+			assert.Equal(t, expected, cfg)
+		})
+		t.Run("shipment limits - invalid value", func(t *testing.T) {
+			t.Setenv(maxPacksEnv, "-1")
+
+			cfg, err := Load(ctx)
+			assert.Error(t, err)
+
+			assert.Nil(t, cfg)
+		})
+		t.Run("fill limits", func(t *testing.T) {
+			t.Setenv(minFillEnv, "0.6")
+			t.Setenv(maxWasteEnv, "0.2")
+
+			cfg, err := Load(ctx)
+			require.NoError(t, err)
+
+			expected := DefaultConfig()
+			expected.Pack.Fill = packer.FillLimits{MinFill: 0.6, MaxWaste: 0.2}
+
+			assert.Equal(t, expected, cfg)
+		})
+		t.Run("fill limits - invalid value", func(t *testing.T) {
+			t.Setenv(minFillEnv, "60%")
+
+			cfg, err := Load(ctx)
+			assert.Error(t, err)
+
+			assert.Nil(t, cfg)
+		})
+		t.Run("skus - invalid value", func(t *testing.T) {
+			t.Setenv(skusEnv, "apple")
+
+			cfg, err := Load(ctx)
+			assert.Error(t, err)
+
+			assert.Nil(t, cfg)
+		})
+		t.Run("timeout", func(t *testing.T) {
+			t.Setenv(timeoutEnv, "250ms")
+			t.Setenv(anytimeEnv, "true")
+
+			cfg, err := Load(ctx)
+			require.NoError(t, err)
+
+			expected := DefaultConfig()
+			expected.Pack.Timeout = 250 * time.Millisecond
+			expected.Pack.Anytime = true
+
+			assert.Equal(t, expected, cfg)
+		})
+		t.Run("timeout - invalid value", func(t *testing.T) {
+			t.Setenv(timeoutEnv, "250")
+
+			cfg, err := Load(ctx)
+			assert.Error(t, err)
+
+			assert.Nil(t, cfg)
+		})
+		t.Run("exact fit", func(t *testing.T) {
+			t.Setenv(exactFitEnv, "true")
+
+			cfg, err := Load(ctx)
+			require.NoError(t, err)
+
+			expected := DefaultConfig()
+			expected.Pack.ExactFit = true
+
+			assert.Equal(t, expected, cfg)
+		})
+		t.Run("exact fit - invalid value", func(t *testing.T) {
+			t.Setenv(exactFitEnv, "always")
+
+			cfg, err := Load(ctx)
+			assert.Error(t, err)
+
+			assert.Nil(t, cfg)
+		})
+		t.Run("geometry boxes", func(t *testing.T) {
+			t.Setenv(geometryEnv, "small=300x200x150")
+
+			cfg, err := Load(ctx)
+			require.NoError(t, err)
+
+			expected := DefaultConfig()
+			expected.Pack.GeometryBoxes = []geometry.Box{
+				{Name: "small", Dimensions: geometry.Dimensions{Length: 300, Width: 200, Height: 150}},
+			}
+
+			assert.Equal(t, expected, cfg)
+		})
+		t.Run("geometry boxes - invalid value", func(t *testing.T) {
+			t.Setenv(geometryEnv, "small=300x200")
+
+			cfg, err := Load(ctx)
+			assert.Error(t, err)
+
+			assert.Nil(t, cfg)
+		})
 		t.Run("level", func(t *testing.T) {
 			t.Setenv(levelEnv, "DEBUG")
 
@@ -0,0 +1,96 @@
+// Package inventory tracks the stock of boxes.
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Inventory holds the number of available boxes per box size.
+// Box sizes without a stock record are considered to be in unlimited supply.
+type Inventory struct {
+	mu    sync.Mutex
+	stock map[uint]uint
+}
+
+// New returns inventory with given stock levels.
+func New(stock map[uint]uint) *Inventory {
+	return &Inventory{
+		stock: maps.Clone(stock),
+	}
+}
+
+// Stock returns a copy of stock levels per box size.
+func (inv *Inventory) Stock() map[uint]uint {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	return maps.Clone(inv.stock)
+}
+
+// Set replaces stock levels.
+func (inv *Inventory) Set(stock map[uint]uint) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	inv.stock = maps.Clone(stock)
+}
+
+// Update calls fn with a copy of stock levels and stores the copy if fn succeeds.
+// Stock levels do not change while fn runs.
+func (inv *Inventory) Update(fn func(stock map[uint]uint) error) error {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	stock := maps.Clone(inv.stock)
+	if stock == nil {
+		stock = make(map[uint]uint)
+	}
+
+	if err := fn(stock); err != nil {
+		return err
+	}
+
+	inv.stock = stock
+
+	return nil
+}
+
+// Parse parses comma separated stock levels in form "size:quantity", e.g. "250:100,500:20".
+func Parse(s string) (map[uint]uint, error) {
+	stock := make(map[uint]uint)
+
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		sizeStr, qtyStr, ok := strings.Cut(field, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid stock %q: expected size:quantity", field)
+		}
+
+		size, err := strconv.ParseUint(strings.TrimSpace(sizeStr), 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid box size %q: %w", field, err)
+		}
+
+		if size == 0 {
+			return nil, errors.New("box with zero volume")
+		}
+
+		qty, err := strconv.ParseUint(strings.TrimSpace(qtyStr), 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity %q: %w", field, err)
+		}
+
+		stock[uint(size)] = uint(qty)
+	}
+
+	return stock, nil
+}
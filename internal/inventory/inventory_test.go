@@ -0,0 +1,90 @@
+package inventory
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    map[uint]uint
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "stock",
+			in:      "250:100, 500:20,",
+			want:    map[uint]uint{250: 100, 500: 20},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "empty",
+			in:      "",
+			want:    map[uint]uint{},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "no quantity",
+			in:      "250",
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name:    "invalid quantity",
+			in:      "250:-1",
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name:    "zero size",
+			in:      "0:10",
+			want:    nil,
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.in)
+			if !tt.wantErr(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestInventory_Update(t *testing.T) {
+	stock := map[uint]uint{250: 10}
+
+	inv := New(stock)
+
+	stock[250] = 0
+	assert.Equal(t, map[uint]uint{250: 10}, inv.Stock(), "inventory must not share the map")
+
+	err := inv.Update(func(stock map[uint]uint) error {
+		stock[250] -= 3
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[uint]uint{250: 7}, inv.Stock())
+
+	errFail := errors.New("fail")
+
+	err = inv.Update(func(stock map[uint]uint) error {
+		stock[250] = 0
+
+		return errFail
+	})
+	require.ErrorIs(t, err, errFail)
+	assert.Equal(t, map[uint]uint{250: 7}, inv.Stock(), "failed update must not change stock")
+
+	inv.Set(map[uint]uint{500: 1})
+	assert.Equal(t, map[uint]uint{500: 1}, inv.Stock())
+}
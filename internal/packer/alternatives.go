@@ -0,0 +1,243 @@
+package packer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	log "github.com/obalunenko/logger"
+)
+
+// MaxAlternatives is the most packings Alternatives returns.
+const MaxAlternatives = 20
+
+// maxAlternativeNodes limits the search of alternatives, the best packings found by then are returned.
+// The search of packings that keep a condition is limited only by the context, so that none is missed.
+const maxAlternativeNodes = 1 << 22
+
+// Alternatives returns up to n best distinct packings of the order ranked by the objective, the best first.
+// Packings are searched like the optimal one, on the reduced problem: for a large order they share the bulk
+// of the most efficient box and differ in the rest. Alternatives are chosen from the stock but never committed.
+// The search is limited by the timeout of the packer, in anytime mode the packings found by then are returned.
+func (p Packer) Alternatives(ctx context.Context, items uint, n int, opts ...OrderOption) ([]PackResult, error) {
+	p = p.loaded()
+
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	if n < 1 || n > MaxAlternatives {
+		return nil, fmt.Errorf("number of alternatives %d is out of range [1, %d]", n, MaxAlternatives)
+	}
+
+	var o orderOptions
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var stock map[uint]uint
+
+	if p.inventory != nil {
+		stock = p.inventory.Stock()
+	}
+
+	prob, fits, err := p.problem(items, o.unit, stock)
+	if err != nil {
+		return nil, err
+	}
+
+	if items == 0 {
+		res := p.newPackResult(fits, nil, 0, o.unit)
+		res.Strategy = ""
+
+		return []PackResult{res}, nil
+	}
+
+	best, err := alternatives(ctx, prob, n, nil)
+
+	var interrupted bool
+
+	switch {
+	case err == nil:
+	case p.anytime && errors.Is(err, ErrInterrupted):
+		log.WithError(ctx, err).WithField("items", items).Warn("Search of alternatives interrupted, packings may be not the best")
+
+		interrupted = true
+	default:
+		return nil, err
+	}
+
+	results := make([]PackResult, 0, len(best))
+
+	for _, counts := range best {
+		res := p.newPackResult(fits, counts, items, o.unit)
+		res.Strategy = ""
+		res.Interrupted = interrupted
+
+		results = append(results, res)
+	}
+
+	return results, nil
+}
+
+// alternatives returns box counts of up to n best distinct packings of the problem ranked by its objective,
+// the best first. When keep is set, only packings it keeps are returned, searched until they run out.
+// When the context is done, the packings found by then are returned with an error wrapping ErrInterrupted,
+// as solvers do.
+func alternatives(ctx context.Context, prob Problem, n int, keep func(counts []uint) bool) ([][]uint, error) {
+	r := prob.reduce()
+
+	if r.capacity < r.target {
+		return nil, fmt.Errorf("%w: boxes hold %d items", ErrInsufficientStock, r.capacity*r.g)
+	}
+
+	s := kbestSearch{
+		reduced:  r,
+		stopper:  stopper{ctx: ctx},
+		n:        n,
+		keep:     keep,
+		limit:    r.limit(),
+		cur:      make([]uint, len(r.units)),
+		minRate:  make([]float64, len(r.units)),
+		capacity: make([]uint, len(r.units)),
+	}
+
+	for i := range r.units {
+		s.minRate[i] = r.weights[i] / float64(r.units[i])
+		s.capacity[i] = satMul(r.max[i], r.units[i])
+
+		if i > 0 {
+			s.minRate[i] = min(s.minRate[i], s.minRate[i-1])
+			s.capacity[i] = satAdd(s.capacity[i], s.capacity[i-1])
+		}
+	}
+
+	s.walk(len(r.units)-1, 0, 0, 0)
+
+	var err error
+
+	if s.stopped {
+		if len(s.best) == 0 {
+			return nil, interrupted(ctx, false)
+		}
+
+		err = interrupted(ctx, true)
+	}
+
+	if len(s.best) == 0 {
+		return nil, fmt.Errorf("%w: no combination of available boxes holds %d items", ErrInsufficientStock, prob.Items)
+	}
+
+	log.WithFields(ctx, log.Fields{
+		"items":        prob.Items,
+		"alternatives": len(s.best),
+		"nodes":        s.nodes,
+	}).Debug("Alternatives found")
+
+	counts := make([][]uint, 0, len(s.best))
+
+	for _, b := range s.best {
+		counts = append(counts, r.expand(b.counts))
+	}
+
+	return counts, err
+}
+
+// kbestSearch enumerates box counts from the largest box down, keeping the n best packings
+// and pruning branches that cannot beat the worst of them.
+type kbestSearch struct {
+	reduced
+	stopper
+
+	n     int
+	limit uint
+	// keep reports whether a packing of the problem counts, all do when it is nil.
+	keep func(counts []uint) bool
+
+	// minRate holds the lowest cost per unit among boxes up to the index.
+	minRate []float64
+	// capacity holds the number of units boxes up to the index can hold.
+	capacity []uint
+
+	cur     []uint
+	best    []rankedCounts
+	nodes   uint
+	stopped bool
+}
+
+type rankedCounts struct {
+	sc     score
+	counts []uint
+}
+
+func (s *kbestSearch) walk(i int, total uint, cost float64, packs uint) {
+	if s.stopped || s.stop() {
+		s.stopped = true
+
+		return
+	}
+
+	s.nodes++
+
+	if i < 0 {
+		if total >= s.target && (s.keep == nil || s.keep(s.expand(slices.Clone(s.cur)))) {
+			s.offer(s.score(total, cost, packs))
+		}
+
+		return
+	}
+
+	if s.keep == nil && s.nodes > maxAlternativeNodes || satAdd(total, s.capacity[i]) < s.target || !s.promising(i, total, cost, packs) {
+		return
+	}
+
+	u := s.units[i]
+	hi := min((s.limit-total)/u, s.max[i])
+
+	for c := hi; ; c-- {
+		s.cur[i] = c
+		s.walk(i-1, total+c*u, cost+float64(c)*s.weights[i], packs+c)
+
+		if c == 0 {
+			break
+		}
+	}
+
+	s.cur[i] = 0
+}
+
+// promising reports whether a packing with boxes up to i-th added may still get into the n best.
+func (s *kbestSearch) promising(i int, total uint, cost float64, packs uint) bool {
+	if len(s.best) < s.n {
+		return true
+	}
+
+	lb := s.score(total, cost, packs)
+	if total < s.target {
+		lb = score{
+			cost:  cost + float64(s.target-total)*s.minRate[i],
+			total: s.target,
+			packs: packs + ceilDiv(s.target-total, s.units[i]),
+		}
+	}
+
+	return lb.less(s.best[len(s.best)-1].sc)
+}
+
+func (s *kbestSearch) offer(sc score) {
+	i, _ := slices.BinarySearchFunc(s.best, sc, func(a rankedCounts, b score) int {
+		if a.sc.less(b) {
+			return -1
+		}
+
+		return 1
+	})
+
+	if i >= s.n {
+		return
+	}
+
+	s.best = slices.Insert(s.best, i, rankedCounts{sc: sc, counts: slices.Clone(s.cur)})
+	s.best = s.best[:min(len(s.best), s.n)]
+}
@@ -0,0 +1,172 @@
+package packer
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obalunenko/orderpacker/internal/inventory"
+	"github.com/obalunenko/orderpacker/internal/testlogger"
+)
+
+func TestPacker_Alternatives(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := NewPacker(ctx, WithDefaultBoxes())
+	require.NoError(t, err)
+
+	got, err := p.Alternatives(ctx, 12001, 2)
+	require.NoError(t, err)
+
+	require.Len(t, got, 2)
+	assert.Equal(t, []BoxQuantity{{Box: 5000, Quantity: 2}, {Box: 2000, Quantity: 1}, {Box: 250, Quantity: 1}}, got[0].Packs)
+	assert.Equal(t, []BoxQuantity{{Box: 5000, Quantity: 2}, {Box: 1000, Quantity: 2}, {Box: 250, Quantity: 1}}, got[1].Packs)
+	assert.Equal(t, uint(12250), got[1].Shipped)
+	assert.Equal(t, uint(5), got[1].PackCount)
+
+	_, err = p.Alternatives(ctx, 12001, 0)
+	assert.Error(t, err)
+
+	_, err = p.Alternatives(ctx, 12001, MaxAlternatives+1)
+	assert.Error(t, err)
+}
+
+func TestPacker_Alternatives_stock(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	inv := inventory.New(map[uint]uint{5000: 0, 2000: 1})
+
+	p, err := NewPacker(ctx, WithDefaultBoxes(), WithInventory(inv))
+	require.NoError(t, err)
+
+	got, err := p.Alternatives(ctx, 12001, 5)
+	require.NoError(t, err)
+
+	for _, res := range got {
+		for _, q := range res.Packs {
+			assert.NotEqual(t, uint(5000), q.Box)
+
+			if q.Box == 2000 {
+				assert.LessOrEqual(t, q.Quantity, uint(1))
+			}
+		}
+	}
+}
+
+func TestPacker_Alternatives_ranked(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	const n = 8
+
+	for _, objective := range []Objective{ObjectivePacks, ObjectiveCost} {
+		t.Run(string(objective), func(t *testing.T) {
+			boxes := []Box{{Size: 23, Cost: 1}, {Size: 31, Cost: 1.2}, {Size: 53, Cost: 2.5}}
+
+			p, err := NewPacker(ctx, WithBoxSet(boxes), WithObjective(objective), WithOvershootPenalty(0.01))
+			require.NoError(t, err)
+
+			for items := uint(1); items <= 300; items++ {
+				got, err := p.Alternatives(ctx, items, n)
+				require.NoError(t, err)
+
+				want := allScores(p, boxes, items)
+				require.GreaterOrEqual(t, len(want), len(got))
+
+				seen := make(map[[3]uint]bool, len(got))
+
+				for i, res := range got {
+					counts := [3]uint{}
+					for _, q := range res.Packs {
+						counts[slices.IndexFunc(boxes, func(b Box) bool { return b.Size == q.Box })] = q.Quantity
+					}
+
+					require.False(t, seen[counts], "items %d: duplicated packing %v", items, res.Packs)
+					seen[counts] = true
+
+					sc := p.scoreOf(res)
+					assert.False(t, sc.less(want[i]) || want[i].less(sc), "items %d: alternative %d scores %v, want %v", items, i, sc, want[i])
+				}
+			}
+		})
+	}
+}
+
+// allScores returns sorted scores of all packings shipping less than the largest box above the order.
+func allScores(p *Packer, boxes []Box, items uint) []score {
+	largest := boxes[len(boxes)-1].Size
+
+	var scores []score
+
+	var walk func(i int, counts []uint, total uint)
+
+	walk = func(i int, counts []uint, total uint) {
+		if i < 0 {
+			if total >= items && total < items+largest {
+				res := p.newPackResult(fitsOf(boxes), counts, items, Unit{})
+				scores = append(scores, p.scoreOf(res))
+			}
+
+			return
+		}
+
+		for c := uint(0); total+c*boxes[i].Size < items+largest; c++ {
+			counts[i] = c
+			walk(i-1, counts, total+c*boxes[i].Size)
+		}
+
+		counts[i] = 0
+	}
+
+	walk(len(boxes)-1, make([]uint, len(boxes)), 0)
+
+	slices.SortFunc(scores, func(a, b score) int {
+		switch {
+		case a.less(b):
+			return -1
+		case b.less(a):
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	return scores
+}
+
+func fitsOf(boxes []Box) []fit {
+	fits := make([]fit, 0, len(boxes))
+
+	for _, b := range boxes {
+		fits = append(fits, fit{box: b, capacity: b.Size})
+	}
+
+	return fits
+}
+
+func TestPacker_Alternatives_timeout(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	boxes := []uint{9973, 10007, 10009, 10037}
+
+	p, err := NewPacker(ctx, WithBoxes(boxes), WithTimeout(time.Nanosecond))
+	require.NoError(t, err)
+
+	_, err = p.Alternatives(ctx, 5000000, MaxAlternatives)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	p, err = NewPacker(ctx, WithBoxes(boxes), WithTimeout(time.Nanosecond), WithAnytime(true))
+	require.NoError(t, err)
+
+	got, err := p.Alternatives(ctx, 5000000, MaxAlternatives)
+	require.NoError(t, err)
+	require.NotEmpty(t, got)
+
+	for _, res := range got {
+		assert.True(t, res.Interrupted)
+		assert.GreaterOrEqual(t, res.Shipped, uint(5000000))
+	}
+}
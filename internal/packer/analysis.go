@@ -0,0 +1,237 @@
+package packer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+const (
+	// typoDivisor is the least common divisor of other sizes that makes a size breaking it look like a typo.
+	typoDivisor = 10
+	// typoGap is the least ratio of neighbouring sizes that makes the larger one look like a typo.
+	typoGap = 100
+)
+
+// Analysis describes how a box set packs orders.
+type Analysis struct {
+	// Sizes are box sizes, ascending.
+	Sizes []uint
+	// GCD is the greatest common divisor of sizes. Orders that are not its multiples are never packed exactly.
+	GCD uint
+	// Frobenius is the largest multiple of GCD that cannot be packed exactly, zero when every multiple can.
+	// Nil when the set is too large to compute it.
+	Frobenius *uint
+	// Dominated are sizes the exact solver never uses: other boxes pack any order at least as well.
+	Dominated []uint
+	// Warnings point at sizes that are likely typos and at parts of the analysis that were skipped.
+	Warnings []string
+}
+
+// Analyze returns the analysis of the box set with unlimited supply of every box, usage rules of boxes are ignored.
+// Dominated sizes depend on the objective and the overshoot penalty of the packer.
+func (p Packer) Analyze() Analysis {
+	p = p.loaded()
+	p.boxes = withoutRules(p.boxes)
+
+	prob, _, err := p.problem(1, Unit{}, nil)
+	if err != nil {
+		return Analysis{Warnings: []string{err.Error()}}
+	}
+
+	a := Analysis{
+		Sizes: sizesOf(prob.Boxes),
+		GCD:   gcdOf(sizesOf(prob.Boxes)),
+	}
+
+	r := prob.reduce()
+
+	if f, ok := frobenius(r.units); ok {
+		f = satMul(f, r.g)
+		a.Frobenius = &f
+	} else {
+		a.Warnings = append(a.Warnings, fmt.Sprintf("the largest order that cannot be packed exactly is not computed: smallest box holds %d multiples of %d", r.units[0], r.g))
+	}
+
+	dominated, err := dominated(r, prob.Boxes)
+	if err != nil {
+		a.Warnings = append(a.Warnings, fmt.Sprintf("dominated sizes are not computed: %v", err))
+	}
+
+	a.Dominated = dominated
+
+	for _, size := range dominated {
+		a.Warnings = append(a.Warnings, fmt.Sprintf("size %d is never used by the optimal solver", size))
+	}
+
+	a.Warnings = append(a.Warnings, typos(a.Sizes)...)
+
+	return a
+}
+
+// frobenius returns the largest number of units that cannot be composed, zero if every number can.
+// It runs the round robin algorithm over remainders of the smallest unit: for each remainder it keeps
+// the least composable total and adds units one by one along the cycles of remainders they walk.
+func frobenius(units []uint) (uint, bool) {
+	a := units[0]
+	if satMul(a, uint(len(units))) > maxTableSize {
+		return 0, false
+	}
+
+	least := make([]uint, a)
+	for i := range least {
+		least[i] = Unlimited
+	}
+
+	least[0] = 0
+
+	for _, u := range units[1:] {
+		d := gcd(a, u)
+
+		for start := range d {
+			// The cycle starts at its least total, nothing composes below it.
+			n := uint(Unlimited)
+
+			for q := start; q < a; q += d {
+				n = min(n, least[q])
+			}
+
+			if n == Unlimited {
+				continue
+			}
+
+			for range a / d {
+				n = satAdd(n, u)
+				if n == Unlimited {
+					return 0, false
+				}
+
+				n = min(n, least[n%a])
+				least[n%a] = n
+			}
+		}
+	}
+
+	largest := slices.Max(least)
+	if largest == Unlimited {
+		return 0, false
+	}
+
+	if largest == 0 {
+		return 0, true
+	}
+
+	return largest - a, true
+}
+
+// dominated returns sizes of boxes that the exact solver leaves out of the packing of every order.
+//
+// Orders above the bound of reduce are packed as a smaller order plus the most efficient boxes,
+// so the orders up to the bound and one more most efficient box show every box the solver uses.
+// The best total of an order is picked as in exactSolver.Solve by a sliding window minimum over totals,
+// then the boxes of chosen totals are traced back through the table.
+func dominated(r reduced, boxes []Box) ([]uint, error) {
+	e := r.units[r.eff]
+
+	bound := r.bound()
+	if bound >= maxTableSize {
+		return nil, fmt.Errorf("%w: more than %d totals for boxes %v", ErrTableTooLarge, bound, sizesOf(boxes))
+	}
+
+	r.target = bound + e
+
+	limit := r.limit()
+	if err := checkTableSize(r, limit, boxes); err != nil {
+		return nil, err
+	}
+
+	t, err := newExactTable(context.Background(), r, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// key ranks totals the same way for any order, the overshoot penalty grows with the total.
+	key := func(s uint) score {
+		c := t.cells[s]
+
+		return score{cost: c.cost + r.penalty*float64(s), total: s, packs: uint(c.packs)}
+	}
+
+	chosen := make([]bool, limit+1)
+	window := make([]uint, 0, limit+1)
+	head := 0
+
+	for s := limit; s >= 1; s-- {
+		if t.cells[s].packs != unreachable {
+			for len(window) > head && key(s).less(key(window[len(window)-1])) {
+				window = window[:len(window)-1]
+			}
+
+			window = append(window, s)
+		}
+
+		for len(window) > head && window[head] > s+r.largest()-1 {
+			head++
+		}
+
+		if s <= r.target && len(window) > head {
+			chosen[window[head]] = true
+		}
+	}
+
+	used := make([]bool, len(r.units))
+	used[r.eff] = true
+
+	for i := len(r.units) - 1; i >= 0; i-- {
+		next := make([]bool, limit+1)
+
+		for s, ok := range chosen {
+			if !ok {
+				continue
+			}
+
+			n := uint(t.used[i][s])
+			if n != 0 {
+				used[i] = true
+			}
+
+			next[uint(s)-n*r.units[i]] = true
+		}
+
+		chosen = next
+	}
+
+	var sizes []uint
+
+	for i, ok := range used {
+		if !ok {
+			sizes = append(sizes, boxes[i].Size)
+		}
+	}
+
+	return sizes, nil
+}
+
+// typos returns warnings about sizes that are larger than the common divisor of all other sizes but break it,
+// or are far larger than the next smaller size.
+func typos(sizes []uint) []string {
+	var warnings []string
+
+	if len(sizes) > 2 {
+		for i, size := range sizes {
+			rest := gcdOf(slices.Delete(slices.Clone(sizes), i, i+1))
+
+			if rest >= typoDivisor && size > rest && size%rest != 0 {
+				warnings = append(warnings, fmt.Sprintf("size %d is not a multiple of %d unlike all other sizes, likely a typo", size, rest))
+			}
+		}
+	}
+
+	for i := 1; i < len(sizes); i++ {
+		if sizes[i]/sizes[i-1] >= typoGap {
+			warnings = append(warnings, fmt.Sprintf("size %d is %d times larger than the next smaller size %d, likely a typo", sizes[i], sizes[i]/sizes[i-1], sizes[i-1]))
+		}
+	}
+
+	return warnings
+}
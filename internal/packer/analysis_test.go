@@ -0,0 +1,206 @@
+package packer
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obalunenko/orderpacker/internal/testlogger"
+)
+
+func TestPacker_Analyze(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	uintPtr := func(v uint) *uint {
+		return &v
+	}
+
+	tests := []struct {
+		name string
+		opts []PackerOption
+		want Analysis
+	}{
+		{
+			name: "default",
+			opts: []PackerOption{WithDefaultBoxes()},
+			want: Analysis{
+				Sizes:     DefaultBoxes,
+				GCD:       250,
+				Frobenius: uintPtr(0),
+			},
+		},
+		{
+			name: "custom[6,9,20]",
+			opts: []PackerOption{WithBoxes([]uint{6, 9, 20})},
+			want: Analysis{
+				Sizes:     []uint{6, 9, 20},
+				GCD:       1,
+				Frobenius: uintPtr(43),
+			},
+		},
+		{
+			name: "custom[500,750] gcd",
+			opts: []PackerOption{WithBoxes([]uint{500, 750})},
+			want: Analysis{
+				Sizes:     []uint{500, 750},
+				GCD:       250,
+				Frobenius: uintPtr(250),
+			},
+		},
+		{
+			name: "expensive large box",
+			opts: []PackerOption{
+				WithBoxSet([]Box{{Size: 10, Cost: 1}, {Size: 20, Cost: 3}}),
+				WithObjective(ObjectiveCost),
+			},
+			want: Analysis{
+				Sizes:     []uint{10, 20},
+				GCD:       10,
+				Frobenius: uintPtr(0),
+				Dominated: []uint{20},
+				Warnings:  []string{"size 20 is never used by the optimal solver"},
+			},
+		},
+		{
+			name: "typo breaks divisor",
+			opts: []PackerOption{WithBoxes([]uint{250, 500, 1001, 2000})},
+			want: Analysis{
+				Sizes:     []uint{250, 500, 1001, 2000},
+				GCD:       1,
+				Frobenius: uintPtr(248999),
+				Warnings:  []string{"size 1001 is not a multiple of 250 unlike all other sizes, likely a typo"},
+			},
+		},
+		{
+			name: "typo extra zero",
+			opts: []PackerOption{WithBoxes([]uint{250, 500, 100000})},
+			want: Analysis{
+				Sizes:     []uint{250, 500, 100000},
+				GCD:       250,
+				Frobenius: uintPtr(0),
+				Warnings:  []string{"size 100000 is 200 times larger than the next smaller size 500, likely a typo"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPacker(ctx, tt.opts...)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, p.Analyze())
+		})
+	}
+}
+
+func TestPacker_Analyze_frobenius(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	for _, boxes := range [][]uint{{23, 31, 53}, {4, 7}, {6, 10, 15}, {12, 18, 27, 40}, {1, 5}, {9}} {
+		p, err := NewPacker(ctx, WithBoxes(boxes))
+		require.NoError(t, err)
+
+		got := p.Analyze()
+		require.NotNil(t, got.Frobenius)
+
+		// Every multiple of gcd above the largest box times the smallest one packs exactly.
+		limit := boxes[0] * boxes[len(boxes)-1]
+		exact := make([]bool, limit+1)
+		exact[0] = true
+
+		var want uint
+
+		for s := uint(1); s <= limit; s++ {
+			for _, b := range boxes {
+				if b <= s && exact[s-b] {
+					exact[s] = true
+				}
+			}
+
+			if !exact[s] && s%got.GCD == 0 {
+				want = s
+			}
+		}
+
+		assert.Equalf(t, want, *got.Frobenius, "boxes %v", boxes)
+	}
+}
+
+func TestPacker_Analyze_dominated(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	tests := []struct {
+		name      string
+		boxes     []Box
+		objective Objective
+		penalty   float64
+		maxItems  uint
+	}{
+		{
+			name:      "custom[23,31,53]",
+			boxes:     []Box{{Size: 23}, {Size: 31}, {Size: 53}},
+			objective: ObjectivePacks,
+			maxItems:  3000,
+		},
+		{
+			name:      "custom[5,6,7,12]",
+			boxes:     SizedBoxes([]uint{5, 6, 7, 12}),
+			objective: ObjectivePacks,
+			maxItems:  500,
+		},
+		{
+			name:      "custom[23,31,53] expensive large",
+			boxes:     []Box{{Size: 23, Cost: 1}, {Size: 31, Cost: 1.5}, {Size: 53, Cost: 3}},
+			objective: ObjectiveCost,
+			maxItems:  3000,
+		},
+		{
+			name:      "custom[6,9,20] with penalty",
+			boxes:     []Box{{Size: 6, Cost: 2}, {Size: 9, Cost: 2.5}, {Size: 20, Cost: 5}},
+			objective: ObjectiveCost,
+			penalty:   0.3,
+			maxItems:  1000,
+		},
+		{
+			name:      "custom[4,7,8] free small",
+			boxes:     []Box{{Size: 4}, {Size: 7, Cost: 1}, {Size: 8, Cost: 0.5}},
+			objective: ObjectiveCost,
+			penalty:   0.1,
+			maxItems:  500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPacker(ctx, WithBoxSet(tt.boxes), WithObjective(tt.objective), WithOvershootPenalty(tt.penalty))
+			require.NoError(t, err)
+
+			used := make(map[uint]bool)
+
+			for items := uint(1); items <= tt.maxItems; items++ {
+				res, err := p.PackOrder(ctx, items)
+				require.NoError(t, err)
+
+				for _, q := range res.Packs {
+					used[q.Box] = true
+				}
+			}
+
+			var want []uint
+
+			for _, b := range tt.boxes {
+				if !used[b.Size] {
+					want = append(want, b.Size)
+				}
+			}
+
+			got := p.Analyze()
+
+			assert.Equal(t, want, got.Dominated)
+			assert.True(t, slices.IsSorted(got.Sizes))
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package packer
+
+import (
+	"context"
+	"fmt"
+)
+
+type branchAndBoundSolver struct{}
+
+func (branchAndBoundSolver) Name() string {
+	return StrategyBranchAndBound
+}
+
+// Solve searches box counts from the largest box down, pruning branches that cannot beat the best
+// packing found so far. Like the exact solver it works on the reduced problem.
+// The first branch takes the most of the largest boxes, so a packing is found early and
+// interrupted search returns the best one found.
+func (branchAndBoundSolver) Solve(ctx context.Context, prob Problem) ([]uint, error) {
+	counts := make([]uint, len(prob.Boxes))
+
+	if prob.Items == 0 || len(prob.Boxes) == 0 {
+		return counts, nil
+	}
+
+	r := prob.reduce()
+
+	if r.capacity < r.target {
+		return nil, fmt.Errorf("%w: boxes hold %d items", ErrInsufficientStock, r.capacity*r.g)
+	}
+
+	s := bnbSearch{
+		reduced:  r,
+		cur:      make([]uint, len(r.units)),
+		best:     counts,
+		minRate:  make([]float64, len(r.units)),
+		capacity: make([]uint, len(r.units)),
+		stopper:  stopper{ctx: ctx},
+	}
+
+	for i := range r.units {
+		s.minRate[i] = r.weights[i] / float64(r.units[i])
+		s.capacity[i] = satMul(r.max[i], r.units[i])
+
+		if i > 0 {
+			s.minRate[i] = min(s.minRate[i], s.minRate[i-1])
+			s.capacity[i] = satAdd(s.capacity[i], s.capacity[i-1])
+		}
+
+		if r.weights[i] != 0 {
+			s.costly = true
+		}
+	}
+
+	s.walk(len(r.units)-1, 0, 0, 0)
+
+	if s.stopped {
+		if !s.found {
+			return nil, interrupted(ctx, false)
+		}
+
+		return r.expand(s.best), interrupted(ctx, true)
+	}
+
+	if !s.found {
+		return nil, fmt.Errorf("%w: no combination of available boxes holds %d items", ErrInsufficientStock, prob.Items)
+	}
+
+	return r.expand(s.best), nil
+}
+
+type bnbSearch struct {
+	reduced
+
+	// minRate holds the lowest cost per unit among boxes up to the index.
+	minRate []float64
+	// capacity holds the number of units boxes up to the index can hold.
+	capacity []uint
+	// costly is set when boxes have costs.
+	costly bool
+
+	stopper
+	// stopped is set when the context is done, the search unwinds then.
+	stopped bool
+
+	cur []uint
+
+	best      []uint
+	bestScore score
+	found     bool
+}
+
+func (s *bnbSearch) walk(i int, total uint, cost float64, packs uint) {
+	if s.stopped || s.stop() {
+		s.stopped = true
+
+		return
+	}
+
+	if total >= s.target {
+		s.offer(s.score(total, cost, packs))
+
+		return
+	}
+
+	if i < 0 || satAdd(total, s.capacity[i]) < s.target {
+		return
+	}
+
+	rest := s.target - total
+	u := s.units[i]
+	hi := min(ceilDiv(rest, u), s.max[i])
+
+	if i == 0 {
+		// The smallest box has to cover the rest.
+		if hi*u < rest {
+			return
+		}
+
+		s.cur[0] = hi
+		s.offer(s.score(total+hi*u, cost+float64(hi)*s.weights[0], packs+hi))
+		s.cur[0] = 0
+
+		return
+	}
+
+	for c := hi; ; c-- {
+		t := total + c*u
+		w := cost + float64(c)*s.weights[i]
+		p := packs + c
+
+		lb := s.score(t, w, p)
+		if t < s.target {
+			lb = score{
+				cost:  w + float64(s.target-t)*s.minRate[i-1],
+				total: s.target,
+				packs: p + ceilDiv(s.target-t, s.units[i-1]),
+			}
+		}
+
+		if !s.found || lb.less(s.bestScore) {
+			s.cur[i] = c
+			s.walk(i-1, t, w, p)
+		} else if !s.costly && t <= s.target {
+			// Without costs the lower bound only grows for fewer boxes from here.
+			break
+		}
+
+		if c == 0 || s.stopped {
+			break
+		}
+	}
+
+	s.cur[i] = 0
+}
+
+func (s *bnbSearch) offer(sc score) {
+	if s.found && !sc.less(s.bestScore) {
+		return
+	}
+
+	s.bestScore, s.found = sc, true
+
+	copy(s.best, s.cur)
+}
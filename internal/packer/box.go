@@ -0,0 +1,256 @@
+package packer
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrNoEligibleBox is returned when usage rules of boxes leave no box for the order.
+var ErrNoEligibleBox = errors.New("no box is used for the order")
+
+// Box is a pack definition.
+type Box struct {
+	// Size is the number of items the box holds.
+	Size uint
+	// Cost is the price of one box.
+	Cost float64
+	// MaxWeight is the heaviest load the box takes, zero means no limit.
+	MaxWeight float64
+	// MaxVolume is the largest volume of items the box takes, zero means no limit.
+	MaxVolume float64
+	// MinQuantity is the least number of boxes of the size a packing uses, zero means none is required.
+	MinQuantity uint
+	// MaxQuantity is the most boxes of the size a packing uses, zero means no limit.
+	MaxQuantity uint
+	// MinOrder is the smallest order the box is used for, zero means no bound.
+	MinOrder uint
+	// MaxOrder is the largest order the box is used for, zero means no bound.
+	MaxOrder uint
+}
+
+// eligible reports whether the box is used for an order of the given number of items.
+// An empty order takes no boxes, every box is eligible for it.
+func (b Box) eligible(items uint) bool {
+	return items == 0 || items >= b.MinOrder && (b.MaxOrder == 0 || items <= b.MaxOrder)
+}
+
+// validateRules returns an error for contradictory usage rules of the box.
+func (b Box) validateRules() error {
+	if b.MaxQuantity != 0 && b.MinQuantity > b.MaxQuantity {
+		return fmt.Errorf("box %d has min quantity %d above max quantity %d", b.Size, b.MinQuantity, b.MaxQuantity)
+	}
+
+	if b.MaxOrder != 0 && b.MinOrder > b.MaxOrder {
+		return fmt.Errorf("box %d has min order %d above max order %d", b.Size, b.MinOrder, b.MaxOrder)
+	}
+
+	if satMul(b.MinQuantity, b.Size) == Unlimited {
+		return fmt.Errorf("box %d has min quantity %d holding too many items", b.Size, b.MinQuantity)
+	}
+
+	return nil
+}
+
+// withoutRules returns copy of boxes without usage rules.
+func withoutRules(boxes []Box) []Box {
+	res := make([]Box, 0, len(boxes))
+
+	for _, b := range boxes {
+		b.MinQuantity, b.MaxQuantity, b.MinOrder, b.MaxOrder = 0, 0, 0, 0
+		res = append(res, b)
+	}
+
+	return res
+}
+
+// checkEligibility returns an error when some orders have no box to be packed in.
+func checkEligibility(boxes []Box) error {
+	type span struct{ from, to uint }
+
+	spans := make([]span, 0, len(boxes))
+
+	for _, b := range boxes {
+		sp := span{from: max(b.MinOrder, 1), to: b.MaxOrder}
+		if sp.to == 0 {
+			sp.to = math.MaxUint
+		}
+
+		spans = append(spans, sp)
+	}
+
+	slices.SortFunc(spans, func(a, b span) int {
+		return cmp.Compare(a.from, b.from)
+	})
+
+	// Orders up to covered have a box.
+	var covered uint
+
+	for _, sp := range spans {
+		if sp.from > covered+1 {
+			return fmt.Errorf("no box is used for orders of %d to %d items", covered+1, sp.from-1)
+		}
+
+		covered = max(covered, sp.to)
+		if covered == math.MaxUint {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no box is used for orders above %d items", covered)
+}
+
+// SizedBoxes returns boxes of given sizes at no cost.
+func SizedBoxes(sizes []uint) []Box {
+	boxes := make([]Box, 0, len(sizes))
+
+	for _, s := range sizes {
+		boxes = append(boxes, Box{Size: s})
+	}
+
+	return boxes
+}
+
+// ParseBoxes parses comma separated box definitions in form "size[:cost[:max_weight[:max_volume]]] [rules]",
+// e.g. "250:0.40,500:0.65,1000::20:0.05". Omitted or empty values are zero.
+// Space separated rules limit the use of the box: "qty=min..max" boxes per packing and "order=min..max" items
+// of orders it is used for, either bound may be omitted and a single value sets both, e.g. "5000:1.20 qty=..1 order=10000..".
+func ParseBoxes(s string) ([]Box, error) {
+	var boxes []Box
+
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		tokens := strings.Fields(field)
+
+		parts := strings.Split(tokens[0], ":")
+		if len(parts) > 4 {
+			return nil, fmt.Errorf("invalid box %q: too many values", field)
+		}
+
+		size, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid box size %q: %w", field, err)
+		}
+
+		b := Box{Size: uint(size)}
+
+		values := []struct {
+			name string
+			dst  *float64
+		}{
+			{name: "cost", dst: &b.Cost},
+			{name: "max weight", dst: &b.MaxWeight},
+			{name: "max volume", dst: &b.MaxVolume},
+		}
+
+		for i, part := range parts[1:] {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+
+			*values[i].dst, err = strconv.ParseFloat(part, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid box %s %q: %w", values[i].name, field, err)
+			}
+		}
+
+		for _, rule := range tokens[1:] {
+			if err = b.parseRule(rule); err != nil {
+				return nil, fmt.Errorf("invalid box rule %q: %w", field, err)
+			}
+		}
+
+		boxes = append(boxes, b)
+	}
+
+	if len(boxes) == 0 {
+		return nil, errors.New("no boxes defined")
+	}
+
+	return boxes, nil
+}
+
+// parseRule sets the usage rule of the box in form "name=min..max".
+func (b *Box) parseRule(rule string) error {
+	name, value, ok := strings.Cut(rule, "=")
+	if !ok {
+		return fmt.Errorf("expected name=min..max, got %q", rule)
+	}
+
+	var lo, hi *uint
+
+	switch name {
+	case "qty":
+		lo, hi = &b.MinQuantity, &b.MaxQuantity
+	case "order":
+		lo, hi = &b.MinOrder, &b.MaxOrder
+	default:
+		return fmt.Errorf("unknown rule %q, available: qty, order", name)
+	}
+
+	from, to, isRange := strings.Cut(value, "..")
+	if !isRange {
+		to = from
+	}
+
+	for _, v := range []struct {
+		s   string
+		dst *uint
+	}{{s: from, dst: lo}, {s: to, dst: hi}} {
+		if v.s == "" {
+			continue
+		}
+
+		n, err := strconv.ParseUint(v.s, 10, 0)
+		if err != nil {
+			return fmt.Errorf("invalid %s bound %q: %w", name, v.s, err)
+		}
+
+		*v.dst = uint(n)
+	}
+
+	return nil
+}
+
+// sortBoxes returns copy of boxes sorted by size, of boxes that differ only in cost the cheapest one is kept.
+// Boxes of the same size that differ in limits or rules are kept, for validation to reject them.
+func sortBoxes(boxes []Box) []Box {
+	sorted := slices.Clone(boxes)
+
+	slices.SortStableFunc(sorted, func(a, b Box) int {
+		if c := cmp.Compare(a.Size, b.Size); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.Cost, b.Cost)
+	})
+
+	return slices.CompactFunc(sorted, func(a, b Box) bool {
+		b.Cost = a.Cost
+
+		return a == b
+	})
+}
+
+func validCost(c float64) bool {
+	return c >= 0 && !math.IsInf(c, 0) && !math.IsNaN(c)
+}
+
+func sizesOf(boxes []Box) []uint {
+	sizes := make([]uint, 0, len(boxes))
+
+	for _, b := range boxes {
+		sizes = append(sizes, b.Size)
+	}
+
+	return sizes
+}
@@ -0,0 +1,102 @@
+package packer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBoxes(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []Box
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "sizes",
+			in:      "250,500,1000,",
+			want:    []Box{{Size: 250}, {Size: 500}, {Size: 1000}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "sizes with costs",
+			in:      "250:0.40, 500:0.65,1000",
+			want:    []Box{{Size: 250, Cost: 0.4}, {Size: 500, Cost: 0.65}, {Size: 1000}},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "sizes with limits",
+			in:   "250:0.40:20:0.05,500::30,1000",
+			want: []Box{
+				{Size: 250, Cost: 0.4, MaxWeight: 20, MaxVolume: 0.05},
+				{Size: 500, MaxWeight: 30},
+				{Size: 1000},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "sizes with rules",
+			in:   "250 qty=..1,500:0.65 order=1000..,1000 qty=2 order=10..20",
+			want: []Box{
+				{Size: 250, MaxQuantity: 1},
+				{Size: 500, Cost: 0.65, MinOrder: 1000},
+				{Size: 1000, MinQuantity: 2, MaxQuantity: 2, MinOrder: 10, MaxOrder: 20},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "unknown rule",
+			in:      "250 size=1",
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name:    "invalid rule bound",
+			in:      "250 qty=one",
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name:    "invalid max weight",
+			in:      "250:0.40:heavy",
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name:    "too many values",
+			in:      "250:0.40:20:0.05:1",
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name:    "empty",
+			in:      " , ",
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name:    "invalid size",
+			in:      "sssd212",
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name:    "invalid cost",
+			in:      "250:cheap",
+			want:    nil,
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBoxes(tt.in)
+			if !tt.wantErr(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
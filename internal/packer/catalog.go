@@ -0,0 +1,273 @@
+package packer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+
+	log "github.com/obalunenko/logger"
+)
+
+// ErrUnknownSKU is returned when an order has a product that is not mapped to a box family.
+var ErrUnknownSKU = errors.New("unknown sku")
+
+// LineItem is a quantity of a product in an order.
+type LineItem struct {
+	SKU      string
+	Quantity uint
+	// Unit is the weight and volume of a single item of the product.
+	Unit Unit
+}
+
+// LineResult is a packing of a line item.
+type LineResult struct {
+	SKU string
+	// Family is the name of the box family the product is packed in.
+	Family string
+	PackResult
+}
+
+// OrderResult is a packing of a multi-product order.
+type OrderResult struct {
+	// Lines hold packings of line items in order of the request.
+	Lines []LineResult
+	// Items is the number of ordered items of all products.
+	Items uint
+	// Shipped is the number of items all packs hold.
+	Shipped uint
+	// Overshoot is the number of shipped items above the order.
+	Overshoot uint
+	// Backordered is the number of ordered items left unshipped by the policy of the order.
+	Backordered uint
+	// PackCount is the total number of packs.
+	PackCount uint
+	// Cost is the cost of all packs plus overshoot penalties.
+	Cost float64
+}
+
+// Catalog packs orders of several products, each product in boxes of its own family.
+// Every family is packed by its own Packer.
+type Catalog struct {
+	families  map[string]*Packer
+	skus      map[string]string
+	inventory Inventory
+}
+
+// NewCatalog returns catalog of box families and the mapping of products to them.
+// When inventory is set, all families pack from its stock.
+func NewCatalog(ctx context.Context, families map[string]*Packer, skus map[string]string, inv Inventory) (*Catalog, error) {
+	for sku, family := range skus {
+		if _, ok := families[family]; !ok {
+			return nil, fmt.Errorf("sku %q is mapped to unknown box family %q", sku, family)
+		}
+	}
+
+	c := Catalog{
+		families:  families,
+		skus:      skus,
+		inventory: inv,
+	}
+
+	log.WithFields(ctx, log.Fields{
+		"families": len(families),
+		"skus":     len(skus),
+	}).Info("Catalog created")
+
+	return &c, nil
+}
+
+// Profile returns catalog of the named box profile, that packs every product in the boxes of the profile's packer p
+// whatever its family and from the inventory of p. The default profile packs products in their families.
+func (c *Catalog) Profile(name string, p *Packer) *Catalog {
+	if c == nil || name == "" || name == DefaultProfile {
+		return c
+	}
+
+	families := make(map[string]*Packer, len(c.families))
+
+	for family := range c.families {
+		families[family] = p
+	}
+
+	return &Catalog{
+		families:  families,
+		skus:      c.skus,
+		inventory: p.inventory,
+	}
+}
+
+// PackLines packs every line item of the order in boxes of its product family.
+// With WithCommit the packs of all lines are taken off the stock at once, or none if any line fails.
+func (c *Catalog) PackLines(ctx context.Context, lines []LineItem, opts ...OrderOption) (OrderResult, error) {
+	var o orderOptions
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	packers := make([]*Packer, 0, len(lines))
+
+	for _, l := range lines {
+		family, ok := c.skus[l.SKU]
+		if !ok {
+			return OrderResult{}, fmt.Errorf("%w: %q", ErrUnknownSKU, l.SKU)
+		}
+
+		packers = append(packers, c.families[family])
+	}
+
+	packAll := func(stock map[uint]uint) (OrderResult, error) {
+		var res OrderResult
+
+		for i, l := range lines {
+			lo := o
+			lo.unit = l.Unit
+
+			lp := packers[i].loaded()
+
+			lctx, cancel := lp.withTimeout(ctx)
+			lr, err := lp.pack(lctx, l.Quantity, lo, stock)
+
+			cancel()
+
+			if err != nil {
+				return OrderResult{}, fmt.Errorf("failed to pack sku %q: %w", l.SKU, err)
+			}
+
+			if stock != nil {
+				takeOff(stock, lr)
+			}
+
+			if err = res.add(LineResult{SKU: l.SKU, Family: c.skus[l.SKU], PackResult: lr}); err != nil {
+				return OrderResult{}, err
+			}
+		}
+
+		return res, nil
+	}
+
+	if c.inventory == nil {
+		return packAll(nil)
+	}
+
+	if !o.commit {
+		return packAll(c.inventory.Stock())
+	}
+
+	res, err := commit(ctx, c.inventory, packAll, func(res OrderResult) map[uint]uint {
+		boxes := make(map[uint]uint)
+
+		for _, l := range res.Lines {
+			for size, n := range usedBoxes(l.PackResult) {
+				boxes[size] += n
+			}
+		}
+
+		return boxes
+	})
+	if err != nil {
+		return OrderResult{}, err
+	}
+
+	log.WithField(ctx, "lines", len(res.Lines)).Info("Packing committed")
+
+	return res, nil
+}
+
+// add appends line to the order result and sums up the totals.
+func (res *OrderResult) add(l LineResult) error {
+	var carry, c uint
+
+	items, c := bits.Add(res.Items, l.Items, 0)
+	carry |= c
+
+	shipped, c := bits.Add(res.Shipped, l.Shipped, 0)
+	carry |= c
+
+	packs, c := bits.Add(res.PackCount, l.PackCount, 0)
+	carry |= c
+
+	if carry != 0 {
+		return fmt.Errorf("%w: totals of the order overflow", ErrOrderTooLarge)
+	}
+
+	res.Lines = append(res.Lines, l)
+	res.Items, res.Shipped, res.PackCount = items, shipped, packs
+	res.Overshoot += l.Overshoot
+	res.Backordered += l.Backordered
+	res.Cost += l.Cost
+
+	return nil
+}
+
+// takeOff takes packs of the result off the stock.
+func takeOff(stock map[uint]uint, res PackResult) {
+	for _, q := range res.Packs {
+		if n, ok := stock[q.Box]; ok {
+			stock[q.Box] = n - q.Quantity
+		}
+	}
+}
+
+// ParseFamilies parses semicolon separated box families in form "name=boxes",
+// where boxes are in ParseBoxes format, e.g. "small=10,20,50;large=250:0.40,500:0.65".
+func ParseFamilies(s string) (map[string][]Box, error) {
+	families := make(map[string][]Box)
+
+	for _, field := range strings.Split(s, ";") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		name, boxesStr, ok := strings.Cut(field, "=")
+		name = strings.TrimSpace(name)
+
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid box family %q: expected name=boxes", field)
+		}
+
+		if _, exist := families[name]; exist {
+			return nil, fmt.Errorf("duplicated box family %q", name)
+		}
+
+		boxes, err := ParseBoxes(boxesStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid box family %q: %w", name, err)
+		}
+
+		families[name] = boxes
+	}
+
+	return families, nil
+}
+
+// ParseSKUs parses comma separated mapping of products to box families in form "sku=family",
+// e.g. "apple=small,tv=large".
+func ParseSKUs(s string) (map[string]string, error) {
+	skus := make(map[string]string)
+
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		sku, family, ok := strings.Cut(field, "=")
+		sku, family = strings.TrimSpace(sku), strings.TrimSpace(family)
+
+		if !ok || sku == "" || family == "" {
+			return nil, fmt.Errorf("invalid sku mapping %q: expected sku=family", field)
+		}
+
+		if _, exist := skus[sku]; exist {
+			return nil, fmt.Errorf("duplicated sku %q", sku)
+		}
+
+		skus[sku] = family
+	}
+
+	return skus, nil
+}
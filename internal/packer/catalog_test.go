@@ -0,0 +1,196 @@
+package packer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obalunenko/orderpacker/internal/inventory"
+	"github.com/obalunenko/orderpacker/internal/testlogger"
+)
+
+func TestCatalog_PackLines(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	small, err := NewPacker(ctx, WithBoxes([]uint{10, 20}))
+	require.NoError(t, err)
+
+	large, err := NewPacker(ctx, WithBoxes([]uint{250, 500}))
+	require.NoError(t, err)
+
+	families := map[string]*Packer{"small": small, "large": large}
+	skus := map[string]string{"apple": "small", "pear": "small", "tv": "large"}
+
+	t.Run("unknown family", func(t *testing.T) {
+		_, err := NewCatalog(ctx, families, map[string]string{"apple": "tiny"}, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("lines", func(t *testing.T) {
+		c, err := NewCatalog(ctx, families, skus, nil)
+		require.NoError(t, err)
+
+		got, err := c.PackLines(ctx, []LineItem{{SKU: "apple", Quantity: 25}, {SKU: "tv", Quantity: 600}, {SKU: "pear", Quantity: 20}})
+		require.NoError(t, err)
+
+		require.Len(t, got.Lines, 3)
+		assert.Equal(t, "small", got.Lines[0].Family)
+		assert.Equal(t, []BoxQuantity{{Box: 20, Quantity: 1}, {Box: 10, Quantity: 1}}, got.Lines[0].Packs)
+		assert.Equal(t, []BoxQuantity{{Box: 500, Quantity: 1}, {Box: 250, Quantity: 1}}, got.Lines[1].Packs)
+		assert.Equal(t, []BoxQuantity{{Box: 20, Quantity: 1}}, got.Lines[2].Packs)
+
+		assert.Equal(t, uint(645), got.Items)
+		assert.Equal(t, uint(800), got.Shipped)
+		assert.Equal(t, uint(155), got.Overshoot)
+		assert.Equal(t, uint(5), got.PackCount)
+	})
+
+	t.Run("profile", func(t *testing.T) {
+		c, err := NewCatalog(ctx, families, skus, nil)
+		require.NoError(t, err)
+
+		east, err := NewPacker(ctx, WithBoxes([]uint{100, 300}))
+		require.NoError(t, err)
+
+		assert.Same(t, c, c.Profile(DefaultProfile, east))
+
+		got, err := c.Profile("east", east).PackLines(ctx, []LineItem{{SKU: "apple", Quantity: 25}, {SKU: "tv", Quantity: 600}})
+		require.NoError(t, err)
+
+		require.Len(t, got.Lines, 2)
+		assert.Equal(t, "small", got.Lines[0].Family)
+		assert.Equal(t, []BoxQuantity{{Box: 100, Quantity: 1}}, got.Lines[0].Packs)
+		assert.Equal(t, []BoxQuantity{{Box: 300, Quantity: 2}}, got.Lines[1].Packs)
+	})
+
+	t.Run("unknown sku", func(t *testing.T) {
+		c, err := NewCatalog(ctx, families, skus, nil)
+		require.NoError(t, err)
+
+		_, err = c.PackLines(ctx, []LineItem{{SKU: "apple", Quantity: 1}, {SKU: "plum", Quantity: 1}})
+		assert.ErrorIs(t, err, ErrUnknownSKU)
+	})
+
+	t.Run("totals overflow", func(t *testing.T) {
+		c, err := NewCatalog(ctx, families, skus, nil)
+		require.NoError(t, err)
+
+		_, err = c.PackLines(ctx, []LineItem{{SKU: "apple", Quantity: 1 << 63}, {SKU: "pear", Quantity: 1 << 63}})
+		assert.ErrorIs(t, err, ErrOrderTooLarge)
+	})
+
+	t.Run("commit shares stock", func(t *testing.T) {
+		inv := inventory.New(map[uint]uint{20: 1})
+
+		c, err := NewCatalog(ctx, families, skus, inv)
+		require.NoError(t, err)
+
+		got, err := c.PackLines(ctx, []LineItem{{SKU: "apple", Quantity: 20}, {SKU: "pear", Quantity: 20}}, WithCommit())
+		require.NoError(t, err)
+
+		assert.Equal(t, []BoxQuantity{{Box: 20, Quantity: 1}}, got.Lines[0].Packs)
+		assert.Equal(t, []BoxQuantity{{Box: 10, Quantity: 2}}, got.Lines[1].Packs)
+		assert.Equal(t, map[uint]uint{20: 0}, inv.Stock())
+	})
+
+	t.Run("commit is all or nothing", func(t *testing.T) {
+		inv := inventory.New(map[uint]uint{20: 1, 500: 0, 250: 0})
+
+		c, err := NewCatalog(ctx, families, skus, inv)
+		require.NoError(t, err)
+
+		_, err = c.PackLines(ctx, []LineItem{{SKU: "apple", Quantity: 20}, {SKU: "tv", Quantity: 1}}, WithCommit())
+		assert.ErrorIs(t, err, ErrInsufficientStock)
+		assert.Equal(t, map[uint]uint{20: 1, 500: 0, 250: 0}, inv.Stock())
+	})
+}
+
+func TestParseFamilies(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    map[string][]Box
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name: "families",
+			s:    "small=10,20; large=250:0.40,500;",
+			want: map[string][]Box{
+				"small": {{Size: 10}, {Size: 20}},
+				"large": {{Size: 250, Cost: 0.4}, {Size: 500}},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "empty",
+			s:       "",
+			want:    map[string][]Box{},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "no boxes",
+			s:       "small=",
+			wantErr: assert.Error,
+		},
+		{
+			name:    "no name",
+			s:       "=10,20",
+			wantErr: assert.Error,
+		},
+		{
+			name:    "duplicated family",
+			s:       "small=10;small=20",
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFamilies(tt.s)
+			if !tt.wantErr(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseSKUs(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    map[string]string
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "skus",
+			s:       "apple=small, tv=large,",
+			want:    map[string]string{"apple": "small", "tv": "large"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "no family",
+			s:       "apple=",
+			wantErr: assert.Error,
+		},
+		{
+			name:    "duplicated sku",
+			s:       "apple=small,apple=large",
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSKUs(tt.s)
+			if !tt.wantErr(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package packer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	log "github.com/obalunenko/logger"
+)
+
+// maxCommitAttempts is the number of times an order is packed to be committed while the stock keeps changing.
+const maxCommitAttempts = 3
+
+// errStockChanged is returned when the boxes of a packing were taken off the stock while the order was packed.
+var errStockChanged = errors.New("stock changed while packing")
+
+// commit packs an order on a copy of the stock and takes the boxes the packing uses off the stock.
+// Packing runs without the inventory lock, so that a slow order does not hold up the others; the lock is taken
+// only to check that the stock still holds the boxes and to take them off. When it does not, the order is packed
+// again on the new stock, and fails with ErrInsufficientStock after maxCommitAttempts.
+func commit[T any](ctx context.Context, inv Inventory, pack func(stock map[uint]uint) (T, error),
+	used func(res T) map[uint]uint,
+) (T, error) {
+	var zero T
+
+	for attempt := 1; ; attempt++ {
+		res, err := pack(inv.Stock())
+		if err != nil {
+			return zero, err
+		}
+
+		boxes := used(res)
+
+		err = inv.Update(func(stock map[uint]uint) error {
+			for size, n := range boxes {
+				if left, ok := stock[size]; ok && left < n {
+					return errStockChanged
+				}
+			}
+
+			for size, n := range boxes {
+				if left, ok := stock[size]; ok {
+					stock[size] = left - n
+				}
+			}
+
+			return nil
+		})
+
+		switch {
+		case err == nil:
+			return res, nil
+		case !errors.Is(err, errStockChanged):
+			return zero, err
+		case attempt == maxCommitAttempts:
+			return zero, fmt.Errorf("%w: %w %d times", ErrInsufficientStock, errStockChanged, attempt)
+		}
+
+		log.WithField(ctx, "attempt", attempt).Warn("Stock changed while packing, packing again")
+	}
+}
+
+// usedBoxes returns the number of boxes of each size the packing uses.
+func usedBoxes(res PackResult) map[uint]uint {
+	boxes := make(map[uint]uint, len(res.Packs))
+
+	for _, q := range res.Packs {
+		boxes[q.Box] += q.Quantity
+	}
+
+	return boxes
+}
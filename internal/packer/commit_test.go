@@ -0,0 +1,95 @@
+package packer
+
+import (
+	"context"
+	"maps"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obalunenko/orderpacker/internal/inventory"
+	"github.com/obalunenko/orderpacker/internal/testlogger"
+)
+
+// racingInventory runs race before the first update, as if another order committed while this one was packed.
+type racingInventory struct {
+	*inventory.Inventory
+
+	race    func(inv *inventory.Inventory)
+	updates int
+}
+
+func (inv *racingInventory) Update(fn func(stock map[uint]uint) error) error {
+	inv.updates++
+
+	if inv.race != nil {
+		inv.race(inv.Inventory)
+		inv.race = nil
+	}
+
+	return inv.Inventory.Update(fn)
+}
+
+// staleInventory always returns the same stock to pack with, while updates see the current one.
+type staleInventory struct {
+	stock   map[uint]uint
+	current map[uint]uint
+	updates int
+}
+
+func (inv *staleInventory) Stock() map[uint]uint {
+	return maps.Clone(inv.stock)
+}
+
+func (inv *staleInventory) Update(fn func(stock map[uint]uint) error) error {
+	inv.updates++
+
+	stock := maps.Clone(inv.current)
+	if err := fn(stock); err != nil {
+		return err
+	}
+
+	inv.current = stock
+
+	return nil
+}
+
+func TestPacker_PackOrder_commitStockChanged(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	t.Run("packed again on the new stock", func(t *testing.T) {
+		inv := &racingInventory{
+			Inventory: inventory.New(map[uint]uint{250: 1, 500: 10}),
+			race: func(inv *inventory.Inventory) {
+				inv.Set(map[uint]uint{250: 0, 500: 10})
+			},
+		}
+
+		p, err := NewPacker(ctx, WithBoxes([]uint{250, 500}), WithInventory(inv))
+		require.NoError(t, err)
+
+		got, err := p.PackOrder(ctx, 250, WithCommit())
+		require.NoError(t, err)
+
+		assert.Equal(t, []BoxQuantity{{Box: 500, Quantity: 1}}, got.Packs)
+		assert.Equal(t, map[uint]uint{250: 0, 500: 9}, inv.Stock())
+		assert.Equal(t, 2, inv.updates)
+	})
+
+	t.Run("stock keeps changing", func(t *testing.T) {
+		inv := &staleInventory{
+			stock:   map[uint]uint{250: 1},
+			current: map[uint]uint{250: 0},
+		}
+
+		p, err := NewPacker(ctx, WithBoxes([]uint{250}), WithInventory(inv))
+		require.NoError(t, err)
+
+		_, err = p.PackOrder(ctx, 250, WithCommit())
+		require.ErrorIs(t, err, ErrInsufficientStock)
+
+		assert.Equal(t, maxCommitAttempts, inv.updates)
+		assert.Equal(t, map[uint]uint{250: 0}, inv.current)
+	})
+}
@@ -0,0 +1,234 @@
+package packer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrTableTooLarge is returned by the exact solver when the box set needs too large a table.
+var ErrTableTooLarge = errors.New("dynamic programming table is too large")
+
+const (
+	// unreachable marks totals that cannot be composed from the box set.
+	unreachable = math.MaxUint32
+	// maxTableSize limits the number of cells the exact solver keeps in memory.
+	maxTableSize = 1 << 26
+)
+
+type exactSolver struct{}
+
+func (exactSolver) Name() string {
+	return StrategyExact
+}
+
+// Solve runs a dynamic programming over reachable totals: for each total it keeps the cheapest
+// (for ObjectivePacks - the fewest packs) way to compose it, then picks the best total not less
+// than the order. The range of totals is bounded, see reduced.
+// Interrupted, it picks the best total composed of the boxes added to the table by then.
+func (exactSolver) Solve(ctx context.Context, prob Problem) ([]uint, error) {
+	if prob.Items == 0 || len(prob.Boxes) == 0 {
+		return make([]uint, len(prob.Boxes)), nil
+	}
+
+	r := prob.reduce()
+
+	if r.capacity < r.target {
+		return nil, fmt.Errorf("%w: boxes hold %d items", ErrInsufficientStock, r.capacity*r.g)
+	}
+
+	limit := r.limit()
+	if err := checkTableSize(r, limit, prob.Boxes); err != nil {
+		return nil, err
+	}
+
+	t, stopErr := newExactTable(ctx, r, limit)
+
+	var (
+		best  score
+		total uint
+		found bool
+	)
+
+	for s := r.target; s <= limit && len(t.cells) != 0; s++ {
+		c := t.cells[s]
+		if c.packs == unreachable {
+			continue
+		}
+
+		if sc := r.score(s, c.cost, uint(c.packs)); !found || sc.less(best) {
+			best, total, found = sc, s, true
+		}
+	}
+
+	if stopErr != nil {
+		if !found {
+			return nil, interrupted(ctx, false)
+		}
+
+		return r.expand(t.counts(total)), interrupted(ctx, true)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%w: no combination of available boxes holds %d items", ErrInsufficientStock, prob.Items)
+	}
+
+	return r.expand(t.counts(total)), nil
+}
+
+// checkTableSize returns ErrTableTooLarge if the table of totals up to the limit does not fit maxTableSize.
+func checkTableSize(r reduced, limit uint, boxes []Box) error {
+	if satMul(limit+1, uint(len(r.units))) > maxTableSize {
+		return fmt.Errorf("%w: %d totals for boxes %v", ErrTableTooLarge, limit+1, sizesOf(boxes))
+	}
+
+	return nil
+}
+
+// cell is the best way to compose a total.
+type cell struct {
+	cost  float64
+	packs uint32
+}
+
+// exactTable holds the best way to compose each total up to the limit with all boxes
+// and how many boxes of each size it takes.
+type exactTable struct {
+	units []uint
+	cells []cell
+	// used[i][s] is the number of i-th boxes in the best way to compose s with the first i+1 boxes.
+	// It is shorter than units when building the table was interrupted, then cells are of the boxes it holds.
+	used [][]uint32
+}
+
+// newExactTable adds boxes one by one. With a box of u units, weight w and stock m the best way
+// to compose s is min over 0 <= c <= m of prev[s-c*u] + c*(w, 1), which is a sliding window minimum
+// of prev[s-c*u] - (s-c*u)/u*(w, 1) along each chain of totals with the same remainder of u.
+// When the context is done it returns the table of the boxes added so far with the context error.
+func newExactTable(ctx context.Context, r reduced, limit uint) (exactTable, error) {
+	prev := make([]cell, limit+1)
+	for s := range prev {
+		prev[s].packs = unreachable
+	}
+
+	prev[0].packs = 0
+
+	t := exactTable{
+		units: r.units,
+		used:  make([][]uint32, 0, len(r.units)),
+	}
+
+	// key is a cell shifted back to the start of its chain, so keys along a chain are comparable.
+	type key struct {
+		cost  float64
+		packs int64
+	}
+
+	less := func(a, b key) bool {
+		if math.Abs(a.cost-b.cost) > CostEpsilon {
+			return a.cost < b.cost
+		}
+
+		return a.packs < b.packs
+	}
+
+	type entry struct {
+		j   uint
+		key key
+	}
+
+	window := make([]entry, 0, limit/r.units[0]+1)
+
+	st := stopper{ctx: ctx}
+
+	for i, u := range r.units {
+		w, m := r.weights[i], r.max[i]
+
+		cur := make([]cell, limit+1)
+		used := make([]uint32, limit+1)
+
+		for rem := uint(0); rem < u && rem <= limit; rem++ {
+			window = window[:0]
+			head := 0
+
+			for j, s := uint(0), rem; s <= limit; j, s = j+1, s+u {
+				if st.stop() {
+					return t, ctx.Err()
+				}
+
+				if p := prev[s]; p.packs != unreachable {
+					k := key{cost: p.cost - float64(j)*w, packs: int64(p.packs) - int64(j)}
+
+					for len(window) > head && !less(window[len(window)-1].key, k) {
+						window = window[:len(window)-1]
+					}
+
+					window = append(window, entry{j: j, key: k})
+				}
+
+				for len(window) > head && j-window[head].j > m {
+					head++
+				}
+
+				if len(window) == head {
+					cur[s].packs = unreachable
+
+					continue
+				}
+
+				front := window[head]
+
+				cur[s] = cell{cost: front.key.cost + float64(j)*w, packs: uint32(front.key.packs + int64(j))}
+				used[s] = uint32(j - front.j)
+			}
+		}
+
+		t.used = append(t.used, used)
+		t.cells = cur
+		prev = cur
+	}
+
+	return t, nil
+}
+
+// counts returns the number of boxes of each size in the best way to compose the reachable total.
+func (t exactTable) counts(total uint) []uint {
+	counts := make([]uint, len(t.units))
+
+	for i := len(t.used) - 1; i >= 0; i-- {
+		n := t.used[i][total]
+
+		counts[i] = uint(n)
+		total -= uint(n) * t.units[i]
+	}
+
+	return counts
+}
+
+func gcd(a, b uint) uint {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
+
+func gcdOf(values []uint) uint {
+	var g uint
+
+	for _, v := range values {
+		g = gcd(g, v)
+	}
+
+	return g
+}
+
+func ceilDiv(a, b uint) uint {
+	n := a / b
+	if a%b != 0 {
+		n++
+	}
+
+	return n
+}
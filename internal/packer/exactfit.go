@@ -0,0 +1,142 @@
+package packer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNoExactFit is returned in exact fit mode when no packing holds exactly the order, as an *ExactFitError.
+var ErrNoExactFit = errors.New("no exact fit")
+
+// ExactFitError reports the nearest totals boxes hold around an order they do not hold exactly.
+type ExactFitError struct {
+	// Items is the number of ordered items.
+	Items uint
+	// Below is the largest total under the order, zero when boxes hold none.
+	Below uint
+	// Above is the smallest total over the order, zero when boxes hold none, e.g. when stock runs out.
+	Above uint
+}
+
+func (e *ExactFitError) Error() string {
+	nearest := "boxes hold no total near it"
+
+	switch {
+	case e.Below != 0 && e.Above != 0:
+		nearest = fmt.Sprintf("nearest totals are %d below and %d above", e.Below, e.Above)
+	case e.Below != 0:
+		nearest = fmt.Sprintf("nearest total is %d below", e.Below)
+	case e.Above != 0:
+		nearest = fmt.Sprintf("nearest total is %d above", e.Above)
+	}
+
+	return fmt.Sprintf("%v for %d items: %s", ErrNoExactFit, e.Items, nearest)
+}
+
+// Is reports whether the target is ErrNoExactFit.
+func (e *ExactFitError) Is(target error) bool {
+	return target == ErrNoExactFit
+}
+
+// WithExactFitOnly makes orders ship exactly the ordered items unless the order sets otherwise.
+// Alternatives are not restricted.
+func WithExactFitOnly(exact bool) PackerOption {
+	return func(p *Packer) {
+		p.exactFit = exact
+	}
+}
+
+// WithExactFit sets whether the order ships exactly the ordered items instead of the packer default.
+// An exact packing ships neither more nor fewer items, so the policy does not apply.
+func WithExactFit(exact bool) OrderOption {
+	return func(o *orderOptions) {
+		o.exactFit = &exact
+	}
+}
+
+// solveExactFit returns box counts holding exactly the order, the cheapest ones with ObjectiveCost. Otherwise it returns
+// an *ExactFitError with totals of the packings up and down, the nearest ones the strategy finds.
+func (p Packer) solveExactFit(ctx context.Context, prob Problem) ([]uint, bool, error) {
+	up, upInterrupted, err := p.solve(ctx, prob)
+	if err != nil && !errors.Is(err, ErrInsufficientStock) {
+		return nil, false, err
+	}
+
+	if err == nil && shippedOf(prob, up) == prob.Items {
+		return up, upInterrupted, nil
+	}
+
+	// The cost objective may pick a packing above the nearest total, the fewest items are shipped with ObjectivePacks.
+	if err == nil && prob.Objective != ObjectivePacks {
+		q := prob
+		q.Objective = ObjectivePacks
+
+		up, upInterrupted, err = p.solve(ctx, q)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if shippedOf(prob, up) == prob.Items {
+			return p.cheapestExact(ctx, prob, up, upInterrupted)
+		}
+	}
+
+	// The solver may miss the exact packing up, when it does not minimize the overshoot.
+	down, downInterrupted, derr := p.solveDown(ctx, prob)
+	if derr != nil {
+		return nil, false, derr
+	}
+
+	fe := &ExactFitError{Items: prob.Items}
+
+	switch below := shippedOf(prob, down); {
+	case below == prob.Items:
+		return p.cheapestExact(ctx, prob, down, downInterrupted)
+	case below < prob.Items:
+		fe.Below = below
+	}
+
+	if err == nil {
+		fe.Above = shippedOf(prob, up)
+	}
+
+	return nil, false, fe
+}
+
+// cheapestExact returns the cheapest packing holding exactly the order with ObjectiveCost, given the exact one.
+// Each item above the order costs more than the exact packing, so the cost objective picks among exact packings only.
+func (p Packer) cheapestExact(ctx context.Context, prob Problem, exact []uint, interrupted bool) ([]uint, bool, error) {
+	if prob.Objective != ObjectiveCost {
+		return exact, interrupted, nil
+	}
+
+	q := prob
+	q.OvershootPenalty = costOf(prob, exact) + 1
+
+	counts, intr, err := p.solve(ctx, q)
+	if err != nil {
+		if errors.Is(err, ErrInsufficientStock) {
+			return exact, interrupted, nil
+		}
+
+		return nil, false, err
+	}
+
+	if shippedOf(prob, counts) != prob.Items || costOf(prob, counts) > costOf(prob, exact)+CostEpsilon {
+		return exact, interrupted, nil
+	}
+
+	return counts, intr, nil
+}
+
+// costOf returns the cost of the boxes of the problem.
+func costOf(prob Problem, counts []uint) float64 {
+	var c float64
+
+	for i, n := range counts {
+		c += float64(n) * prob.Boxes[i].Cost
+	}
+
+	return c
+}
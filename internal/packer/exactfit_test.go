@@ -0,0 +1,171 @@
+package packer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obalunenko/orderpacker/internal/inventory"
+	"github.com/obalunenko/orderpacker/internal/testlogger"
+)
+
+func TestPacker_PackOrder_exactFit(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	tests := []struct {
+		name    string
+		boxes   []uint
+		items   uint
+		want    []BoxQuantity
+		wantErr *ExactFitError
+	}{
+		{
+			name:  "exact order ships",
+			boxes: []uint{250, 500, 1000, 2000, 5000},
+			items: 750,
+			want:  []BoxQuantity{{Box: 500, Quantity: 1}, {Box: 250, Quantity: 1}},
+		},
+		{
+			name:  "exact packing of a single size",
+			boxes: []uint{23, 31},
+			items: 62,
+			want:  []BoxQuantity{{Box: 31, Quantity: 2}},
+		},
+		{
+			name:    "nearest totals around the order",
+			boxes:   []uint{250, 500, 1000, 2000, 5000},
+			items:   10999,
+			wantErr: &ExactFitError{Items: 10999, Below: 10750, Above: 11000},
+		},
+		{
+			name:    "nothing below the smallest box",
+			boxes:   []uint{250, 500},
+			items:   100,
+			wantErr: &ExactFitError{Items: 100, Above: 250},
+		},
+		{
+			name:  "empty order",
+			boxes: []uint{250, 500},
+			items: 0,
+			want:  []BoxQuantity{},
+		},
+	}
+
+	for _, strategy := range Strategies() {
+		t.Run(strategy, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					p, err := NewPacker(ctx, WithBoxes(tt.boxes), WithStrategy(strategy))
+					require.NoError(t, err)
+
+					got, err := p.PackOrder(ctx, tt.items, WithExactFit(true))
+					if tt.wantErr != nil {
+						require.ErrorIs(t, err, ErrNoExactFit)
+
+						var fe *ExactFitError
+
+						require.ErrorAs(t, err, &fe)
+						assert.Equal(t, tt.wantErr, fe)
+
+						return
+					}
+
+					require.NoError(t, err)
+
+					assert.Equal(t, tt.want, got.Packs)
+					assert.Equal(t, tt.items, got.Shipped)
+					assert.Zero(t, got.Overshoot)
+					assert.Zero(t, got.Backordered)
+				})
+			}
+		})
+	}
+}
+
+func TestPacker_PackOrder_exactFitDefault(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := NewPacker(ctx, WithBoxes([]uint{250, 500}), WithExactFitOnly(true))
+	require.NoError(t, err)
+
+	_, err = p.PackOrder(ctx, 751)
+	require.EqualError(t, err, "no exact fit for 751 items: nearest totals are 750 below and 1000 above")
+
+	got, err := p.PackOrder(ctx, 751, WithExactFit(false))
+	require.NoError(t, err)
+	assert.Equal(t, uint(1000), got.Shipped)
+}
+
+func TestPacker_PackOrder_exactFitCost(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	tests := []struct {
+		name    string
+		boxes   []Box
+		items   uint
+		want    []BoxQuantity
+		wantErr *ExactFitError
+	}{
+		{
+			name:    "nearest total above is not the cheapest packing",
+			boxes:   []Box{{Size: 5, Cost: 100}, {Size: 10, Cost: 1}},
+			items:   4,
+			wantErr: &ExactFitError{Items: 4, Above: 5},
+		},
+		{
+			name:  "cheapest exact packing",
+			boxes: []Box{{Size: 2, Cost: 1}, {Size: 3, Cost: 1}, {Size: 5, Cost: 100}, {Size: 10, Cost: 1}},
+			items: 5,
+			want:  []BoxQuantity{{Box: 3, Quantity: 1}, {Box: 2, Quantity: 1}},
+		},
+	}
+
+	for _, strategy := range []string{StrategyExact, StrategyBranchAndBound} {
+		t.Run(strategy, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					p, err := NewPacker(ctx, WithBoxSet(tt.boxes), WithStrategy(strategy), WithObjective(ObjectiveCost))
+					require.NoError(t, err)
+
+					got, err := p.PackOrder(ctx, tt.items, WithExactFit(true))
+					if tt.wantErr != nil {
+						var fe *ExactFitError
+
+						require.ErrorAs(t, err, &fe)
+						assert.Equal(t, tt.wantErr, fe)
+
+						return
+					}
+
+					require.NoError(t, err)
+					assert.Equal(t, tt.want, got.Packs)
+				})
+			}
+		})
+	}
+}
+
+func TestPacker_PackOrder_exactFitStock(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	for _, strategy := range Strategies() {
+		t.Run(strategy, func(t *testing.T) {
+			inv := inventory.New(map[uint]uint{250: 1, 500: 0})
+
+			p, err := NewPacker(ctx, WithBoxes([]uint{250, 500}), WithStrategy(strategy), WithInventory(inv))
+			require.NoError(t, err)
+
+			_, err = p.PackOrder(ctx, 500, WithExactFit(true), WithCommit())
+			require.ErrorIs(t, err, ErrNoExactFit)
+			require.EqualError(t, err, "no exact fit for 500 items: nearest total is 250 below")
+			assert.Equal(t, map[uint]uint{250: 1, 500: 0}, inv.Stock(), "failed commit must not change stock")
+
+			got, err := p.PackOrder(ctx, 250, WithExactFit(true), WithCommit())
+			require.NoError(t, err)
+			assert.Equal(t, []BoxQuantity{{Box: 250, Quantity: 1}}, got.Packs)
+			assert.Equal(t, map[uint]uint{250: 0, 500: 0}, inv.Stock())
+		})
+	}
+}
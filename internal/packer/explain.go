@@ -0,0 +1,215 @@
+package packer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// maxCandidates limits the number of candidates an explanation lists.
+const maxCandidates = 10
+
+// Candidate is a packing considered for an order.
+type Candidate struct {
+	Packs     []BoxQuantity
+	Shipped   uint
+	Overshoot uint
+	PackCount uint
+	Cost      float64
+	// Chosen marks the packing returned for the order.
+	Chosen bool
+	// Reason tells how the candidate compares to the chosen packing, empty for the chosen one.
+	Reason string
+}
+
+// Explanation is a trace of a packing decision.
+type Explanation struct {
+	Objective Objective
+	Strategy  string
+	// Considered is the number of reachable totals from the order up to the largest worth shipping.
+	// Each total is represented by its best packing.
+	Considered uint
+	// Candidates are the best of the considered packings ranked by the objective, at most maxCandidates,
+	// with the chosen packing added if it is not among them.
+	Candidates []Candidate
+	// Decision tells why the chosen packing won.
+	Decision string
+}
+
+// WithExplain adds to the result an explanation of why its packing was chosen.
+func WithExplain() OrderOption {
+	return func(o *orderOptions) {
+		o.explain = true
+	}
+}
+
+// explain ranks the best packing of every total the exact table reaches against the chosen one.
+func (p Packer) explain(ctx context.Context, prob Problem, fits []fit, chosen PackResult, u Unit) *Explanation {
+	e := Explanation{
+		Objective: p.objective,
+		Strategy:  p.strategy,
+	}
+
+	if prob.Items == 0 {
+		e.Decision = "Empty order needs no packs."
+
+		return &e
+	}
+
+	r := prob.reduce()
+	limit := r.limit()
+
+	var t exactTable
+
+	err := checkTableSize(r, limit, prob.Boxes)
+	if err == nil {
+		t, err = newExactTable(ctx, r, limit)
+	}
+
+	if err != nil {
+		e.Candidates = []Candidate{p.candidate(chosen, chosen)}
+		e.Decision = fmt.Sprintf("Chosen by %s strategy, alternatives are not listed: %v.", p.strategy, err)
+
+		return &e
+	}
+
+	type ranked struct {
+		sc    score
+		total uint
+	}
+
+	var top []ranked
+
+	for s := r.target; s <= limit; s++ {
+		c := t.cells[s]
+		if c.packs == unreachable {
+			continue
+		}
+
+		e.Considered++
+
+		rk := ranked{sc: r.score(s, c.cost, uint(c.packs)), total: s}
+
+		i, _ := slices.BinarySearchFunc(top, rk, func(a, b ranked) int {
+			if a.sc.less(b.sc) {
+				return -1
+			}
+
+			return 1
+		})
+
+		if i < maxCandidates {
+			top = slices.Insert(top, i, rk)
+			top = top[:min(len(top), maxCandidates)]
+		}
+	}
+
+	best := chosen
+	found := false
+
+	for i, rk := range top {
+		res := p.newPackResult(fits, r.expand(t.counts(rk.total)), prob.Items, u)
+		if i == 0 {
+			best = res
+		}
+
+		c := p.candidate(res, chosen)
+		found = found || c.Chosen
+
+		e.Candidates = append(e.Candidates, c)
+	}
+
+	if !found {
+		e.Candidates = append(e.Candidates, p.candidate(chosen, chosen))
+	}
+
+	e.Decision = p.decision(e.Considered, best, chosen)
+
+	return &e
+}
+
+func (p Packer) candidate(res, chosen PackResult) Candidate {
+	c := Candidate{
+		Packs:     res.Packs,
+		Shipped:   res.Shipped,
+		Overshoot: res.Overshoot,
+		PackCount: res.PackCount,
+		Cost:      res.Cost,
+		Chosen:    SamePacks(res.Packs, chosen.Packs),
+	}
+
+	if !c.Chosen {
+		c.Reason = p.compare(res, chosen)
+	}
+
+	return c
+}
+
+// compare tells how the packing compares to the chosen one by the first criterion of the objective they differ in.
+func (p Packer) compare(res, chosen PackResult) string {
+	if p.objective == ObjectiveCost && math.Abs(res.Cost-chosen.Cost) > CostEpsilon {
+		if res.Cost > chosen.Cost {
+			return fmt.Sprintf("costs %.2f more", res.Cost-chosen.Cost)
+		}
+
+		return fmt.Sprintf("costs %.2f less", chosen.Cost-res.Cost)
+	}
+
+	switch {
+	case res.Shipped > chosen.Shipped:
+		return fmt.Sprintf("ships %d more items", res.Shipped-chosen.Shipped)
+	case res.Shipped < chosen.Shipped:
+		return fmt.Sprintf("ships %d fewer items", chosen.Shipped-res.Shipped)
+	case res.PackCount > chosen.PackCount:
+		return fmt.Sprintf("ships as many items in %d more packs", res.PackCount-chosen.PackCount)
+	case res.PackCount < chosen.PackCount:
+		return fmt.Sprintf("ships as many items in %d fewer packs", chosen.PackCount-res.PackCount)
+	default:
+		return "ties with the chosen packing"
+	}
+}
+
+// decision tells why the chosen packing won over the best packings of considered totals, best is the top ranked one.
+func (p Packer) decision(considered uint, best, chosen PackResult) string {
+	if chosen.Backordered != 0 {
+		return fmt.Sprintf("Ships %d of %d items in full boxes, %d backordered; the best packing of the whole order %s.",
+			chosen.Shipped, chosen.Items, chosen.Backordered, p.compare(best, chosen))
+	}
+
+	if err := p.fill.check(best); err != nil && p.fill.check(chosen) == nil {
+		return fmt.Sprintf("Keeps the fill limits; the best candidate %s but %v.", p.compare(best, chosen), err)
+	}
+
+	if p.scoreOf(best).less(p.scoreOf(chosen)) {
+		return fmt.Sprintf("Chosen by %s strategy, which is not optimal: the best candidate %s.", p.strategy, p.compare(best, chosen))
+	}
+
+	if p.objective == ObjectiveCost {
+		return fmt.Sprintf("Costs %.2f, the least of the best packings of %d reachable totals; "+
+			"of equally cheap packings it ships the fewest items, %d, in the fewest packs, %d.",
+			chosen.Cost, considered, chosen.Shipped, chosen.PackCount)
+	}
+
+	return fmt.Sprintf("Ships %d items, the fewest of %d reachable totals not less than the order (overshoot %d); "+
+		"no packing of %d items uses fewer than %d packs.",
+		chosen.Shipped, considered, chosen.Overshoot, chosen.Shipped, chosen.PackCount)
+}
+
+// scoreOf returns the score of the result under the packer objective.
+func (p Packer) scoreOf(res PackResult) score {
+	sc := score{total: res.Shipped, packs: res.PackCount}
+
+	if p.objective == ObjectiveCost {
+		sc.cost = res.Cost
+	}
+
+	return sc
+}
+
+// SamePacks reports whether packs hold the same boxes in the same quantities.
+func SamePacks(a, b []BoxQuantity) bool {
+	return slices.EqualFunc(a, b, func(x, y BoxQuantity) bool {
+		return x.Box == y.Box && x.Quantity == y.Quantity
+	})
+}
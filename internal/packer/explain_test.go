@@ -0,0 +1,83 @@
+package packer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obalunenko/orderpacker/internal/testlogger"
+)
+
+func TestPacker_PackOrder_explain(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	t.Run("optimal", func(t *testing.T) {
+		p, err := NewPacker(ctx, WithDefaultBoxes())
+		require.NoError(t, err)
+
+		got, err := p.PackOrder(ctx, 12001, WithExplain())
+		require.NoError(t, err)
+		require.NotNil(t, got.Explanation)
+
+		e := got.Explanation
+		assert.Equal(t, ObjectivePacks, e.Objective)
+		assert.Equal(t, StrategyExact, e.Strategy)
+		assert.Equal(t, uint(20), e.Considered)
+		require.Len(t, e.Candidates, maxCandidates)
+
+		assert.Equal(t, Candidate{
+			Packs:     got.Packs,
+			Shipped:   12250,
+			Overshoot: 249,
+			PackCount: 4,
+			Chosen:    true,
+		}, e.Candidates[0])
+
+		assert.Equal(t, uint(12500), e.Candidates[1].Shipped)
+		assert.Equal(t, "ships 250 more items", e.Candidates[1].Reason)
+		assert.Equal(t, "Ships 12250 items, the fewest of 20 reachable totals not less than the order (overshoot 249); "+
+			"no packing of 12250 items uses fewer than 4 packs.", e.Decision)
+	})
+
+	t.Run("cost", func(t *testing.T) {
+		p, err := NewPacker(ctx,
+			WithBoxSet([]Box{{Size: 250, Cost: 1}, {Size: 500, Cost: 1.5}}),
+			WithObjective(ObjectiveCost),
+		)
+		require.NoError(t, err)
+
+		got, err := p.PackOrder(ctx, 500, WithExplain())
+		require.NoError(t, err)
+
+		e := got.Explanation
+		assert.True(t, e.Candidates[0].Chosen)
+		assert.Equal(t, "costs 1.00 more", e.Candidates[1].Reason)
+		assert.Equal(t, "Costs 1.50, the least of the best packings of 2 reachable totals; "+
+			"of equally cheap packings it ships the fewest items, 500, in the fewest packs, 1.", e.Decision)
+	})
+
+	t.Run("not optimal strategy", func(t *testing.T) {
+		p, err := NewPacker(ctx, WithBoxes([]uint{23, 31, 53}), WithStrategy(StrategyGreedy))
+		require.NoError(t, err)
+
+		got, err := p.PackOrder(ctx, 32, WithExplain())
+		require.NoError(t, err)
+
+		e := got.Explanation
+		assert.False(t, e.Candidates[0].Chosen)
+		assert.Equal(t, uint(46), e.Candidates[0].Shipped)
+		assert.Equal(t, "ships 8 fewer items", e.Candidates[0].Reason)
+		assert.Equal(t, "Chosen by greedy strategy, which is not optimal: the best candidate ships 8 fewer items.", e.Decision)
+	})
+
+	t.Run("not requested", func(t *testing.T) {
+		p, err := NewPacker(ctx, WithDefaultBoxes())
+		require.NoError(t, err)
+
+		got, err := p.PackOrder(ctx, 12001)
+		require.NoError(t, err)
+		assert.Nil(t, got.Explanation)
+	})
+}
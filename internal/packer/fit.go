@@ -0,0 +1,164 @@
+package packer
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+var (
+	// ErrUnitTooLarge is returned when a single unit exceeds weight or volume limit of every box.
+	ErrUnitTooLarge = errors.New("unit does not fit any box")
+	// ErrInvalidUnit is returned for negative or non-finite unit weight or volume, or one too large to total.
+	ErrInvalidUnit = errors.New("invalid unit")
+)
+
+// fitEpsilon is the tolerance of limits division, so that 20/0.1 fits 200 units.
+const fitEpsilon = 1e-9
+
+// maxUnitMeasure is the largest unit weight or volume that any number of units totals finitely.
+const maxUnitMeasure = math.MaxFloat64 / math.MaxUint64
+
+// Unit is the weight and volume of a single item. Zero values mean the item is not measured.
+type Unit struct {
+	Weight float64
+	Volume float64
+}
+
+func (u Unit) validate() error {
+	if !validCost(u.Weight) || !validCost(u.Volume) {
+		return fmt.Errorf("%w: weight %v, volume %v", ErrInvalidUnit, u.Weight, u.Volume)
+	}
+
+	if u.Weight > maxUnitMeasure || u.Volume > maxUnitMeasure {
+		return fmt.Errorf("%w: weight %v, volume %v, totals of units overflow", ErrInvalidUnit, u.Weight, u.Volume)
+	}
+
+	return nil
+}
+
+// measured reports whether the unit has weight or volume.
+func (u Unit) measured() bool {
+	return u.Weight > 0 || u.Volume > 0
+}
+
+// Fill is how much of a box one pack takes up.
+type Fill struct {
+	// Items is the number of items in the pack, less than the box size when weight or volume limit binds.
+	Items uint
+	// Weight is the load of the pack.
+	Weight float64
+	// Volume is the volume of items in the pack.
+	Volume float64
+	// WeightRatio is the share of the box weight limit taken, zero for boxes without the limit.
+	WeightRatio float64
+	// VolumeRatio is the share of the box volume limit taken, zero for boxes without the limit.
+	VolumeRatio float64
+}
+
+// fit is a box with the number of units it holds.
+type fit struct {
+	box      Box
+	capacity uint
+}
+
+// capacityOf returns the number of units the box holds: its size, or less when weight or volume limit binds first.
+func capacityOf(b Box, u Unit) uint {
+	capacity := b.Size
+
+	limit := func(maxLoad, load float64) {
+		if maxLoad <= 0 || load <= 0 {
+			return
+		}
+
+		n := math.Floor(maxLoad/load + fitEpsilon)
+		if n < float64(capacity) {
+			capacity = uint(n)
+		}
+	}
+
+	limit(b.MaxWeight, u.Weight)
+	limit(b.MaxVolume, u.Volume)
+
+	return capacity
+}
+
+// fillOf returns the fill of a pack of the fit.
+func fillOf(f fit, u Unit) Fill {
+	fill := Fill{
+		Items:  f.capacity,
+		Weight: float64(f.capacity) * u.Weight,
+		Volume: float64(f.capacity) * u.Volume,
+	}
+
+	if f.box.MaxWeight > 0 {
+		fill.WeightRatio = fill.Weight / f.box.MaxWeight
+	}
+
+	if f.box.MaxVolume > 0 {
+		fill.VolumeRatio = fill.Volume / f.box.MaxVolume
+	}
+
+	return fill
+}
+
+// fits returns boxes that hold at least one unit and are used for orders of the given items, sorted by capacity.
+// Of boxes with the same capacity and neither quantity rules nor a stock record the cheapest one is kept,
+// the others are kept apart, so that their limits hold.
+func (p Packer) fits(items uint, u Unit, stock map[uint]uint) ([]fit, error) {
+	fits := make([]fit, 0, len(p.boxes))
+
+	var eligible bool
+
+	for _, b := range p.boxes {
+		if !b.eligible(items) {
+			continue
+		}
+
+		eligible = true
+
+		if c := capacityOf(b, u); c != 0 {
+			fits = append(fits, fit{box: b, capacity: c})
+		}
+	}
+
+	if !eligible {
+		return nil, fmt.Errorf("%w: %d items", ErrNoEligibleBox, items)
+	}
+
+	if len(fits) == 0 {
+		return nil, fmt.Errorf("%w: weight %v, volume %v", ErrUnitTooLarge, u.Weight, u.Volume)
+	}
+
+	if !u.measured() {
+		return fits, nil
+	}
+
+	plain := func(b Box) bool {
+		_, ok := stock[b.Size]
+
+		return !ok && b.MinQuantity == 0 && b.MaxQuantity == 0
+	}
+
+	slices.SortStableFunc(fits, func(a, b fit) int {
+		if c := cmp.Compare(a.capacity, b.capacity); c != 0 {
+			return c
+		}
+
+		if pa, pb := plain(a.box), plain(b.box); pa != pb {
+			if pa {
+				return -1
+			}
+
+			return 1
+		}
+
+		return cmp.Compare(a.box.Cost, b.box.Cost)
+	})
+
+	return slices.CompactFunc(fits, func(a, b fit) bool {
+		return a.capacity == b.capacity && plain(a.box) && plain(b.box)
+	}), nil
+}
@@ -0,0 +1,181 @@
+// Package geometry packs items into boxes by their dimensions and returns where each item is placed.
+package geometry
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrItemTooLarge is returned when an item fits no box in any allowed orientation.
+	ErrItemTooLarge = errors.New("item does not fit any box")
+	// ErrTooManyItems is returned when an order has more units than the engine places.
+	ErrTooManyItems = errors.New("too many items")
+	// ErrInvalidItem is returned for items with zero dimensions or unknown rotation.
+	ErrInvalidItem = errors.New("invalid item")
+)
+
+// Dimensions are the length (x), width (y) and height (z) of an item or a box in whole units, e.g. millimetres.
+type Dimensions struct {
+	Length uint
+	Width  uint
+	Height uint
+}
+
+func (d Dimensions) volume() float64 {
+	return float64(d.Length) * float64(d.Width) * float64(d.Height)
+}
+
+func (d Dimensions) valid() bool {
+	return d.Length != 0 && d.Width != 0 && d.Height != 0
+}
+
+func (d Dimensions) String() string {
+	return fmt.Sprintf("%dx%dx%d", d.Length, d.Width, d.Height)
+}
+
+// Rotation tells which orientations of an item are allowed.
+type Rotation string
+
+const (
+	// RotationAny allows any of six orientations.
+	RotationAny Rotation = "any"
+	// RotationUpright keeps the height vertical and allows turning the item around it.
+	RotationUpright Rotation = "upright"
+	// RotationNone keeps the item as it is.
+	RotationNone Rotation = "none"
+)
+
+// DefaultRotation is used when an item sets no rotation.
+const DefaultRotation = RotationAny
+
+// orientations returns distinct dimensions the item may take.
+func (r Rotation) orientations(d Dimensions) ([]Dimensions, error) {
+	l, w, h := d.Length, d.Width, d.Height
+
+	var all []Dimensions
+
+	switch r {
+	case RotationAny, "":
+		all = []Dimensions{{l, w, h}, {w, l, h}, {l, h, w}, {h, l, w}, {w, h, l}, {h, w, l}}
+	case RotationUpright:
+		all = []Dimensions{{l, w, h}, {w, l, h}}
+	case RotationNone:
+		all = []Dimensions{{l, w, h}}
+	default:
+		return nil, fmt.Errorf("%w: unknown rotation %q", ErrInvalidItem, r)
+	}
+
+	res := all[:0]
+
+	for _, o := range all {
+		seen := false
+
+		for _, r := range res {
+			if r == o {
+				seen = true
+
+				break
+			}
+		}
+
+		if !seen {
+			res = append(res, o)
+		}
+	}
+
+	return res, nil
+}
+
+// Box is a box type to pack items in.
+type Box struct {
+	Name string
+	Dimensions
+}
+
+// Item is a quantity of units of the same product.
+type Item struct {
+	ID string
+	Dimensions
+	Quantity uint
+	Rotation Rotation
+}
+
+// Point is a position inside a box, measured from its back bottom left corner.
+type Point struct {
+	X uint
+	Y uint
+	Z uint
+}
+
+// Placement is a unit of an item placed in a box.
+type Placement struct {
+	Item string
+	// Position is the corner of the unit nearest to the box origin.
+	Position Point
+	// Dimensions are the dimensions of the unit as it is oriented in the box.
+	Dimensions Dimensions
+}
+
+// PackedBox is a box with the units placed in it.
+type PackedBox struct {
+	Box        Box
+	Placements []Placement
+	// Fill is the share of the box volume the units take.
+	Fill float64
+}
+
+// Result is a packing of an order.
+type Result struct {
+	Boxes []PackedBox
+	// Units is the number of placed units.
+	Units uint
+}
+
+// ParseBoxes parses comma separated box types in form "name=LxWxH", e.g. "small=300x200x150,large=600x400x400".
+func ParseBoxes(s string) ([]Box, error) {
+	var boxes []Box
+
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		name, dims, ok := strings.Cut(field, "=")
+		name = strings.TrimSpace(name)
+
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid box %q: expected name=LxWxH", field)
+		}
+
+		parts := strings.Split(strings.TrimSpace(dims), "x")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid box %q: expected name=LxWxH", field)
+		}
+
+		values := make([]uint, 0, len(parts))
+
+		for _, part := range parts {
+			v, err := strconv.ParseUint(strings.TrimSpace(part), 10, 0)
+			if err != nil {
+				return nil, fmt.Errorf("invalid box %q dimension: %w", field, err)
+			}
+
+			values = append(values, uint(v))
+		}
+
+		boxes = append(boxes, Box{
+			Name:       name,
+			Dimensions: Dimensions{Length: values[0], Width: values[1], Height: values[2]},
+		})
+	}
+
+	if len(boxes) == 0 {
+		return nil, errors.New("no boxes defined")
+	}
+
+	return boxes, nil
+}
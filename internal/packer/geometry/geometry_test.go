@@ -0,0 +1,228 @@
+package geometry
+
+import (
+	"context"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obalunenko/orderpacker/internal/testlogger"
+)
+
+func TestParseBoxes(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []Box
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name: "boxes",
+			in:   "small=300x200x150, large=600x400x400,",
+			want: []Box{
+				{Name: "small", Dimensions: Dimensions{Length: 300, Width: 200, Height: 150}},
+				{Name: "large", Dimensions: Dimensions{Length: 600, Width: 400, Height: 400}},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "empty",
+			in:      "",
+			wantErr: assert.Error,
+		},
+		{
+			name:    "no name",
+			in:      "300x200x150",
+			wantErr: assert.Error,
+		},
+		{
+			name:    "two dimensions",
+			in:      "flat=300x200",
+			wantErr: assert.Error,
+		},
+		{
+			name:    "invalid dimension",
+			in:      "small=300x200xhigh",
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBoxes(tt.in)
+			if !tt.wantErr(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPacker_Pack(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	cube := func(name string, side uint) Box {
+		return Box{Name: name, Dimensions: Dimensions{Length: side, Width: side, Height: side}}
+	}
+
+	t.Run("cubes fill the box", func(t *testing.T) {
+		p, err := NewPacker(ctx, []Box{cube("large", 10)})
+		require.NoError(t, err)
+
+		got, err := p.Pack(ctx, []Item{{ID: "cube", Dimensions: Dimensions{Length: 5, Width: 5, Height: 5}, Quantity: 8}})
+		require.NoError(t, err)
+
+		require.Len(t, got.Boxes, 1)
+		assert.Equal(t, uint(8), got.Units)
+		assert.Equal(t, 1.0, got.Boxes[0].Fill)
+
+		positions := make([]Point, 0, 8)
+		for _, pl := range got.Boxes[0].Placements {
+			positions = append(positions, pl.Position)
+		}
+
+		assert.ElementsMatch(t, []Point{
+			{0, 0, 0}, {5, 0, 0}, {0, 5, 0}, {5, 5, 0},
+			{0, 0, 5}, {5, 0, 5}, {0, 5, 5}, {5, 5, 5},
+		}, positions)
+	})
+
+	t.Run("box is downsized", func(t *testing.T) {
+		p, err := NewPacker(ctx, []Box{cube("large", 10), cube("small", 5)})
+		require.NoError(t, err)
+
+		got, err := p.Pack(ctx, []Item{{ID: "cube", Dimensions: Dimensions{Length: 5, Width: 5, Height: 5}, Quantity: 9}})
+		require.NoError(t, err)
+
+		require.Len(t, got.Boxes, 2)
+		assert.Equal(t, "large", got.Boxes[0].Box.Name)
+		assert.Equal(t, "small", got.Boxes[1].Box.Name)
+	})
+
+	t.Run("item fits only a smaller box", func(t *testing.T) {
+		p, err := NewPacker(ctx, []Box{
+			{Name: "tube", Dimensions: Dimensions{Length: 2000, Width: 100, Height: 100}},
+			cube("crate", 600),
+		})
+		require.NoError(t, err)
+
+		got, err := p.Pack(ctx, []Item{
+			{ID: "rod", Dimensions: Dimensions{Length: 1500, Width: 50, Height: 50}, Quantity: 1},
+			{ID: "cube", Dimensions: Dimensions{Length: 500, Width: 500, Height: 500}, Quantity: 1},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, got.Boxes, 2)
+		assert.Equal(t, "crate", got.Boxes[0].Box.Name)
+		assert.Equal(t, "cube", got.Boxes[0].Placements[0].Item)
+		assert.Equal(t, "tube", got.Boxes[1].Box.Name)
+		assert.Equal(t, "rod", got.Boxes[1].Placements[0].Item)
+	})
+
+	t.Run("rotation rules", func(t *testing.T) {
+		p, err := NewPacker(ctx, []Box{{Name: "flat", Dimensions: Dimensions{Length: 10, Width: 4, Height: 2}}})
+		require.NoError(t, err)
+
+		item := Item{ID: "rod", Dimensions: Dimensions{Length: 4, Width: 10, Height: 2}, Quantity: 1}
+
+		item.Rotation = RotationNone
+		_, err = p.Pack(ctx, []Item{item})
+		assert.ErrorIs(t, err, ErrItemTooLarge)
+
+		item.Rotation = RotationUpright
+		got, err := p.Pack(ctx, []Item{item})
+		require.NoError(t, err)
+		assert.Equal(t, Dimensions{Length: 10, Width: 4, Height: 2}, got.Boxes[0].Placements[0].Dimensions)
+
+		item.Dimensions = Dimensions{Length: 4, Width: 2, Height: 10}
+		_, err = p.Pack(ctx, []Item{item})
+		assert.ErrorIs(t, err, ErrItemTooLarge, "upright item must not lay down")
+
+		item.Rotation = RotationAny
+		_, err = p.Pack(ctx, []Item{item})
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid items", func(t *testing.T) {
+		p, err := NewPacker(ctx, []Box{cube("large", 10)})
+		require.NoError(t, err)
+
+		_, err = p.Pack(ctx, []Item{{ID: "flat", Dimensions: Dimensions{Length: 1, Width: 1}, Quantity: 1}})
+		assert.ErrorIs(t, err, ErrInvalidItem)
+
+		_, err = p.Pack(ctx, []Item{{ID: "cube", Dimensions: Dimensions{Length: 1, Width: 1, Height: 1}, Quantity: 1, Rotation: "sideways"}})
+		assert.ErrorIs(t, err, ErrInvalidItem)
+
+		_, err = p.Pack(ctx, []Item{{ID: "cube", Dimensions: Dimensions{Length: 1, Width: 1, Height: 1}, Quantity: MaxUnits + 1}})
+		assert.ErrorIs(t, err, ErrTooManyItems)
+	})
+
+	t.Run("no overlaps", func(t *testing.T) {
+		p, err := NewPacker(ctx, []Box{
+			{Name: "small", Dimensions: Dimensions{Length: 30, Width: 20, Height: 15}},
+			{Name: "large", Dimensions: Dimensions{Length: 60, Width: 40, Height: 40}},
+		})
+		require.NoError(t, err)
+
+		rnd := rand.New(rand.NewPCG(1, 2))
+
+		items := make([]Item, 0, 20)
+
+		for i := range 20 {
+			items = append(items, Item{
+				ID: string(rune('a' + i)),
+				Dimensions: Dimensions{
+					Length: 1 + rnd.UintN(30),
+					Width:  1 + rnd.UintN(20),
+					Height: 1 + rnd.UintN(15),
+				},
+				Quantity: 1 + rnd.UintN(5),
+				Rotation: []Rotation{RotationAny, RotationUpright, RotationNone}[rnd.IntN(3)],
+			})
+		}
+
+		got, err := p.Pack(ctx, items)
+		require.NoError(t, err)
+
+		var want uint
+		for _, it := range items {
+			want += it.Quantity
+		}
+
+		assert.Equal(t, want, got.Units)
+
+		for _, b := range got.Boxes {
+			for i, a := range b.Placements {
+				assert.True(t, b.Box.fits(Dimensions{
+					Length: a.Position.X + a.Dimensions.Length,
+					Width:  a.Position.Y + a.Dimensions.Width,
+					Height: a.Position.Z + a.Dimensions.Height,
+				}), "unit must be inside the box")
+
+				for _, c := range b.Placements[i+1:] {
+					assert.False(t,
+						overlaps(a.Position.X, a.Dimensions.Length, c.Position.X, c.Dimensions.Length) &&
+							overlaps(a.Position.Y, a.Dimensions.Width, c.Position.Y, c.Dimensions.Width) &&
+							overlaps(a.Position.Z, a.Dimensions.Height, c.Position.Z, c.Dimensions.Height),
+						"units must not overlap")
+				}
+			}
+		}
+	})
+}
+
+func TestNewPacker(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	_, err := NewPacker(ctx, nil)
+	assert.Error(t, err)
+
+	_, err = NewPacker(ctx, []Box{{Name: "flat", Dimensions: Dimensions{Length: 1, Width: 1}}})
+	assert.Error(t, err)
+
+	_, err = NewPacker(ctx, []Box{{Name: "a", Dimensions: Dimensions{1, 1, 1}}, {Name: "a", Dimensions: Dimensions{2, 2, 2}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,331 @@
+package geometry
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	log "github.com/obalunenko/logger"
+)
+
+// MaxUnits is the most units of all items an order may have.
+const MaxUnits = 256
+
+// Packer places items into boxes of a box set.
+type Packer struct {
+	// boxes are sorted by volume, the smallest first.
+	boxes []Box
+}
+
+// NewPacker returns packer of the box set.
+func NewPacker(ctx context.Context, boxes []Box) (*Packer, error) {
+	if len(boxes) == 0 {
+		return nil, errors.New("boxes list is empty")
+	}
+
+	names := make(map[string]struct{}, len(boxes))
+
+	for _, b := range boxes {
+		if !b.valid() {
+			return nil, fmt.Errorf("box %q has zero dimension", b.Name)
+		}
+
+		if _, ok := names[b.Name]; ok {
+			return nil, fmt.Errorf("duplicated box %q", b.Name)
+		}
+
+		names[b.Name] = struct{}{}
+	}
+
+	p := Packer{
+		boxes: slices.Clone(boxes),
+	}
+
+	slices.SortStableFunc(p.boxes, func(a, b Box) int {
+		return cmp.Compare(a.volume(), b.volume())
+	})
+
+	log.WithField(ctx, "boxes", p.boxes).Info("Geometry packer created")
+
+	return &p, nil
+}
+
+// Boxes returns box types sorted by volume.
+func (p *Packer) Boxes() []Box {
+	return slices.Clone(p.boxes)
+}
+
+// unit is a single unit of an item with the orientations it may take.
+type unit struct {
+	item         string
+	orientations []Dimensions
+	volume       float64
+}
+
+// Pack places units into boxes. Units go the largest first, each at the first free corner
+// (the lowest, then the nearest) of the first open box it fits in any allowed orientation;
+// a unit that fits no open box opens the largest box type it fits. Then each box is replaced with
+// the smallest box type all its units fit.
+func (p *Packer) Pack(ctx context.Context, items []Item) (Result, error) {
+	units, err := p.units(items)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var open []*container
+
+	for _, u := range units {
+		if err = ctx.Err(); err != nil {
+			return Result{}, err
+		}
+
+		placed := false
+
+		for _, c := range open {
+			if c.place(u) {
+				placed = true
+
+				break
+			}
+		}
+
+		if placed {
+			continue
+		}
+
+		c := p.open(u)
+		if c == nil {
+			return Result{}, fmt.Errorf("%w: %q", ErrItemTooLarge, u.item)
+		}
+
+		open = append(open, c)
+	}
+
+	res := Result{
+		Boxes: make([]PackedBox, 0, len(open)),
+	}
+
+	for _, c := range open {
+		c = p.downsize(c)
+
+		res.Boxes = append(res.Boxes, c.packed())
+		res.Units += uint(len(c.placements))
+	}
+
+	log.WithFields(ctx, log.Fields{
+		"units": res.Units,
+		"boxes": len(res.Boxes),
+	}).Debug("Geometry packing done")
+
+	return res, nil
+}
+
+// units expands items into units sorted by volume, the largest first.
+func (p *Packer) units(items []Item) ([]unit, error) {
+	var (
+		units []unit
+		total uint
+	)
+
+	for _, it := range items {
+		if !it.valid() {
+			return nil, fmt.Errorf("%w: item %q has zero dimension", ErrInvalidItem, it.ID)
+		}
+
+		orientations, err := it.Rotation.orientations(it.Dimensions)
+		if err != nil {
+			return nil, fmt.Errorf("item %q: %w", it.ID, err)
+		}
+
+		if !slices.ContainsFunc(p.boxes, func(b Box) bool { return slices.ContainsFunc(orientations, b.fits) }) {
+			return nil, fmt.Errorf("%w: %q of %s", ErrItemTooLarge, it.ID, it.Dimensions)
+		}
+
+		if it.Quantity > MaxUnits-total {
+			return nil, fmt.Errorf("%w: more than %d units", ErrTooManyItems, MaxUnits)
+		}
+
+		total += it.Quantity
+
+		for range it.Quantity {
+			units = append(units, unit{
+				item:         it.ID,
+				orientations: orientations,
+				volume:       it.volume(),
+			})
+		}
+	}
+
+	slices.SortStableFunc(units, func(a, b unit) int {
+		return cmp.Compare(b.volume, a.volume)
+	})
+
+	return units, nil
+}
+
+// open returns a container of the largest box type the unit fits, with the unit placed, or nil if it fits none.
+// Box types are tried by volume, a unit may fit only a smaller box of a different shape.
+func (p *Packer) open(u unit) *container {
+	for i := len(p.boxes) - 1; i >= 0; i-- {
+		if c := newContainer(p.boxes[i]); c.place(u) {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// downsize returns the container repacked into the smallest box type all its units fit.
+func (p *Packer) downsize(c *container) *container {
+	units := c.units()
+
+	var volume float64
+
+	for _, u := range units {
+		volume += u.volume
+	}
+
+	for _, b := range p.boxes {
+		if b.volume() < volume {
+			continue
+		}
+
+		if b.volume() >= c.box.volume() {
+			break
+		}
+
+		smaller := newContainer(b)
+
+		fits := true
+
+		for _, u := range units {
+			if !smaller.place(u) {
+				fits = false
+
+				break
+			}
+		}
+
+		if fits {
+			return smaller
+		}
+	}
+
+	return c
+}
+
+// fits reports whether the dimensions fit inside the box.
+func (b Box) fits(d Dimensions) bool {
+	return d.Length <= b.Length && d.Width <= b.Width && d.Height <= b.Height
+}
+
+// container is a box being packed. Free corners are extreme points: corners of placed units
+// next to which another unit may go.
+type container struct {
+	box        Box
+	placements []Placement
+	placed     []unit
+	points     []Point
+}
+
+func newContainer(b Box) *container {
+	return &container{
+		box:    b,
+		points: []Point{{}},
+	}
+}
+
+// place puts the unit at the first free corner where it fits in any of its orientations.
+func (c *container) place(u unit) bool {
+	for i, pt := range c.points {
+		for _, d := range u.orientations {
+			if !c.free(pt, d) {
+				continue
+			}
+
+			c.placements = append(c.placements, Placement{
+				Item:       u.item,
+				Position:   pt,
+				Dimensions: d,
+			})
+			c.placed = append(c.placed, u)
+
+			c.points = slices.Delete(c.points, i, i+1)
+			c.addPoint(Point{X: pt.X + d.Length, Y: pt.Y, Z: pt.Z})
+			c.addPoint(Point{X: pt.X, Y: pt.Y + d.Width, Z: pt.Z})
+			c.addPoint(Point{X: pt.X, Y: pt.Y, Z: pt.Z + d.Height})
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// free reports whether a unit of dimensions d at pt is inside the box and overlaps no placed unit.
+func (c *container) free(pt Point, d Dimensions) bool {
+	if pt.X+d.Length > c.box.Length || pt.Y+d.Width > c.box.Width || pt.Z+d.Height > c.box.Height {
+		return false
+	}
+
+	for _, pl := range c.placements {
+		if overlaps(pt.X, d.Length, pl.Position.X, pl.Dimensions.Length) &&
+			overlaps(pt.Y, d.Width, pl.Position.Y, pl.Dimensions.Width) &&
+			overlaps(pt.Z, d.Height, pl.Position.Z, pl.Dimensions.Height) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// addPoint adds a free corner inside the box, keeping corners ordered the lowest, then the nearest first.
+func (c *container) addPoint(pt Point) {
+	if pt.X >= c.box.Length || pt.Y >= c.box.Width || pt.Z >= c.box.Height {
+		return
+	}
+
+	cmpPoints := func(a, b Point) int {
+		if r := cmp.Compare(a.Z, b.Z); r != 0 {
+			return r
+		}
+
+		if r := cmp.Compare(a.Y, b.Y); r != 0 {
+			return r
+		}
+
+		return cmp.Compare(a.X, b.X)
+	}
+
+	i, found := slices.BinarySearchFunc(c.points, pt, cmpPoints)
+	if found {
+		return
+	}
+
+	c.points = slices.Insert(c.points, i, pt)
+}
+
+func (c *container) units() []unit {
+	return slices.Clone(c.placed)
+}
+
+func (c *container) packed() PackedBox {
+	var volume float64
+
+	for _, pl := range c.placements {
+		volume += pl.Dimensions.volume()
+	}
+
+	return PackedBox{
+		Box:        c.box,
+		Placements: c.placements,
+		Fill:       volume / c.box.volume(),
+	}
+}
+
+// overlaps reports whether intervals [a, a+la) and [b, b+lb) intersect.
+func overlaps(a, la, b, lb uint) bool {
+	return a < b+lb && b < a+la
+}
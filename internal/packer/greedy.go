@@ -0,0 +1,120 @@
+package packer
+
+import (
+	"context"
+	"fmt"
+)
+
+type greedySolver struct{}
+
+func (greedySolver) Name() string {
+	return StrategyGreedy
+}
+
+// Solve takes as many boxes as fit, starting from the largest one, and closes the rest with
+// the smallest box that covers it. Box costs are ignored.
+func (s greedySolver) Solve(ctx context.Context, prob Problem) ([]uint, error) {
+	if prob.Min != nil {
+		rest, fixed := prob.residual()
+
+		counts, err := s.Solve(ctx, rest)
+		if err != nil {
+			return nil, err
+		}
+
+		return addCounts(counts, fixed), nil
+	}
+
+	boxes := sizesOf(prob.Boxes)
+	items := prob.Items
+
+	counts := make([]uint, len(boxes))
+
+	if items == 0 || len(boxes) == 0 {
+		return counts, nil
+	}
+
+	if prob.Max != nil {
+		return greedyLimited(prob, counts)
+	}
+
+	if len(boxes) == 1 {
+		n := items / boxes[0]
+		if items%boxes[0] != 0 {
+			n++
+		}
+
+		counts[0] = n
+
+		return counts, nil
+	}
+
+	for i := len(boxes) - 1; i >= 0; i-- {
+		box := boxes[i]
+
+		if box >= items {
+			if i == 0 {
+				counts[i]++
+
+				break
+			}
+
+			continue
+		}
+
+		if i == 0 {
+			counts[i+1]++
+
+			break
+		}
+
+		counts[i] += items / box
+
+		left := items % box
+		if left == 0 {
+			break
+		}
+
+		items = left
+	}
+
+	return counts, nil
+}
+
+// greedyLimited takes as many boxes as fit and available, starting from the largest one,
+// and closes the rest with the smallest available box that covers it, or the largest available one.
+func greedyLimited(prob Problem, counts []uint) ([]uint, error) {
+	rest := prob.Items
+
+	for i := len(prob.Boxes) - 1; i >= 0; i-- {
+		n := min(rest/prob.Boxes[i].Size, prob.Max[i])
+
+		counts[i] += n
+		rest -= n * prob.Boxes[i].Size
+	}
+
+	for rest > 0 {
+		pick := -1
+
+		for i, b := range prob.Boxes {
+			if counts[i] >= prob.Max[i] {
+				continue
+			}
+
+			pick = i
+
+			if b.Size >= rest {
+				break
+			}
+		}
+
+		if pick < 0 {
+			return nil, fmt.Errorf("%w: %d items left unpacked", ErrInsufficientStock, rest)
+		}
+
+		counts[pick]++
+		rest -= min(rest, prob.Boxes[pick].Size)
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,104 @@
+package packer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/obalunenko/logger"
+)
+
+// ErrNoPreviousBoxes is returned by Rollback when the box set was never replaced.
+var ErrNoPreviousBoxes = errors.New("no previous box set")
+
+// liveBoxes holds the box set orders are packed with and the one it replaced.
+// Readers load the current set without locking, replacements are serialized.
+type liveBoxes struct {
+	mu       sync.Mutex
+	current  atomic.Pointer[[]Box]
+	previous []Box
+}
+
+func newLiveBoxes(boxes []Box) *liveBoxes {
+	var l liveBoxes
+
+	l.current.Store(&boxes)
+
+	return &l
+}
+
+// loaded returns copy of the packer with the current box set, so that an order is packed
+// with the same boxes from start to end while the set is replaced.
+func (p Packer) loaded() Packer {
+	if p.live != nil {
+		p.boxes = *p.live.current.Load()
+	}
+
+	return p
+}
+
+// SetBoxes replaces the box set of the packer. Orders being packed finish with the boxes they started with.
+// The new set is validated before it goes live, the replaced one is kept for Rollback.
+// The same set as the current one changes nothing, so the set to roll back to is kept.
+// Packaging levels stay as they are: their boxes are sized by the number of packs they hold,
+// so they were validated with no regard to box sizes and pack the boxes of any set.
+func (p Packer) SetBoxes(ctx context.Context, boxes []Box) error {
+	next := p
+	next.boxes = sortBoxes(boxes)
+
+	if err := next.validate(); err != nil {
+		return fmt.Errorf("invalid box set: %w", err)
+	}
+
+	p.live.mu.Lock()
+	defer p.live.mu.Unlock()
+
+	if slices.Equal(*p.live.current.Load(), next.boxes) {
+		log.WithField(ctx, "boxes", next.boxes).Debug("Box set unchanged")
+
+		return nil
+	}
+
+	prev := p.live.current.Swap(&next.boxes)
+	p.live.previous = *prev
+
+	log.WithFields(ctx, log.Fields{
+		"boxes":    next.boxes,
+		"previous": *prev,
+	}).Info("Box set replaced")
+
+	return nil
+}
+
+// Rollback swaps the box set with the one it replaced, so that a second rollback restores it.
+func (p Packer) Rollback(ctx context.Context) error {
+	p.live.mu.Lock()
+	defer p.live.mu.Unlock()
+
+	if p.live.previous == nil {
+		return ErrNoPreviousBoxes
+	}
+
+	boxes := p.live.previous
+
+	prev := p.live.current.Swap(&boxes)
+	p.live.previous = *prev
+
+	log.WithFields(ctx, log.Fields{
+		"boxes":    boxes,
+		"previous": *prev,
+	}).Info("Box set rolled back")
+
+	return nil
+}
+
+// PreviousBoxes returns the box set replaced by the current one, nil if it was never replaced.
+func (p Packer) PreviousBoxes() []Box {
+	p.live.mu.Lock()
+	defer p.live.mu.Unlock()
+
+	return slices.Clone(p.live.previous)
+}
@@ -0,0 +1,119 @@
+package packer
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obalunenko/orderpacker/internal/testlogger"
+)
+
+func TestPacker_SetBoxes(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := NewPacker(ctx, WithBoxes([]uint{250, 500}))
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, p.Rollback(ctx), ErrNoPreviousBoxes)
+	assert.Nil(t, p.PreviousBoxes())
+
+	require.NoError(t, p.SetBoxes(ctx, SizedBoxes([]uint{300, 100})))
+
+	assert.Equal(t, SizedBoxes([]uint{100, 300}), p.Boxes())
+	assert.Equal(t, SizedBoxes([]uint{250, 500}), p.PreviousBoxes())
+
+	got, err := p.PackOrder(ctx, 400)
+	require.NoError(t, err)
+	assert.Equal(t, []BoxQuantity{{Box: 300, Quantity: 1}, {Box: 100, Quantity: 1}}, got.Packs)
+
+	// An invalid set does not go live.
+	assert.Error(t, p.SetBoxes(ctx, SizedBoxes([]uint{0, 10})))
+	assert.Error(t, p.SetBoxes(ctx, nil))
+	assert.Equal(t, SizedBoxes([]uint{100, 300}), p.Boxes())
+
+	require.NoError(t, p.Rollback(ctx))
+
+	assert.Equal(t, SizedBoxes([]uint{250, 500}), p.Boxes())
+	assert.Equal(t, SizedBoxes([]uint{100, 300}), p.PreviousBoxes())
+
+	got, err = p.PackOrder(ctx, 400)
+	require.NoError(t, err)
+	assert.Equal(t, []BoxQuantity{{Box: 500, Quantity: 1}}, got.Packs)
+
+	// Rolling back again restores the replaced set.
+	require.NoError(t, p.Rollback(ctx))
+
+	assert.Equal(t, SizedBoxes([]uint{100, 300}), p.Boxes())
+
+	// The same set keeps the set to roll back to.
+	require.NoError(t, p.SetBoxes(ctx, SizedBoxes([]uint{300, 100, 100})))
+
+	assert.Equal(t, SizedBoxes([]uint{250, 500}), p.PreviousBoxes())
+}
+
+func TestPacker_SetBoxes_levels(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := NewPacker(ctx, WithBoxes([]uint{10}), WithLevels(Level{Name: "carton", Boxes: SizedBoxes([]uint{4})}))
+	require.NoError(t, err)
+
+	require.NoError(t, p.SetBoxes(ctx, SizedBoxes([]uint{5})))
+
+	got, err := p.PackNested(ctx, 20)
+	require.NoError(t, err)
+
+	assert.Equal(t, []NestedPack{
+		{Level: "carton", Box: 4, Quantity: 1, Contents: []NestedPack{{Level: BoxLevel, Box: 5, Quantity: 4}}},
+	}, got.Packs)
+}
+
+func TestPacker_SetBoxes_concurrent(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	sets := [][]uint{{250, 500}, {100, 300}}
+
+	p, err := NewPacker(ctx, WithBoxes(sets[0]))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for i := range 100 {
+			assert.NoError(t, p.SetBoxes(ctx, SizedBoxes(sets[(i+1)%2])))
+		}
+	}()
+
+	for range 4 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for range 100 {
+				got, err := p.PackOrder(ctx, 400)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				// Every packing uses boxes of a single set.
+				switch got.Packs[0].Box {
+				case 500:
+					assert.Equal(t, []BoxQuantity{{Box: 500, Quantity: 1}}, got.Packs)
+				case 300:
+					assert.Equal(t, []BoxQuantity{{Box: 300, Quantity: 1}, {Box: 100, Quantity: 1}}, got.Packs)
+				default:
+					t.Errorf("unexpected packs %v", got.Packs)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
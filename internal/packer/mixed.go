@@ -0,0 +1,526 @@
+package packer
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"math/bits"
+	"slices"
+
+	log "github.com/obalunenko/logger"
+)
+
+// maxMixedBoxes limits the number of boxes of a mixed packing, which is built box by box.
+const maxMixedBoxes = 1 << 12
+
+// LineQuantity is a number of items of a product.
+type LineQuantity struct {
+	SKU      string
+	Quantity uint
+}
+
+// MixedBox is a box shared by items of several products.
+type MixedBox struct {
+	Box uint
+	// Contents hold quantities per product in order of the request.
+	Contents []LineQuantity
+	// Fill is how much of the box the contents take up.
+	Fill Fill
+}
+
+// MixedResult is a packing of several products into shared boxes.
+type MixedResult struct {
+	// Boxes hold contents of each box, the fullest box first.
+	Boxes []MixedBox
+	// Items is the number of ordered items of all products, all of them are shipped.
+	Items uint
+	// PackCount is the number of boxes.
+	PackCount uint
+	// Cost is the cost of the boxes.
+	Cost float64
+}
+
+// PackMixed packs items of several products into shared boxes of the packer box set.
+// Products are placed first-fit-decreasing, the largest unit first, then an improvement pass
+// empties underfilled boxes into others and replaces each box with the cheapest smaller one its contents fit.
+// Boxes hold items up to their size and weight and volume limits and are used as their rules allow for the order total,
+// but for the minimum quantity, which mixed packing ignores.
+func (p Packer) PackMixed(ctx context.Context, lines []LineItem, opts ...OrderOption) (MixedResult, error) {
+	p = p.loaded()
+
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var o orderOptions
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if p.inventory == nil {
+		return p.packMixed(ctx, lines, nil)
+	}
+
+	if !o.commit {
+		return p.packMixed(ctx, lines, p.inventory.Stock())
+	}
+
+	res, err := commit(ctx, p.inventory, func(stock map[uint]uint) (MixedResult, error) {
+		return p.packMixed(ctx, lines, stock)
+	}, func(res MixedResult) map[uint]uint {
+		boxes := make(map[uint]uint, len(res.Boxes))
+
+		for _, b := range res.Boxes {
+			boxes[b.Box]++
+		}
+
+		return boxes
+	})
+	if err != nil {
+		return MixedResult{}, err
+	}
+
+	log.WithFields(ctx, log.Fields{
+		"lines": len(lines),
+		"boxes": res.PackCount,
+	}).Info("Mixed packing committed")
+
+	return res, nil
+}
+
+func (p Packer) packMixed(ctx context.Context, lines []LineItem, stock map[uint]uint) (MixedResult, error) {
+	log.WithFields(ctx, log.Fields{
+		"lines": len(lines),
+		"boxes": p.boxes,
+		"stock": stock,
+	}).Debug("Packing mixed order")
+
+	var total uint
+
+	for _, l := range lines {
+		var carry uint
+
+		if total, carry = bits.Add(total, l.Quantity, 0); carry != 0 {
+			return MixedResult{}, fmt.Errorf("%w: total of the order overflows", ErrOrderTooLarge)
+		}
+
+		if err := l.Unit.validate(); err != nil {
+			return MixedResult{}, fmt.Errorf("sku %q: %w", l.SKU, err)
+		}
+	}
+
+	m := mixedPacking{
+		lines: lines,
+	}
+
+	for _, b := range p.boxes {
+		if !b.eligible(total) {
+			continue
+		}
+
+		left := uint(Unlimited)
+
+		if n, ok := stock[b.Size]; ok {
+			left = n
+		}
+
+		if b.MaxQuantity != 0 {
+			left = min(left, b.MaxQuantity)
+		}
+
+		m.boxes = append(m.boxes, b)
+		m.left = append(m.left, left)
+	}
+
+	if len(m.boxes) == 0 {
+		return MixedResult{}, fmt.Errorf("%w: %d items", ErrNoEligibleBox, total)
+	}
+
+	for _, l := range lines {
+		if m.largestFor(l.Unit, false) < 0 {
+			return MixedResult{}, fmt.Errorf("sku %q: %w: weight %v, volume %v", l.SKU, ErrUnitTooLarge, l.Unit.Weight, l.Unit.Volume)
+		}
+	}
+
+	if err := m.firstFitDecreasing(); err != nil {
+		return MixedResult{}, err
+	}
+
+	if !m.improve(ctx) {
+		if !p.anytime {
+			return MixedResult{}, fmt.Errorf("failed to improve mixed packing: %w", context.Cause(ctx))
+		}
+
+		log.WithError(ctx, context.Cause(ctx)).WithField("lines", len(lines)).
+			Warn("Improvement of mixed packing interrupted, packing may be not optimal")
+	}
+
+	return m.result(), nil
+}
+
+// mixedBin is a box being packed.
+type mixedBin struct {
+	// box is the index of the box in the box set.
+	box    int
+	items  uint
+	weight float64
+	volume float64
+	// counts hold the number of items per line index.
+	counts  map[int]uint
+	removed bool
+}
+
+// room returns how many more units the bin takes in the box.
+func (b *mixedBin) room(box Box, u Unit) uint {
+	if b.items >= box.Size {
+		return 0
+	}
+
+	n := box.Size - b.items
+
+	limit := func(maxLoad, load, unit float64) {
+		if maxLoad <= 0 || unit <= 0 {
+			return
+		}
+
+		k := math.Floor(max(maxLoad-load, 0)/unit + fitEpsilon)
+		if k < float64(n) {
+			n = uint(k)
+		}
+	}
+
+	limit(box.MaxWeight, b.weight, u.Weight)
+	limit(box.MaxVolume, b.volume, u.Volume)
+
+	return n
+}
+
+func (b *mixedBin) add(line int, n uint, u Unit) {
+	b.items += n
+	b.weight += float64(n) * u.Weight
+	b.volume += float64(n) * u.Volume
+	b.counts[line] += n
+}
+
+// fits reports whether the bin contents fit the box.
+func (b *mixedBin) fits(box Box) bool {
+	within := func(maxLoad, load float64) bool {
+		return maxLoad <= 0 || load <= maxLoad*(1+fitEpsilon)
+	}
+
+	return b.items <= box.Size && within(box.MaxWeight, b.weight) && within(box.MaxVolume, b.volume)
+}
+
+// fill returns the largest share of box size, weight or volume limit the bin takes.
+func (b *mixedBin) fill(box Box) float64 {
+	f := float64(b.items) / float64(box.Size)
+
+	if box.MaxWeight > 0 {
+		f = max(f, b.weight/box.MaxWeight)
+	}
+
+	if box.MaxVolume > 0 {
+		f = max(f, b.volume/box.MaxVolume)
+	}
+
+	return f
+}
+
+// mixedPacking packs lines into bins, left holds the number of boxes available per box index.
+type mixedPacking struct {
+	boxes []Box
+	lines []LineItem
+	left  []uint
+	bins  []mixedBin
+}
+
+// largestFor returns the index of the box holding most of the units, the cheapest of equal ones,
+// or -1 if no box holds one. With available set only boxes in stock are considered.
+func (m *mixedPacking) largestFor(u Unit, available bool) int {
+	best, bestCap := -1, uint(0)
+
+	for i, b := range m.boxes {
+		if available && m.left[i] == 0 {
+			continue
+		}
+
+		c := capacityOf(b, u)
+		if c == 0 {
+			continue
+		}
+
+		if best < 0 || c > bestCap || (c == bestCap && b.Cost < m.boxes[best].Cost) {
+			best, bestCap = i, c
+		}
+	}
+
+	return best
+}
+
+// firstFitDecreasing places lines from the largest unit to the smallest into the first bin with room,
+// opening a box that holds most of the units when no bin has room.
+func (m *mixedPacking) firstFitDecreasing() error {
+	largest := m.boxes[len(m.boxes)-1]
+
+	// share is the largest share of the largest box one unit takes.
+	share := func(u Unit) float64 {
+		e := mixedBin{items: 1, weight: u.Weight, volume: u.Volume}
+
+		return e.fill(largest)
+	}
+
+	order := make([]int, len(m.lines))
+	for i := range order {
+		order[i] = i
+	}
+
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(share(m.lines[b].Unit), share(m.lines[a].Unit))
+	})
+
+	for _, li := range order {
+		l := m.lines[li]
+		rest := l.Quantity
+
+		for bi := range m.bins {
+			if rest == 0 {
+				break
+			}
+
+			b := &m.bins[bi]
+
+			if n := min(rest, b.room(m.boxes[b.box], l.Unit)); n != 0 {
+				b.add(li, n, l.Unit)
+				rest -= n
+			}
+		}
+
+		for rest != 0 {
+			box := m.largestFor(l.Unit, true)
+			if box < 0 {
+				return fmt.Errorf("%w: no box left for sku %q", ErrInsufficientStock, l.SKU)
+			}
+
+			if len(m.bins) == maxMixedBoxes {
+				return fmt.Errorf("%w: more than %d boxes", ErrOrderTooLarge, maxMixedBoxes)
+			}
+
+			m.take(box)
+
+			b := mixedBin{box: box, counts: make(map[int]uint)}
+
+			n := min(rest, b.room(m.boxes[box], l.Unit))
+			b.add(li, n, l.Unit)
+			rest -= n
+
+			m.bins = append(m.bins, b)
+		}
+	}
+
+	return nil
+}
+
+// improve empties underfilled bins into the others, the emptiest first, and then replaces
+// each box with the cheapest, then the smallest, box available its contents fit. Emptying bins stops
+// when the context is done, improve reports whether it went through all of them.
+func (m *mixedPacking) improve(ctx context.Context) bool {
+	done := true
+
+	order := make([]int, len(m.bins))
+	for i := range order {
+		order[i] = i
+	}
+
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(m.fillOf(a), m.fillOf(b))
+	})
+
+	for _, i := range order {
+		if ctx.Err() != nil {
+			done = false
+
+			break
+		}
+
+		if m.fillOf(i) < 1 {
+			m.tryEmpty(i)
+		}
+	}
+
+	m.bins = slices.DeleteFunc(m.bins, func(b mixedBin) bool {
+		return b.removed
+	})
+
+	for i := range m.bins {
+		m.downsize(i)
+	}
+
+	return done
+}
+
+func (m *mixedPacking) fillOf(i int) float64 {
+	return m.bins[i].fill(m.boxes[m.bins[i].box])
+}
+
+// tryEmpty moves contents of i-th bin into other bins, or leaves all bins as they are if they do not take it all.
+func (m *mixedPacking) tryEmpty(i int) {
+	type move struct {
+		to, line int
+		n        uint
+	}
+
+	var moves []move
+
+	undo := func() {
+		for _, mv := range moves {
+			b := &m.bins[mv.to]
+			b.counts[mv.line] -= mv.n
+
+			if b.counts[mv.line] == 0 {
+				delete(b.counts, mv.line)
+			}
+
+			m.recount(b)
+		}
+	}
+
+	src := &m.bins[i]
+
+	for _, li := range sortedKeys(src.counts) {
+		u := m.lines[li].Unit
+		rest := src.counts[li]
+
+		for j := range m.bins {
+			if rest == 0 {
+				break
+			}
+
+			b := &m.bins[j]
+			if j == i || b.removed {
+				continue
+			}
+
+			if n := min(rest, b.room(m.boxes[b.box], u)); n != 0 {
+				b.add(li, n, u)
+				rest -= n
+
+				moves = append(moves, move{to: j, line: li, n: n})
+			}
+		}
+
+		if rest != 0 {
+			undo()
+
+			return
+		}
+	}
+
+	src.removed = true
+	m.put(src.box)
+}
+
+// downsize replaces box of i-th bin with the cheapest, then the smallest, available box its contents fit.
+func (m *mixedPacking) downsize(i int) {
+	b := &m.bins[i]
+	best := b.box
+
+	for k, box := range m.boxes {
+		if k == b.box || m.left[k] == 0 || !b.fits(box) {
+			continue
+		}
+
+		cur := m.boxes[best]
+		if box.Cost < cur.Cost-CostEpsilon || (math.Abs(box.Cost-cur.Cost) <= CostEpsilon && box.Size < cur.Size) {
+			best = k
+		}
+	}
+
+	if best != b.box {
+		m.put(b.box)
+		m.take(best)
+		b.box = best
+	}
+}
+
+// recount recomputes the load of the bin from its contents.
+func (m *mixedPacking) recount(b *mixedBin) {
+	b.items, b.weight, b.volume = 0, 0, 0
+
+	for li, n := range b.counts {
+		u := m.lines[li].Unit
+
+		b.items += n
+		b.weight += float64(n) * u.Weight
+		b.volume += float64(n) * u.Volume
+	}
+}
+
+func (m *mixedPacking) take(box int) {
+	if m.left[box] != Unlimited {
+		m.left[box]--
+	}
+}
+
+func (m *mixedPacking) put(box int) {
+	if m.left[box] != Unlimited {
+		m.left[box]++
+	}
+}
+
+func (m *mixedPacking) result() MixedResult {
+	var res MixedResult
+
+	slices.SortStableFunc(m.bins, func(a, b mixedBin) int {
+		return cmp.Compare(b.fill(m.boxes[b.box]), a.fill(m.boxes[a.box]))
+	})
+
+	for i := range m.bins {
+		b := &m.bins[i]
+		box := m.boxes[b.box]
+
+		mb := MixedBox{
+			Box:      box.Size,
+			Contents: make([]LineQuantity, 0, len(b.counts)),
+			Fill: Fill{
+				Items:  b.items,
+				Weight: b.weight,
+				Volume: b.volume,
+			},
+		}
+
+		if box.MaxWeight > 0 {
+			mb.Fill.WeightRatio = b.weight / box.MaxWeight
+		}
+
+		if box.MaxVolume > 0 {
+			mb.Fill.VolumeRatio = b.volume / box.MaxVolume
+		}
+
+		for _, li := range sortedKeys(b.counts) {
+			mb.Contents = append(mb.Contents, LineQuantity{
+				SKU:      m.lines[li].SKU,
+				Quantity: b.counts[li],
+			})
+		}
+
+		res.Boxes = append(res.Boxes, mb)
+		res.Items += b.items
+		res.PackCount++
+		res.Cost += box.Cost
+	}
+
+	return res
+}
+
+func sortedKeys(counts map[int]uint) []int {
+	keys := make([]int, 0, len(counts))
+
+	for k := range counts {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
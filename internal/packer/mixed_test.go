@@ -0,0 +1,176 @@
+package packer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obalunenko/orderpacker/internal/inventory"
+	"github.com/obalunenko/orderpacker/internal/testlogger"
+)
+
+func TestPacker_PackMixed(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	tests := []struct {
+		name    string
+		boxes   []Box
+		lines   []LineItem
+		want    MixedResult
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:  "shared box",
+			boxes: SizedBoxes([]uint{10, 20, 50}),
+			lines: []LineItem{{SKU: "a", Quantity: 15}, {SKU: "b", Quantity: 20}, {SKU: "c", Quantity: 7}},
+			want: MixedResult{
+				Boxes: []MixedBox{
+					{
+						Box:      50,
+						Contents: []LineQuantity{{SKU: "a", Quantity: 15}, {SKU: "b", Quantity: 20}, {SKU: "c", Quantity: 7}},
+						Fill:     Fill{Items: 42},
+					},
+				},
+				Items:     42,
+				PackCount: 1,
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:  "heavy units first",
+			boxes: []Box{{Size: 100, MaxWeight: 10}},
+			lines: []LineItem{{SKU: "light", Quantity: 10, Unit: Unit{Weight: 0.5}}, {SKU: "heavy", Quantity: 3, Unit: Unit{Weight: 4}}},
+			want: MixedResult{
+				Boxes: []MixedBox{
+					{
+						Box:      100,
+						Contents: []LineQuantity{{SKU: "light", Quantity: 4}, {SKU: "heavy", Quantity: 2}},
+						Fill:     Fill{Items: 6, Weight: 10, WeightRatio: 1},
+					},
+					{
+						Box:      100,
+						Contents: []LineQuantity{{SKU: "light", Quantity: 6}, {SKU: "heavy", Quantity: 1}},
+						Fill:     Fill{Items: 7, Weight: 7, WeightRatio: 0.7},
+					},
+				},
+				Items:     13,
+				PackCount: 2,
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:  "last box downsized",
+			boxes: []Box{{Size: 10, Cost: 1}, {Size: 50, Cost: 3}},
+			lines: []LineItem{{SKU: "a", Quantity: 55}},
+			want: MixedResult{
+				Boxes: []MixedBox{
+					{Box: 50, Contents: []LineQuantity{{SKU: "a", Quantity: 50}}, Fill: Fill{Items: 50}},
+					{Box: 10, Contents: []LineQuantity{{SKU: "a", Quantity: 5}}, Fill: Fill{Items: 5}},
+				},
+				Items:     55,
+				PackCount: 2,
+				Cost:      4,
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "unit too large",
+			boxes:   []Box{{Size: 100, MaxWeight: 10}},
+			lines:   []LineItem{{SKU: "a", Quantity: 1}, {SKU: "anvil", Quantity: 1, Unit: Unit{Weight: 11}}},
+			wantErr: assertErrorIs(ErrUnitTooLarge),
+		},
+		{
+			name:    "too many boxes",
+			boxes:   SizedBoxes([]uint{1}),
+			lines:   []LineItem{{SKU: "a", Quantity: maxMixedBoxes + 1}},
+			wantErr: assertErrorIs(ErrOrderTooLarge),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPacker(ctx, WithBoxSet(tt.boxes))
+			require.NoError(t, err)
+
+			got, err := p.PackMixed(ctx, tt.lines)
+			if !tt.wantErr(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPacker_PackMixed_stock(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	inv := inventory.New(map[uint]uint{10: 7, 50: 0})
+
+	p, err := NewPacker(ctx, WithBoxes([]uint{10, 50}), WithInventory(inv))
+	require.NoError(t, err)
+
+	got, err := p.PackMixed(ctx, []LineItem{{SKU: "a", Quantity: 40}, {SKU: "b", Quantity: 15}}, WithCommit())
+	require.NoError(t, err)
+
+	assert.Equal(t, uint(6), got.PackCount)
+	assert.Equal(t, []LineQuantity{{SKU: "b", Quantity: 5}}, got.Boxes[5].Contents)
+	assert.Equal(t, map[uint]uint{10: 1, 50: 0}, inv.Stock())
+
+	_, err = p.PackMixed(ctx, []LineItem{{SKU: "a", Quantity: 11}}, WithCommit())
+	require.ErrorIs(t, err, ErrInsufficientStock)
+	assert.Equal(t, map[uint]uint{10: 1, 50: 0}, inv.Stock(), "failed commit must not change stock")
+}
+
+func TestPacker_PackMixed_rules(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := NewPacker(ctx, WithBoxSet([]Box{{Size: 10}, {Size: 50, MaxQuantity: 1}, {Size: 100, MinOrder: 200}}))
+	require.NoError(t, err)
+
+	boxes := func(res MixedResult) map[uint]uint {
+		counts := make(map[uint]uint)
+
+		for _, b := range res.Boxes {
+			counts[b.Box]++
+		}
+
+		return counts
+	}
+
+	got, err := p.PackMixed(ctx, []LineItem{{SKU: "a", Quantity: 40}, {SKU: "b", Quantity: 30}})
+	require.NoError(t, err)
+	assert.Equal(t, map[uint]uint{50: 1, 10: 2}, boxes(got))
+
+	got, err = p.PackMixed(ctx, []LineItem{{SKU: "a", Quantity: 120}, {SKU: "b", Quantity: 80}})
+	require.NoError(t, err)
+	assert.Equal(t, map[uint]uint{100: 2}, boxes(got))
+}
+
+func TestMixedPacking_improve(t *testing.T) {
+	lines := []LineItem{{SKU: "a", Quantity: 6}}
+
+	m := mixedPacking{
+		boxes: []Box{{Size: 5, Cost: 1}, {Size: 10, Cost: 1}},
+		lines: lines,
+		left:  []uint{Unlimited, Unlimited},
+		bins: []mixedBin{
+			{box: 1, items: 3, counts: map[int]uint{0: 3}},
+			{box: 1, items: 3, counts: map[int]uint{0: 3}},
+		},
+	}
+
+	assert.True(t, m.improve(context.Background()))
+
+	require.Len(t, m.bins, 1)
+	assert.Equal(t, uint(6), m.bins[0].items)
+	assert.Equal(t, 1, m.bins[0].box, "contents do not fit the smaller box")
+}
+
+func assertErrorIs(target error) assert.ErrorAssertionFunc {
+	return func(t assert.TestingT, err error, msgAndArgs ...any) bool {
+		return assert.ErrorIs(t, err, target, msgAndArgs...)
+	}
+}
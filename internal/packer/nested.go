@@ -0,0 +1,305 @@
+package packer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	log "github.com/obalunenko/logger"
+)
+
+// ErrNoLevels is returned for a nested packing by a packer without packaging levels.
+var ErrNoLevels = errors.New("no packaging levels")
+
+// BoxLevel is the name of the innermost packaging level, the boxes items are packed in.
+const BoxLevel = "box"
+
+// Level is a packaging level that nests packs of the level below, e.g. cartons of boxes or pallets of cartons.
+// Sizes of its boxes are numbers of packs of the level below they hold.
+type Level struct {
+	Name  string
+	Boxes []Box
+}
+
+// level packs packs of the level below.
+type level struct {
+	name   string
+	packer *Packer
+}
+
+// WithLevels sets packaging levels above boxes, innermost first.
+// Each level is packed by the solver of the packer in boxes of its own.
+func WithLevels(levels ...Level) PackerOption {
+	return func(p *Packer) {
+		p.levelSpecs = levels
+	}
+}
+
+// newLevels returns packers of the packaging levels, they solve with the solver and objective of p.
+func (p Packer) newLevels(ctx context.Context) ([]level, error) {
+	if len(p.levelSpecs) == 0 {
+		return nil, nil
+	}
+
+	levels := make([]level, 0, len(p.levelSpecs))
+	seen := map[string]bool{BoxLevel: true}
+
+	for _, spec := range p.levelSpecs {
+		if spec.Name == "" {
+			return nil, errors.New("packaging level without name")
+		}
+
+		if seen[spec.Name] {
+			return nil, fmt.Errorf("duplicated packaging level %q", spec.Name)
+		}
+
+		seen[spec.Name] = true
+
+		lp, err := NewPacker(ctx,
+			WithBoxSet(spec.Boxes),
+			WithSolver(p.solver),
+			WithObjective(p.objective),
+			WithAnytime(p.anytime),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("invalid packaging level %q: %w", spec.Name, err)
+		}
+
+		levels = append(levels, level{name: spec.Name, packer: lp})
+	}
+
+	return levels, nil
+}
+
+// ParseLevels parses semicolon separated packaging levels in form "name=boxes", innermost first,
+// e.g. "carton=4,8;pallet=10,20". Boxes are in the format of ParseBoxes, sized by the number of packs they hold.
+func ParseLevels(s string) ([]Level, error) {
+	var levels []Level
+
+	for _, field := range strings.Split(s, ";") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		name, boxesStr, ok := strings.Cut(field, "=")
+		name = strings.TrimSpace(name)
+
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid packaging level %q: expected name=boxes", field)
+		}
+
+		if name == BoxLevel || slices.ContainsFunc(levels, func(l Level) bool { return l.Name == name }) {
+			return nil, fmt.Errorf("duplicated packaging level %q", name)
+		}
+
+		boxes, err := ParseBoxes(boxesStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid packaging level %q: %w", name, err)
+		}
+
+		levels = append(levels, Level{Name: name, Boxes: boxes})
+	}
+
+	return levels, nil
+}
+
+// LevelResult is a packing of a packaging level, its items are packs of the level below.
+type LevelResult struct {
+	Level string
+	PackResult
+}
+
+// NestedPack is a number of identical packs of a packaging level with the packs of the level below each holds.
+type NestedPack struct {
+	Level    string
+	Box      uint
+	Quantity uint
+	// Contents hold packs of the level below in each pack, none at the box level.
+	Contents []NestedPack
+}
+
+// NestedResult is a packing of an order in nested packaging levels.
+type NestedResult struct {
+	// Items is the number of ordered items.
+	Items uint
+	// Levels hold packings of each level, the box level first.
+	Levels []LevelResult
+	// Packs are the packs of the outermost level with their contents.
+	// Packs are filled in order, the largest first, so that all but the last packs of a level are full.
+	Packs []NestedPack
+}
+
+// PackNested returns the packing of the given number of items in boxes, of the boxes in packs of the next level
+// and so on up to the outermost level. Order options apply to the box level, packs of the other levels
+// are never taken off the inventory stock.
+func (p Packer) PackNested(ctx context.Context, items uint, opts ...OrderOption) (NestedResult, error) {
+	p = p.loaded()
+
+	if len(p.levels) == 0 {
+		return NestedResult{}, ErrNoLevels
+	}
+
+	var o orderOptions
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	packAll := func(stock map[uint]uint) (NestedResult, error) {
+		boxes, err := p.pack(ctx, items, o, stock)
+		if err != nil {
+			return NestedResult{}, err
+		}
+
+		return p.nest(ctx, boxes)
+	}
+
+	var (
+		res NestedResult
+		err error
+	)
+
+	switch {
+	case p.inventory == nil:
+		res, err = packAll(nil)
+	case !o.commit:
+		res, err = packAll(p.inventory.Stock())
+	default:
+		res, err = commit(ctx, p.inventory, packAll, func(res NestedResult) map[uint]uint {
+			return usedBoxes(res.Levels[0].PackResult)
+		})
+	}
+
+	if err != nil {
+		return NestedResult{}, err
+	}
+
+	log.WithFields(ctx, log.Fields{
+		"items":  items,
+		"levels": len(res.Levels),
+		"packs":  res.Packs,
+	}).Debug("Order packed in levels")
+
+	return res, nil
+}
+
+// nest packs the packs of each level in the next one, starting with the box packing.
+func (p Packer) nest(ctx context.Context, boxes PackResult) (NestedResult, error) {
+	res := NestedResult{
+		Items:  boxes.Items,
+		Levels: make([]LevelResult, 0, len(p.levels)+1),
+	}
+
+	res.Levels = append(res.Levels, LevelResult{Level: BoxLevel, PackResult: boxes})
+
+	packs := make([]NestedPack, 0, len(boxes.Packs))
+
+	for _, q := range boxes.Packs {
+		packs = append(packs, NestedPack{Level: BoxLevel, Box: q.Box, Quantity: q.Quantity})
+	}
+
+	below := boxes
+
+	for _, l := range p.levels {
+		lp := l.packer.loaded()
+
+		lr, err := lp.pack(ctx, below.PackCount, orderOptions{}, nil)
+		if err != nil {
+			return NestedResult{}, fmt.Errorf("failed to pack %s level: %w", l.name, err)
+		}
+
+		res.Levels = append(res.Levels, LevelResult{Level: l.name, PackResult: lr})
+		packs = fill(l.name, lr.Packs, packs)
+		below = lr
+	}
+
+	res.Packs = packs
+
+	return res, nil
+}
+
+// fill puts packs into containers in order, each container is filled before the next one,
+// and groups consecutive containers of the same contents. Containers filled from a single group of packs
+// are grouped at once, so the number of groups does not depend on the number of packs.
+func fill(name string, containers []BoxQuantity, packs []NestedPack) []NestedPack {
+	var (
+		res []NestedPack
+		// next is the group of packs to fill containers from, left is the number of packs left in it.
+		next, left = 0, uint(0)
+	)
+
+	if len(packs) != 0 {
+		left = packs[0].Quantity
+	}
+
+	advance := func() {
+		for left == 0 && next < len(packs) {
+			next++
+
+			if next < len(packs) {
+				left = packs[next].Quantity
+			}
+		}
+	}
+
+	for _, c := range containers {
+		for remaining := c.Quantity; remaining > 0; {
+			if left >= c.Box {
+				n := min(remaining, left/c.Box)
+				res = appendNested(res, NestedPack{Level: name, Box: c.Box, Quantity: n, Contents: []NestedPack{withQuantity(packs[next], c.Box)}})
+				left -= n * c.Box
+				remaining -= n
+
+				advance()
+
+				continue
+			}
+
+			// The container takes packs of several groups or is the last one, partly filled.
+			var contents []NestedPack
+
+			for free := c.Box; free > 0 && left > 0; {
+				n := min(free, left)
+				contents = append(contents, withQuantity(packs[next], n))
+				left -= n
+				free -= n
+
+				advance()
+			}
+
+			res = appendNested(res, NestedPack{Level: name, Box: c.Box, Quantity: 1, Contents: contents})
+			remaining--
+		}
+	}
+
+	return res
+}
+
+// appendNested appends the group of packs, merging it with the last one of the same box and contents.
+func appendNested(packs []NestedPack, np NestedPack) []NestedPack {
+	if n := len(packs); n != 0 && packs[n-1].Box == np.Box && sameContents(packs[n-1].Contents, np.Contents) {
+		packs[n-1].Quantity += np.Quantity
+
+		return packs
+	}
+
+	return append(packs, np)
+}
+
+func sameContents(a, b []NestedPack) bool {
+	return slices.EqualFunc(a, b, func(x, y NestedPack) bool {
+		return x.Level == y.Level && x.Box == y.Box && x.Quantity == y.Quantity && sameContents(x.Contents, y.Contents)
+	})
+}
+
+func withQuantity(np NestedPack, n uint) NestedPack {
+	np.Quantity = n
+
+	return np
+}
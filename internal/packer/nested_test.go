@@ -0,0 +1,191 @@
+package packer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obalunenko/orderpacker/internal/inventory"
+	"github.com/obalunenko/orderpacker/internal/testlogger"
+)
+
+func TestPacker_PackNested(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	tests := []struct {
+		name   string
+		boxes  []uint
+		levels []Level
+		items  uint
+		want   []NestedPack
+	}{
+		{
+			name:   "boxes of the order in a carton on a pallet",
+			boxes:  DefaultBoxes,
+			levels: []Level{{Name: "carton", Boxes: SizedBoxes([]uint{2, 4})}, {Name: "pallet", Boxes: SizedBoxes([]uint{3})}},
+			items:  12001,
+			want: []NestedPack{
+				{Level: "pallet", Box: 3, Quantity: 1, Contents: []NestedPack{
+					{Level: "carton", Box: 4, Quantity: 1, Contents: []NestedPack{
+						{Level: BoxLevel, Box: 5000, Quantity: 2},
+						{Level: BoxLevel, Box: 2000, Quantity: 1},
+						{Level: BoxLevel, Box: 250, Quantity: 1},
+					}},
+				}},
+			},
+		},
+		{
+			name:   "full packs are grouped, the last one is partly filled",
+			boxes:  []uint{10},
+			levels: []Level{{Name: "carton", Boxes: SizedBoxes([]uint{4})}, {Name: "pallet", Boxes: SizedBoxes([]uint{2})}},
+			items:  100,
+			want: []NestedPack{
+				{Level: "pallet", Box: 2, Quantity: 1, Contents: []NestedPack{
+					{Level: "carton", Box: 4, Quantity: 2, Contents: []NestedPack{{Level: BoxLevel, Box: 10, Quantity: 4}}},
+				}},
+				{Level: "pallet", Box: 2, Quantity: 1, Contents: []NestedPack{
+					{Level: "carton", Box: 4, Quantity: 1, Contents: []NestedPack{{Level: BoxLevel, Box: 10, Quantity: 2}}},
+				}},
+			},
+		},
+		{
+			name:   "a carton takes boxes of several sizes",
+			boxes:  []uint{10, 20},
+			levels: []Level{{Name: "carton", Boxes: SizedBoxes([]uint{2})}},
+			items:  50,
+			want: []NestedPack{
+				{Level: "carton", Box: 2, Quantity: 1, Contents: []NestedPack{{Level: BoxLevel, Box: 20, Quantity: 2}}},
+				{Level: "carton", Box: 2, Quantity: 1, Contents: []NestedPack{{Level: BoxLevel, Box: 10, Quantity: 1}}},
+			},
+		},
+		{
+			name:   "large order",
+			boxes:  []uint{1},
+			levels: []Level{{Name: "carton", Boxes: SizedBoxes([]uint{1000})}, {Name: "pallet", Boxes: SizedBoxes([]uint{50})}},
+			items:  1_000_000_000_000,
+			want: []NestedPack{
+				{Level: "pallet", Box: 50, Quantity: 20_000_000, Contents: []NestedPack{
+					{Level: "carton", Box: 1000, Quantity: 50, Contents: []NestedPack{{Level: BoxLevel, Box: 1, Quantity: 1000}}},
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPacker(ctx, WithBoxes(tt.boxes), WithLevels(tt.levels...))
+			require.NoError(t, err)
+
+			got, err := p.PackNested(ctx, tt.items)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, got.Packs)
+			assert.Equal(t, tt.items, got.Items)
+			require.Len(t, got.Levels, len(tt.levels)+1)
+			assert.Equal(t, BoxLevel, got.Levels[0].Level)
+
+			for i := 1; i < len(got.Levels); i++ {
+				assert.Equal(t, tt.levels[i-1].Name, got.Levels[i].Level)
+				assert.Equal(t, got.Levels[i-1].PackCount, got.Levels[i].Items, "a level packs the packs of the level below")
+			}
+		})
+	}
+}
+
+func TestPacker_PackNested_commit(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	inv := inventory.New(map[uint]uint{10: 5})
+
+	p, err := NewPacker(ctx,
+		WithBoxes([]uint{10}),
+		WithInventory(inv),
+		WithLevels(Level{Name: "carton", Boxes: SizedBoxes([]uint{4})}),
+	)
+	require.NoError(t, err)
+
+	_, err = p.PackNested(ctx, 60, WithCommit())
+	require.ErrorIs(t, err, ErrInsufficientStock)
+
+	got, err := p.PackNested(ctx, 40, WithCommit())
+	require.NoError(t, err)
+
+	assert.Equal(t, []NestedPack{
+		{Level: "carton", Box: 4, Quantity: 1, Contents: []NestedPack{{Level: BoxLevel, Box: 10, Quantity: 4}}},
+	}, got.Packs)
+	assert.Equal(t, map[uint]uint{10: 1}, inv.Stock())
+}
+
+func TestPacker_PackNested_noLevels(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := NewPacker(ctx, WithDefaultBoxes())
+	require.NoError(t, err)
+
+	_, err = p.PackNested(ctx, 1)
+	assert.ErrorIs(t, err, ErrNoLevels)
+}
+
+func TestNewPacker_levels(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	tests := []struct {
+		name   string
+		levels []Level
+	}{
+		{name: "without name", levels: []Level{{Boxes: SizedBoxes([]uint{4})}}},
+		{name: "box level", levels: []Level{{Name: BoxLevel, Boxes: SizedBoxes([]uint{4})}}},
+		{
+			name:   "duplicated",
+			levels: []Level{{Name: "carton", Boxes: SizedBoxes([]uint{4})}, {Name: "carton", Boxes: SizedBoxes([]uint{8})}},
+		},
+		{name: "empty boxes", levels: []Level{{Name: "carton"}}},
+		{name: "zero size", levels: []Level{{Name: "carton", Boxes: SizedBoxes([]uint{0})}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPacker(ctx, WithDefaultBoxes(), WithLevels(tt.levels...))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseLevels(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []Level
+		wantErr bool
+	}{
+		{
+			name: "innermost first",
+			s:    "pallet=10; carton=4,8:1.5",
+			want: []Level{
+				{Name: "pallet", Boxes: []Box{{Size: 10}}},
+				{Name: "carton", Boxes: []Box{{Size: 4}, {Size: 8, Cost: 1.5}}},
+			},
+		},
+		{name: "empty", s: ""},
+		{name: "without name", s: "=4", wantErr: true},
+		{name: "box level", s: "box=4", wantErr: true},
+		{name: "duplicated", s: "carton=4;carton=8", wantErr: true},
+		{name: "invalid boxes", s: "carton=four", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLevels(tt.s)
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
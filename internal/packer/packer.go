@@ -2,14 +2,44 @@ package packer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"slices"
+	"time"
 
 	log "github.com/obalunenko/logger"
 )
 
+// ErrOrderTooLarge is returned when packing of the order could exceed the maximum number of items.
+var ErrOrderTooLarge = errors.New("order is too large")
+
+// Inventory tracks the stock of boxes. Box sizes without a stock record are in unlimited supply.
+type Inventory interface {
+	// Stock returns a copy of stock levels per box size.
+	Stock() map[uint]uint
+	// Update calls fn with a copy of stock levels and stores the copy if fn succeeds.
+	// Stock levels do not change while fn runs.
+	Update(fn func(stock map[uint]uint) error) error
+}
+
 type Packer struct {
-	boxes []uint
+	// boxes is the box set an order is packed with, loaded from live when packing starts.
+	boxes     []Box
+	live      *liveBoxes
+	strategy  string
+	solver    Solver
+	objective Objective
+	penalty   float64
+	inventory Inventory
+	timeout   time.Duration
+	anytime   bool
+	shipment  ShipmentLimits
+	exactFit  bool
+	fill      FillLimits
+	// levelSpecs define packaging levels above boxes, levels pack them.
+	levelSpecs []Level
+	levels     []level
 }
 
 var DefaultBoxes = []uint{
@@ -22,37 +52,72 @@ var DefaultBoxes = []uint{
 
 type PackerOption func(*Packer)
 
+// WithBoxes sets box sizes at no cost.
 func WithBoxes(boxes []uint) PackerOption {
+	return WithBoxSet(SizedBoxes(boxes))
+}
+
+// WithBoxSet sets box definitions sorted by size. A size defined more than once keeps its cheapest definition,
+// the definitions may differ in cost only.
+func WithBoxSet(boxes []Box) PackerOption {
 	return func(p *Packer) {
-		slices.Sort(boxes)
+		p.boxes = sortBoxes(boxes)
+	}
+}
 
-		unique := make(map[uint]struct{}, len(boxes))
+func WithDefaultBoxes() PackerOption {
+	return func(p *Packer) {
+		p.boxes = SizedBoxes(DefaultBoxes)
+	}
+}
 
-		isUnique := func(x uint) bool {
-			if _, exist := unique[x]; !exist {
-				unique[x] = struct{}{}
+// WithStrategy sets the name of the registered solver used to pack orders.
+func WithStrategy(name string) PackerOption {
+	return func(p *Packer) {
+		p.strategy = name
+	}
+}
 
-				return true
-			}
+// WithSolver sets the solver used to pack orders. It takes precedence over WithStrategy.
+func WithSolver(s Solver) PackerOption {
+	return func(p *Packer) {
+		p.solver = s
+	}
+}
 
-			return false
-		}
+// WithObjective sets what solvers minimize.
+func WithObjective(o Objective) PackerOption {
+	return func(p *Packer) {
+		p.objective = o
+	}
+}
 
-		filtered := boxes[:0]
+// WithOvershootPenalty sets the cost of each shipped item above the order.
+func WithOvershootPenalty(penalty float64) PackerOption {
+	return func(p *Packer) {
+		p.penalty = penalty
+	}
+}
 
-		for _, b := range boxes {
-			if isUnique(b) {
-				filtered = append(filtered, b)
-			}
-		}
+// WithInventory makes packer choose only from boxes in stock.
+func WithInventory(inv Inventory) PackerOption {
+	return func(p *Packer) {
+		p.inventory = inv
+	}
+}
 
-		p.boxes = filtered
+// WithTimeout limits the time solving an order takes, zero means no limit.
+func WithTimeout(d time.Duration) PackerOption {
+	return func(p *Packer) {
+		p.timeout = d
 	}
 }
 
-func WithDefaultBoxes() PackerOption {
+// WithAnytime makes packer return the best packing found so far instead of an error
+// when solving is interrupted by the timeout or the context of the order.
+func WithAnytime(anytime bool) PackerOption {
 	return func(p *Packer) {
-		p.boxes = DefaultBoxes
+		p.anytime = anytime
 	}
 }
 
@@ -67,11 +132,47 @@ func NewPacker(ctx context.Context, opts ...PackerOption) (*Packer, error) {
 		opt(&p)
 	}
 
+	if p.solver == nil {
+		if p.strategy == "" {
+			p.strategy = DefaultStrategy
+		}
+
+		s, err := SolverByName(p.strategy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create packer: %w", err)
+		}
+
+		p.solver = s
+	}
+
+	p.strategy = p.solver.Name()
+
+	if p.objective == "" {
+		p.objective = DefaultObjective
+	}
+
 	if err := p.validate(); err != nil {
 		return nil, fmt.Errorf("failed to validate packer: %w", err)
 	}
 
-	log.WithField(ctx, "boxes", p.boxes).Info("Packer created")
+	levels, err := p.newLevels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create packer: %w", err)
+	}
+
+	p.levels = levels
+	p.live = newLiveBoxes(p.boxes)
+
+	log.WithFields(ctx, log.Fields{
+		"boxes":     p.boxes,
+		"strategy":  p.strategy,
+		"objective": p.objective,
+		"timeout":   p.timeout,
+		"anytime":   p.anytime,
+		"exact_fit": p.exactFit,
+		"fill":      p.fill,
+		"levels":    len(p.levels),
+	}).Info("Packer created")
 
 	return &p, nil
 }
@@ -81,98 +182,271 @@ func (p Packer) validate() error {
 		return fmt.Errorf("boxes list is empty")
 	}
 
-	// There should be no box with zero volume.
-	for _, box := range p.boxes {
-		if box == 0 {
+	for i, box := range p.boxes {
+		// There should be no box with zero volume.
+		if box.Size == 0 {
 			return fmt.Errorf("box with zero volume")
 		}
+
+		// Stock and packs are counted per size, so boxes of a size cannot differ in limits or rules.
+		if i > 0 && p.boxes[i-1].Size == box.Size {
+			return fmt.Errorf("box %d is defined more than once with different limits or rules", box.Size)
+		}
+
+		if !validCost(box.Cost) {
+			return fmt.Errorf("box %d has invalid cost %v", box.Size, box.Cost)
+		}
+
+		if !validCost(box.MaxWeight) || !validCost(box.MaxVolume) {
+			return fmt.Errorf("box %d has invalid limits: max weight %v, max volume %v", box.Size, box.MaxWeight, box.MaxVolume)
+		}
+
+		if err := box.validateRules(); err != nil {
+			return err
+		}
+	}
+
+	if err := checkEligibility(p.boxes); err != nil {
+		return err
+	}
+
+	if p.timeout < 0 {
+		return fmt.Errorf("negative timeout %s", p.timeout)
 	}
 
-	return nil
+	if !validCost(p.penalty) {
+		return fmt.Errorf("invalid overshoot penalty %v", p.penalty)
+	}
+
+	if err := p.shipment.validate(); err != nil {
+		return err
+	}
+
+	if err := p.fill.validate(); err != nil {
+		return err
+	}
+
+	return p.objective.validate()
+}
 
+// Boxes returns box definitions sorted by size.
+func (p Packer) Boxes() []Box {
+	return slices.Clone(p.loaded().boxes)
 }
 
-func (p Packer) PackOrder(ctx context.Context, items uint) []uint {
+type orderOptions struct {
+	commit   bool
+	unit     Unit
+	explain  bool
+	policy   Policy
+	shipment *ShipmentLimits
+	exactFit *bool
+}
+
+// OrderOption configures packing of a single order.
+type OrderOption func(*orderOptions)
+
+// WithCommit takes boxes of the packing off the inventory stock.
+func WithCommit() OrderOption {
+	return func(o *orderOptions) {
+		o.commit = true
+	}
+}
+
+// WithUnit sets the weight and volume of a single item, so that boxes hold no more items than their limits allow.
+func WithUnit(u Unit) OrderOption {
+	return func(o *orderOptions) {
+		o.unit = u
+	}
+}
+
+// withTimeout returns the context of packing an order, limited by the timeout of the packer.
+func (p Packer) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.timeout > 0 {
+		return context.WithTimeout(ctx, p.timeout)
+	}
+
+	return ctx, func() {}
+}
+
+// PackOrder returns the packing of the given number of items.
+// The result holds quantities per box size, so its size does not depend on the number of items.
+// Solving stops when the context is done or the timeout of the packer runs out.
+func (p Packer) PackOrder(ctx context.Context, items uint, opts ...OrderOption) (PackResult, error) {
+	p = p.loaded()
+
+	var o orderOptions
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	if p.inventory == nil {
+		return p.pack(ctx, items, o, nil)
+	}
+
+	if !o.commit {
+		return p.pack(ctx, items, o, p.inventory.Stock())
+	}
+
+	res, err := commit(ctx, p.inventory, func(stock map[uint]uint) (PackResult, error) {
+		return p.pack(ctx, items, o, stock)
+	}, usedBoxes)
+	if err != nil {
+		return PackResult{}, err
+	}
+
 	log.WithFields(ctx, log.Fields{
 		"items": items,
-		"boxes": p.boxes,
+		"packs": res.Packs,
+	}).Info("Packing committed")
+
+	return res, nil
+}
+
+// pack solves the order with boxes limited by stock, nil stock means unlimited supply.
+func (p Packer) pack(ctx context.Context, items uint, o orderOptions, stock map[uint]uint) (PackResult, error) {
+	exactFit := p.exactFit
+	if o.exactFit != nil {
+		exactFit = *o.exactFit
+	}
+
+	log.WithFields(ctx, log.Fields{
+		"items":     items,
+		"boxes":     p.boxes,
+		"strategy":  p.strategy,
+		"objective": p.objective,
+		"stock":     stock,
+		"unit":      o.unit,
+		"policy":    o.policy,
+		"exact_fit": exactFit,
 	}).Debug("Packing order")
 
-	if items == 0 {
-		return []uint{}
+	if err := o.policy.validate(); err != nil {
+		return PackResult{}, err
+	}
+
+	prob, fits, err := p.problem(items, o.unit, stock)
+	if err != nil {
+		return PackResult{}, err
 	}
 
-	if p.boxes[0] == 0 {
-		// This should never happen, cause we validate boxes on creation.
-		panic(fmt.Errorf("packer has box with zero volume: boxes [%v]", p.boxes))
+	solve := func(ctx context.Context, prob Problem) ([]uint, bool, error) {
+		return p.solvePolicy(ctx, prob, o.policy)
 	}
 
-	// Preallocate memory for the result slice.
-	// Make a prediction based on the number of items and the smallest box.
-	result := make([]uint, 0, items/p.boxes[0])
+	if exactFit {
+		solve = p.solveExactFit
+	}
 
-	if len(p.boxes) == 1 {
-		box := p.boxes[0]
+	counts, interrupted, err := solve(ctx, prob)
+	if err != nil {
+		return PackResult{}, err
+	}
 
-		if items < box {
-			return []uint{box}
-		}
+	res := p.newPackResult(fits, counts, items, o.unit)
+	res.Interrupted = interrupted
 
-		n := items / box
+	res, err = p.withinFill(ctx, prob, fits, res, o.unit)
+	if err != nil {
+		return PackResult{}, err
+	}
 
-		last := items % box
-		if last != 0 {
-			n++
-		}
+	limits := p.shipment
+	if o.shipment != nil {
+		limits = *o.shipment
+	}
 
-		for i := uint(0); i < n; i++ {
-			result = append(result, box)
+	if limits.set() {
+		res.Shipments, err = split(res, o.unit, limits)
+		if err != nil {
+			return PackResult{}, err
 		}
+	}
 
-		return result
+	if o.explain {
+		res.Explanation = p.explain(ctx, prob, fits, res, o.unit)
 	}
 
-	for i := len(p.boxes) - 1; i >= 0; i-- {
-		box := p.boxes[i]
+	return res, nil
+}
+
+// problem returns the problem of packing items into boxes that hold at least one unit and are used for the order,
+// limited by stock and usage rules of boxes.
+// Problem boxes are sized by the number of units they hold and indexed as the returned fits.
+func (p Packer) problem(items uint, u Unit, stock map[uint]uint) (Problem, []fit, error) {
+	if err := u.validate(); err != nil {
+		return Problem{}, nil, err
+	}
 
-		if box >= items {
-			if i == 0 {
-				result = append(result, box)
+	fits, err := p.fits(items, u, stock)
+	if err != nil {
+		return Problem{}, nil, err
+	}
 
-				break
-			}
+	prob := Problem{
+		Boxes:            make([]Box, 0, len(fits)),
+		Items:            items,
+		Objective:        p.objective,
+		OvershootPenalty: p.penalty,
+	}
 
-			continue
-		}
+	for _, f := range fits {
+		prob.Boxes = append(prob.Boxes, Box{Size: f.capacity, Cost: f.box.Cost})
+	}
 
-		if box < items {
-			if i == 0 {
-				result = append(result, p.boxes[i+1])
+	// Any packing ships less than a largest box above the order, keep it countable.
+	largest := fits[len(fits)-1].capacity
+	if limit := math.MaxUint - (largest - 1); items > limit {
+		return Problem{}, nil, fmt.Errorf("%w: %d items, maximum for boxes %v is %d", ErrOrderTooLarge, items, sizesOf(prob.Boxes), limit)
+	}
+
+	limit := func(i int, n uint) {
+		if prob.Max == nil {
+			prob.Max = make([]uint, len(fits))
 
-				break
+			for j := range prob.Max {
+				prob.Max[j] = Unlimited
 			}
+		}
 
-			n := items / box
+		prob.Max[i] = min(prob.Max[i], n)
+	}
 
-			if n == 0 {
-				result = append(result, box)
+	var fixed uint
 
-				break
-			}
+	for i, f := range fits {
+		if n, ok := stock[f.box.Size]; ok {
+			limit(i, n)
+		}
 
-			for j := uint(0); j < n; j++ {
-				result = append(result, box)
-			}
+		if f.box.MaxQuantity != 0 {
+			limit(i, f.box.MaxQuantity)
+		}
 
-			left := items % box
+		if f.box.MinQuantity == 0 || items == 0 {
+			continue
+		}
 
-			if left == 0 {
-				break
-			}
+		if prob.Min == nil {
+			prob.Min = make([]uint, len(fits))
+		}
 
-			items = left
+		prob.Min[i] = f.box.MinQuantity
+
+		if n := prob.maxOf(i); n < f.box.MinQuantity {
+			return Problem{}, nil, fmt.Errorf("%w: box %d is used at least %d times, %d in stock", ErrInsufficientStock, f.box.Size, f.box.MinQuantity, n)
 		}
+
+		fixed = satAdd(fixed, satMul(f.box.MinQuantity, f.capacity))
+	}
+
+	if fixed == Unlimited {
+		return Problem{}, nil, fmt.Errorf("%w: boxes used at least once per order hold too many items", ErrOrderTooLarge)
 	}
 
-	return result
+	return prob, fits, nil
 }
@@ -3,11 +3,15 @@ package packer
 import (
 	"context"
 	"encoding/json"
+	"math"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/obalunenko/orderpacker/internal/inventory"
 	"github.com/obalunenko/orderpacker/internal/testlogger"
 )
 
@@ -15,7 +19,8 @@ func TestPacker_PackOrder(t *testing.T) {
 	ctx := testlogger.New(context.Background())
 
 	type fields struct {
-		boxes []uint
+		boxes    []uint
+		strategy string
 	}
 
 	type args struct {
@@ -100,18 +105,973 @@ func TestPacker_PackOrder(t *testing.T) {
 			},
 			want: []uint{4, 2, 1},
 		},
+		{
+			name: "custom[23,31,53]. 263 - 7x31, 2x23",
+			fields: fields{
+				boxes: []uint{23, 31, 53},
+			},
+			args: args{
+				items: 263,
+			},
+			want: []uint{31, 31, 31, 31, 31, 31, 31, 23, 23},
+		},
+		{
+			name: "custom[6,9,20]. 43 - 1x20, 2x9, 1x6",
+			fields: fields{
+				boxes: []uint{6, 9, 20},
+			},
+			args: args{
+				items: 43,
+			},
+			want: []uint{20, 9, 9, 6},
+		},
+		{
+			name: "custom[3,5]. 7 - 1x5, 1x3",
+			fields: fields{
+				boxes: []uint{3, 5},
+			},
+			args: args{
+				items: 7,
+			},
+			want: []uint{5, 3},
+		},
+		{
+			name: "default. 751 - 1x1000",
+			fields: fields{
+				boxes: DefaultBoxes,
+			},
+			args: args{
+				items: 751,
+			},
+			want: []uint{1000},
+		},
+		{
+			name: "greedy. custom[23,31,53]. 263 - 4x53, 1x31, 1x23",
+			fields: fields{
+				boxes:    []uint{23, 31, 53},
+				strategy: StrategyGreedy,
+			},
+			args: args{
+				items: 263,
+			},
+			want: []uint{53, 53, 53, 53, 31, 23},
+		},
+		{
+			name: "greedy. default. 12001  - 2x5000, 1x2000, 1x250",
+			fields: fields{
+				boxes:    DefaultBoxes,
+				strategy: StrategyGreedy,
+			},
+			args: args{
+				items: 12001,
+			},
+			want: []uint{5000, 5000, 2000, 250},
+		},
+		{
+			name: "branch-and-bound. custom[23,31,53]. 263 - 7x31, 2x23",
+			fields: fields{
+				boxes:    []uint{23, 31, 53},
+				strategy: StrategyBranchAndBound,
+			},
+			args: args{
+				items: 263,
+			},
+			want: []uint{31, 31, 31, 31, 31, 31, 31, 23, 23},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []PackerOption{WithBoxes(tt.fields.boxes)}
+			if tt.fields.strategy != "" {
+				opts = append(opts, WithStrategy(tt.fields.strategy))
+			}
+
+			p, err := NewPacker(ctx, opts...)
+			require.NoError(t, err)
+
+			got, err := p.PackOrder(ctx, tt.args.items)
+			require.NoError(t, err)
+
+			compareSlices(t, tt.want, expand(got))
+		})
+	}
+}
+
+func TestPacker_PackOrder_optimal(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	tests := []struct {
+		name     string
+		boxes    []uint
+		maxItems uint
+	}{
+		{
+			name:     "default",
+			boxes:    DefaultBoxes,
+			maxItems: 6000,
+		},
+		{
+			name:     "custom[23,31,53]",
+			boxes:    []uint{23, 31, 53},
+			maxItems: 600,
+		},
+		{
+			name:     "custom[1,2,4,8,16]",
+			boxes:    []uint{1, 2, 4, 8, 16},
+			maxItems: 200,
+		},
+		{
+			name:     "custom[6,9,20]",
+			boxes:    []uint{6, 9, 20},
+			maxItems: 300,
+		},
+		{
+			name:     "custom[4,7]",
+			boxes:    []uint{4, 7},
+			maxItems: 300,
+		},
+		{
+			name:     "custom[5]",
+			boxes:    []uint{5},
+			maxItems: 50,
+		},
+	}
+
+	for _, strategy := range []string{StrategyExact, StrategyBranchAndBound} {
+		for _, tt := range tests {
+			t.Run(strategy+"/"+tt.name, func(t *testing.T) {
+				p, err := NewPacker(ctx, WithBoxes(tt.boxes), WithStrategy(strategy))
+				require.NoError(t, err)
+
+				for items := uint(1); items <= tt.maxItems; items++ {
+					got, err := p.PackOrder(ctx, items)
+					require.NoError(t, err)
+
+					wantTotal, wantPacks := bruteForce(sizesOf(p.boxes), items)
+
+					require.Equalf(t, wantTotal, got.Shipped, "items %d: shipped items of %v", items, got.Packs)
+					require.Equalf(t, wantPacks, got.PackCount, "items %d: packs count of %v", items, got.Packs)
+				}
+			})
+		}
+	}
+}
+
+func TestPacker_PackOrder_strategiesAgree(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	boxes := []Box{{Size: 23, Cost: 1}, {Size: 31, Cost: 1.5}, {Size: 53, Cost: 3}}
+
+	for _, objective := range []Objective{ObjectivePacks, ObjectiveCost} {
+		t.Run(string(objective), func(t *testing.T) {
+			exact, err := NewPacker(ctx, WithBoxSet(boxes), WithStrategy(StrategyExact), WithObjective(objective))
+			require.NoError(t, err)
+
+			bnb, err := NewPacker(ctx, WithBoxSet(boxes), WithStrategy(StrategyBranchAndBound), WithObjective(objective))
+			require.NoError(t, err)
+
+			for items := uint(100_000); items <= 100_200; items++ {
+				want, err := exact.PackOrder(ctx, items)
+				require.NoError(t, err)
+
+				got, err := bnb.PackOrder(ctx, items)
+				require.NoError(t, err)
+
+				require.InDeltaf(t, want.Cost, got.Cost, CostEpsilon, "items %d: cost", items)
+				require.Equalf(t, want.Shipped, got.Shipped, "items %d: shipped items", items)
+				require.Equalf(t, want.PackCount, got.PackCount, "items %d: packs count", items)
+			}
+		})
+	}
+}
+
+func TestPacker_PackOrder_cost(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	boxes := []Box{
+		{Size: 1000, Cost: 1},
+		{Size: 5000, Cost: 3},
+	}
+
+	tests := []struct {
+		name      string
+		objective Objective
+		penalty   float64
+		items     uint
+		want      []BoxQuantity
+		wantCost  float64
+	}{
+		{
+			name:      "packs. 4000 - 4x1000",
+			objective: ObjectivePacks,
+			items:     4000,
+			want:      []BoxQuantity{{Box: 1000, Quantity: 4}},
+			wantCost:  4,
+		},
+		{
+			name:      "cost. 4000 - 1x5000",
+			objective: ObjectiveCost,
+			items:     4000,
+			want:      []BoxQuantity{{Box: 5000, Quantity: 1}},
+			wantCost:  3,
+		},
+		{
+			name:      "cost with penalty. 4000 - 1x5000",
+			objective: ObjectiveCost,
+			penalty:   0.0005,
+			items:     4000,
+			want:      []BoxQuantity{{Box: 5000, Quantity: 1}},
+			wantCost:  3.5,
+		},
+		{
+			name:      "cost with high penalty. 4000 - 4x1000",
+			objective: ObjectiveCost,
+			penalty:   0.002,
+			items:     4000,
+			want:      []BoxQuantity{{Box: 1000, Quantity: 4}},
+			wantCost:  4,
+		},
+		{
+			name:      "cost with equal penalty. 4000 - less overshoot wins",
+			objective: ObjectiveCost,
+			penalty:   0.001,
+			items:     4000,
+			want:      []BoxQuantity{{Box: 1000, Quantity: 4}},
+			wantCost:  4,
+		},
+	}
+
+	for _, strategy := range []string{StrategyExact, StrategyBranchAndBound} {
+		for _, tt := range tests {
+			t.Run(strategy+"/"+tt.name, func(t *testing.T) {
+				p, err := NewPacker(ctx,
+					WithBoxSet(boxes),
+					WithStrategy(strategy),
+					WithObjective(tt.objective),
+					WithOvershootPenalty(tt.penalty),
+				)
+				require.NoError(t, err)
+
+				got, err := p.PackOrder(ctx, tt.items)
+				require.NoError(t, err)
+
+				assert.Equal(t, tt.want, got.Packs)
+				assert.InDelta(t, tt.wantCost, got.Cost, CostEpsilon)
+			})
+		}
+	}
+}
+
+func TestPacker_PackOrder_costOptimal(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	tests := []struct {
+		name     string
+		boxes    []Box
+		penalty  float64
+		maxItems uint
+	}{
+		{
+			name:     "default with bulk discount",
+			boxes:    []Box{{Size: 250, Cost: 0.4}, {Size: 500, Cost: 0.65}, {Size: 1000, Cost: 1.2}, {Size: 2000, Cost: 2}, {Size: 5000, Cost: 4.5}},
+			penalty:  0.001,
+			maxItems: 8000,
+		},
+		{
+			name:     "custom[23,31,53] expensive large",
+			boxes:    []Box{{Size: 23, Cost: 1}, {Size: 31, Cost: 1.5}, {Size: 53, Cost: 3}},
+			maxItems: 400,
+		},
+		{
+			name:     "custom[6,9,20] with penalty",
+			boxes:    []Box{{Size: 6, Cost: 2}, {Size: 9, Cost: 2.5}, {Size: 20, Cost: 5}},
+			penalty:  0.3,
+			maxItems: 200,
+		},
+		{
+			name:     "custom[4,7] free small",
+			boxes:    []Box{{Size: 4}, {Size: 7, Cost: 1}},
+			penalty:  0.1,
+			maxItems: 100,
+		},
+	}
+
+	for _, strategy := range []string{StrategyExact, StrategyBranchAndBound} {
+		for _, tt := range tests {
+			t.Run(strategy+"/"+tt.name, func(t *testing.T) {
+				p, err := NewPacker(ctx,
+					WithBoxSet(tt.boxes),
+					WithStrategy(strategy),
+					WithObjective(ObjectiveCost),
+					WithOvershootPenalty(tt.penalty),
+				)
+				require.NoError(t, err)
+
+				for items := uint(1); items <= tt.maxItems; items++ {
+					got, err := p.PackOrder(ctx, items)
+					require.NoError(t, err)
+
+					wantCost, wantTotal, wantPacks := bruteForceCost(tt.boxes, tt.penalty, items)
+
+					require.InDeltaf(t, wantCost, got.Cost, CostEpsilon, "items %d: cost of %v", items, got.Packs)
+					require.Equalf(t, wantTotal, got.Shipped, "items %d: shipped items of %v", items, got.Packs)
+					require.Equalf(t, wantPacks, got.PackCount, "items %d: packs count of %v", items, got.Packs)
+				}
+			})
+		}
+	}
+}
+
+func TestPacker_PackOrder_stock(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	for _, strategy := range Strategies() {
+		t.Run(strategy, func(t *testing.T) {
+			inv := inventory.New(map[uint]uint{5000: 1, 2000: 0, 250: 3})
+
+			p, err := NewPacker(ctx, WithDefaultBoxes(), WithStrategy(strategy), WithInventory(inv))
+			require.NoError(t, err)
+
+			got, err := p.PackOrder(ctx, 12001)
+			require.NoError(t, err)
+
+			assert.Equal(t, []BoxQuantity{
+				{Box: 5000, Quantity: 1},
+				{Box: 1000, Quantity: 7},
+				{Box: 250, Quantity: 1},
+			}, got.Packs)
+			assert.Equal(t, map[uint]uint{5000: 1, 2000: 0, 250: 3}, inv.Stock(), "stock must not change without commit")
+
+			got, err = p.PackOrder(ctx, 5001, WithCommit())
+			require.NoError(t, err)
+
+			assert.Equal(t, []BoxQuantity{
+				{Box: 5000, Quantity: 1},
+				{Box: 250, Quantity: 1},
+			}, got.Packs)
+			assert.Equal(t, map[uint]uint{5000: 0, 2000: 0, 250: 2}, inv.Stock())
+
+			got, err = p.PackOrder(ctx, 5001, WithCommit())
+			require.NoError(t, err)
+
+			assert.Equal(t, []BoxQuantity{
+				{Box: 1000, Quantity: 5},
+				{Box: 250, Quantity: 1},
+			}, got.Packs)
+			assert.Equal(t, map[uint]uint{5000: 0, 2000: 0, 250: 1}, inv.Stock())
+		})
+	}
+}
+
+func TestPacker_PackOrder_insufficientStock(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	for _, strategy := range Strategies() {
+		t.Run(strategy, func(t *testing.T) {
+			inv := inventory.New(map[uint]uint{250: 1, 500: 1})
+
+			p, err := NewPacker(ctx, WithBoxes([]uint{250, 500}), WithStrategy(strategy), WithInventory(inv))
+			require.NoError(t, err)
+
+			got, err := p.PackOrder(ctx, 700)
+			require.NoError(t, err)
+			assert.Equal(t, uint(750), got.Shipped)
+
+			_, err = p.PackOrder(ctx, 751, WithCommit())
+			require.ErrorIs(t, err, ErrInsufficientStock)
+			assert.Equal(t, map[uint]uint{250: 1, 500: 1}, inv.Stock(), "failed commit must not change stock")
+		})
+	}
+}
+
+func TestPacker_PackOrder_rules(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	boxes := []Box{
+		{Size: 250, MaxOrder: 5000},
+		{Size: 500},
+		{Size: 1000, MaxQuantity: 1},
+		{Size: 5000, MinOrder: 10_000},
+		{Size: 100, MinQuantity: 1, MinOrder: 20_000},
+	}
+
+	tests := []struct {
+		items uint
+		want  []BoxQuantity
+	}{
+		{items: 250, want: []BoxQuantity{{Box: 250, Quantity: 1}}},
+		{items: 4750, want: []BoxQuantity{{Box: 1000, Quantity: 1}, {Box: 500, Quantity: 7}, {Box: 250, Quantity: 1}}},
+		{items: 6001, want: []BoxQuantity{{Box: 1000, Quantity: 1}, {Box: 500, Quantity: 11}}},
+		{items: 15_000, want: []BoxQuantity{{Box: 5000, Quantity: 3}}},
+		{items: 20_000, want: []BoxQuantity{
+			{Box: 5000, Quantity: 3},
+			{Box: 1000, Quantity: 1},
+			{Box: 500, Quantity: 7},
+			{Box: 100, Quantity: 5},
+		}},
+	}
+
+	for _, strategy := range Strategies() {
+		t.Run(strategy, func(t *testing.T) {
+			p, err := NewPacker(ctx, WithBoxSet(boxes), WithStrategy(strategy))
+			require.NoError(t, err)
+
+			for _, tt := range tests {
+				got, err := p.PackOrder(ctx, tt.items)
+				require.NoError(t, err)
+				assert.Equalf(t, tt.want, got.Packs, "items %d", tt.items)
+			}
+
+			got, err := p.PackOrder(ctx, 20_050, WithPolicy(PolicyDown))
+			require.NoError(t, err)
+			assert.Equal(t, uint(20_000), got.Shipped)
+			assert.Equal(t, uint(50), got.Backordered)
+			assert.True(t, slices.ContainsFunc(got.Packs, func(q BoxQuantity) bool { return q.Box == 100 }), "box 100 must be used")
+		})
+	}
+}
+
+func TestPacker_PackOrder_unit(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	boxes := []Box{
+		{Size: 250, Cost: 1, MaxWeight: 20},
+		{Size: 500, Cost: 2, MaxWeight: 30, MaxVolume: 0.5},
+	}
+
+	for _, strategy := range Strategies() {
+		t.Run(strategy, func(t *testing.T) {
+			p, err := NewPacker(ctx, WithBoxSet(boxes), WithStrategy(strategy))
+			require.NoError(t, err)
+
+			t.Run("weight binds", func(t *testing.T) {
+				got, err := p.PackOrder(ctx, 500, WithUnit(Unit{Weight: 0.1}))
+				require.NoError(t, err)
+
+				assert.Equal(t, []BoxQuantity{
+					{Box: 500, Quantity: 1, Fill: &Fill{Items: 300, Weight: 30, WeightRatio: 1}},
+					{Box: 250, Quantity: 1, Fill: &Fill{Items: 200, Weight: 20, WeightRatio: 1}},
+				}, got.Packs)
+				assert.Equal(t, uint(500), got.Shipped)
+				assert.Equal(t, uint(0), got.Overshoot)
+			})
+
+			t.Run("volume binds", func(t *testing.T) {
+				got, err := p.PackOrder(ctx, 350, WithUnit(Unit{Weight: 0.01, Volume: 0.005}))
+				require.NoError(t, err)
+
+				assert.Equal(t, []BoxQuantity{
+					{Box: 250, Quantity: 1, Fill: &Fill{Items: 250, Weight: 2.5, Volume: 1.25, WeightRatio: 0.125}},
+					{Box: 500, Quantity: 1, Fill: &Fill{Items: 100, Weight: 1, Volume: 0.5, WeightRatio: 1.0 / 30, VolumeRatio: 1}},
+				}, got.Packs)
+				assert.Equal(t, uint(350), got.Shipped)
+			})
+
+			t.Run("same capacity keeps the cheapest box in stock", func(t *testing.T) {
+				inv := inventory.New(map[uint]uint{250: 1})
+
+				p, err := NewPacker(ctx,
+					WithBoxSet([]Box{{Size: 250, Cost: 1, MaxWeight: 10}, {Size: 500, Cost: 2, MaxWeight: 10}}),
+					WithStrategy(strategy),
+					WithInventory(inv),
+				)
+				require.NoError(t, err)
+
+				unit := WithUnit(Unit{Weight: 0.1})
+
+				got, err := p.PackOrder(ctx, 100, unit, WithCommit())
+				require.NoError(t, err)
+				assert.Equal(t, uint(250), got.Packs[0].Box)
+
+				got, err = p.PackOrder(ctx, 100, unit)
+				require.NoError(t, err)
+				assert.Equal(t, uint(500), got.Packs[0].Box)
+			})
+
+			t.Run("unit too large", func(t *testing.T) {
+				_, err := p.PackOrder(ctx, 1, WithUnit(Unit{Weight: 31}))
+				assert.ErrorIs(t, err, ErrUnitTooLarge)
+			})
+
+			t.Run("invalid unit", func(t *testing.T) {
+				_, err := p.PackOrder(ctx, 1, WithUnit(Unit{Weight: -1}))
+				assert.ErrorIs(t, err, ErrInvalidUnit)
+
+				_, err = p.PackOrder(ctx, 1, WithUnit(Unit{Volume: 1e308}))
+				assert.ErrorIs(t, err, ErrInvalidUnit, "volume of units overflows")
+			})
+		})
+	}
+}
+
+func TestPacker_PackOrder_sameCapacityRules(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	unit := WithUnit(Unit{Weight: 0.1})
+
+	tests := []struct {
+		name  string
+		boxes []Box
+		items uint
+		want  []BoxQuantity
+	}{
+		{
+			name:  "max quantity of the cheapest box",
+			boxes: []Box{{Size: 250, Cost: 1, MaxWeight: 10, MaxQuantity: 1}, {Size: 500, Cost: 2, MaxWeight: 10}},
+			items: 200,
+			want:  []BoxQuantity{{Box: 250, Quantity: 1}, {Box: 500, Quantity: 1}},
+		},
+		{
+			name:  "min quantity of the dearer box",
+			boxes: []Box{{Size: 250, Cost: 1, MaxWeight: 10}, {Size: 500, Cost: 2, MaxWeight: 10, MinQuantity: 2}},
+			items: 100,
+			want:  []BoxQuantity{{Box: 500, Quantity: 2}},
+		},
+	}
+
+	for _, strategy := range Strategies() {
+		t.Run(strategy, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					p, err := NewPacker(ctx, WithBoxSet(tt.boxes), WithStrategy(strategy))
+					require.NoError(t, err)
+
+					got, err := p.PackOrder(ctx, tt.items, unit)
+					require.NoError(t, err)
+
+					packs := make([]BoxQuantity, 0, len(got.Packs))
+					for _, q := range got.Packs {
+						packs = append(packs, BoxQuantity{Box: q.Box, Quantity: q.Quantity})
+					}
+
+					assert.ElementsMatch(t, tt.want, packs)
+				})
+			}
+		})
+	}
+}
+
+func TestPacker_PackOrder_stockOptimal(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	tests := []struct {
+		name     string
+		boxes    []uint
+		stock    map[uint]uint
+		maxItems uint
+	}{
+		{
+			name:     "default, few large",
+			boxes:    DefaultBoxes,
+			stock:    map[uint]uint{5000: 1, 2000: 2, 500: 1},
+			maxItems: 12000,
+		},
+		{
+			name:     "custom[23,31,53], all limited",
+			boxes:    []uint{23, 31, 53},
+			stock:    map[uint]uint{23: 3, 31: 4, 53: 2},
+			maxItems: 300,
+		},
+		{
+			name:     "custom[6,9,20], no 20",
+			boxes:    []uint{6, 9, 20},
+			stock:    map[uint]uint{20: 0, 9: 5},
+			maxItems: 200,
+		},
+		{
+			name:     "custom[4,7], limited small",
+			boxes:    []uint{4, 7},
+			stock:    map[uint]uint{4: 2},
+			maxItems: 100,
+		},
+	}
+
+	for _, strategy := range []string{StrategyExact, StrategyBranchAndBound} {
+		for _, tt := range tests {
+			t.Run(strategy+"/"+tt.name, func(t *testing.T) {
+				p, err := NewPacker(ctx, WithBoxes(tt.boxes), WithStrategy(strategy), WithInventory(inventory.New(tt.stock)))
+				require.NoError(t, err)
+
+				limits := make([]uint, len(p.boxes))
+				for i, b := range p.boxes {
+					limits[i] = Unlimited
+
+					if n, ok := tt.stock[b.Size]; ok {
+						limits[i] = n
+					}
+				}
+
+				for items := uint(1); items <= tt.maxItems; items++ {
+					got, err := p.PackOrder(ctx, items)
+
+					wantTotal, wantPacks, ok := bruteForceLimited(sizesOf(p.boxes), limits, items)
+					if !ok {
+						require.ErrorIsf(t, err, ErrInsufficientStock, "items %d", items)
+
+						continue
+					}
+
+					require.NoError(t, err)
+
+					for _, q := range got.Packs {
+						if n, ok := tt.stock[q.Box]; ok {
+							require.LessOrEqualf(t, q.Quantity, n, "items %d: box %d over stock", items, q.Box)
+						}
+					}
+
+					require.Equalf(t, wantTotal, got.Shipped, "items %d: shipped items of %v", items, got.Packs)
+					require.Equalf(t, wantPacks, got.PackCount, "items %d: packs count of %v", items, got.Packs)
+				}
+			})
+		}
+	}
+}
+
+// bruteForceLimited is an oracle like bruteForce with limited number of boxes of each size.
+func bruteForceLimited(boxes, limits []uint, items uint) (uint, uint, bool) {
+	var (
+		bestTotal uint
+		bestPacks uint
+		found     bool
+	)
+
+	var walk func(i int, total, packs uint)
+
+	walk = func(i int, total, packs uint) {
+		if total >= items {
+			if !found || total < bestTotal || (total == bestTotal && packs < bestPacks) {
+				bestTotal, bestPacks, found = total, packs, true
+			}
+
+			return
+		}
+
+		if i < 0 {
+			return
+		}
+
+		for n := uint(0); n <= limits[i] && total+n*boxes[i] < items+boxes[i]; n++ {
+			walk(i-1, total+n*boxes[i], packs+n)
+		}
+	}
+
+	walk(len(boxes)-1, 0, 0)
+
+	return bestTotal, bestPacks, found
+}
+
+// bruteForceCost is an oracle that tries every combination of boxes and returns the lowest cost,
+// then the fewest shipped items and packs for it.
+func bruteForceCost(boxes []Box, penalty float64, items uint) (float64, uint, uint) {
+	var (
+		best  score
+		found bool
+	)
+
+	var walk func(i int, total uint, cost float64, packs uint)
+
+	walk = func(i int, total uint, cost float64, packs uint) {
+		if total >= items {
+			sc := score{cost: cost + penalty*float64(total-items), total: total, packs: packs}
+			if !found || sc.less(best) {
+				best, found = sc, true
+			}
+
+			return
+		}
+
+		if i < 0 {
+			return
+		}
+
+		b := boxes[i]
+
+		for n := uint(0); total+n*b.Size < items+b.Size; n++ {
+			walk(i-1, total+n*b.Size, cost+float64(n)*b.Cost, packs+n)
+		}
+	}
+
+	walk(len(boxes)-1, 0, 0, 0)
+
+	return best.cost, best.total, best.packs
+}
+
+// bruteForce is an oracle that tries every combination of boxes and returns the fewest shipped items
+// not less than the order and the fewest packs for that amount.
+func bruteForce(boxes []uint, items uint) (uint, uint) {
+	var (
+		bestTotal uint
+		bestPacks uint
+		found     bool
+	)
+
+	var walk func(i int, total, packs uint)
+
+	walk = func(i int, total, packs uint) {
+		if total >= items {
+			if !found || total < bestTotal || (total == bestTotal && packs < bestPacks) {
+				bestTotal, bestPacks, found = total, packs, true
+			}
+
+			return
+		}
+
+		if i < 0 {
+			return
+		}
+
+		for n := uint(0); total+n*boxes[i] < items+boxes[i]; n++ {
+			walk(i-1, total+n*boxes[i], packs+n)
+		}
+	}
+
+	walk(len(boxes)-1, 0, 0)
+
+	return bestTotal, bestPacks
+}
+
+// expand lists every pack of the result, largest box first.
+func expand(res PackResult) []uint {
+	boxes := make([]uint, 0, res.PackCount)
+
+	for _, p := range res.Packs {
+		for i := uint(0); i < p.Quantity; i++ {
+			boxes = append(boxes, p.Box)
+		}
+	}
+
+	return boxes
+}
+
+func TestPacker_PackOrder_result(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := NewPacker(ctx, WithDefaultBoxes())
+	require.NoError(t, err)
+
+	got, err := p.PackOrder(ctx, 12001)
+	require.NoError(t, err)
+
+	assert.Equal(t, PackResult{
+		Items: 12001,
+		Packs: []BoxQuantity{
+			{Box: 5000, Quantity: 2},
+			{Box: 2000, Quantity: 1},
+			{Box: 250, Quantity: 1},
+		},
+		Shipped:   12250,
+		Overshoot: 249,
+		PackCount: 4,
+		Strategy:  StrategyExact,
+	}, got)
+
+	got, err = p.PackOrder(ctx, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, PackResult{
+		Items:    0,
+		Packs:    []BoxQuantity{},
+		Strategy: StrategyExact,
+	}, got)
+}
+
+func TestPacker_PackOrder_large(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	tests := []struct {
+		name    string
+		boxes   []uint
+		items   uint
+		want    []BoxQuantity
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:  "default. max items",
+			boxes: DefaultBoxes,
+			items: math.MaxUint - 4999,
+			want: []BoxQuantity{
+				{Box: 5000, Quantity: 3689348814741909},
+				{Box: 1000, Quantity: 1},
+				{Box: 500, Quantity: 1},
+				{Box: 250, Quantity: 1},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:  "custom[1]. max uint",
+			boxes: []uint{1},
+			items: math.MaxUint,
+			want: []BoxQuantity{
+				{Box: 1, Quantity: math.MaxUint},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:  "custom[23,31,53]. 10^18",
+			boxes: []uint{23, 31, 53},
+			items: 1_000_000_000_000_000_000,
+			want: []BoxQuantity{
+				{Box: 53, Quantity: 18867924528301883},
+				{Box: 31, Quantity: 5},
+				{Box: 23, Quantity: 2},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "default. too large",
+			boxes:   DefaultBoxes,
+			items:   math.MaxUint - 4998,
+			want:    nil,
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, strategy := range Strategies() {
+		for _, tt := range tests {
+			t.Run(strategy+"/"+tt.name, func(t *testing.T) {
+				p, err := NewPacker(ctx, WithBoxes(tt.boxes), WithStrategy(strategy))
+				require.NoError(t, err)
+
+				got, err := p.PackOrder(ctx, tt.items)
+				if !tt.wantErr(t, err) {
+					return
+				}
+
+				if err != nil {
+					assert.ErrorIs(t, err, ErrOrderTooLarge)
+
+					return
+				}
+
+				assert.GreaterOrEqual(t, got.Shipped, tt.items)
+
+				if strategy != StrategyGreedy {
+					assert.Equal(t, tt.want, got.Packs)
+				}
+			})
+		}
+	}
+}
+
+// countdownContext is done after its Err is called n times, so solvers stop at a known step.
+type countdownContext struct {
+	context.Context
+	n int
+}
+
+func (c *countdownContext) Err() error {
+	if c.n == 0 {
+		return context.Canceled
+	}
+
+	c.n--
+
+	return nil
+}
+
+func TestPacker_PackOrder_interrupted(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	// The exact table of 5*10^6 items takes about 1200 checks of the context per box.
+	boxes := []uint{9973, 10007, 10009, 10037}
+	items := uint(5_000_000)
+
+	tests := []struct {
+		name     string
+		strategy string
+		anytime  bool
+		checks   int
+		// want is the number of boxes of each size, the smallest first, any packing if nil.
+		want    []uint
+		wantErr error
+	}{
+		{
+			name:     "exact. first box added",
+			strategy: StrategyExact,
+			anytime:  true,
+			checks:   2000,
+			want:     []uint{502, 0, 0, 0},
+		},
+		{
+			name:     "exact. no box added",
+			strategy: StrategyExact,
+			anytime:  true,
+			checks:   0,
+			wantErr:  context.Canceled,
+		},
+		{
+			name:     "exact. not anytime",
+			strategy: StrategyExact,
+			checks:   2000,
+			wantErr:  ErrInterrupted,
+		},
+		{
+			name:     "branch-and-bound. best found",
+			strategy: StrategyBranchAndBound,
+			anytime:  true,
+			checks:   0,
+		},
+		{
+			name:     "branch-and-bound. not anytime",
+			strategy: StrategyBranchAndBound,
+			checks:   0,
+			wantErr:  ErrInterrupted,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			p, err := NewPacker(ctx, WithBoxes(tt.fields.boxes))
+			p, err := NewPacker(ctx, WithBoxes(boxes), WithStrategy(tt.strategy), WithAnytime(tt.anytime))
+			require.NoError(t, err)
+
+			got, err := p.PackOrder(&countdownContext{Context: ctx, n: tt.checks}, items)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
 			require.NoError(t, err)
 
-			got := p.PackOrder(ctx, tt.args.items)
+			assert.True(t, got.Interrupted)
+			assert.GreaterOrEqual(t, got.Shipped, items)
+
+			if tt.want == nil {
+				return
+			}
+
+			counts := make([]uint, len(boxes))
+
+			for _, q := range got.Packs {
+				for i, b := range boxes {
+					if b == q.Box {
+						counts[i] = q.Quantity
+					}
+				}
+			}
 
-			compareSlices(t, tt.want, got)
+			assert.Equal(t, tt.want, counts)
 		})
 	}
+
+	t.Run("timeout", func(t *testing.T) {
+		p, err := NewPacker(ctx, WithBoxes(boxes), WithTimeout(time.Nanosecond))
+		require.NoError(t, err)
+
+		_, err = p.PackOrder(ctx, items)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("not interrupted", func(t *testing.T) {
+		p, err := NewPacker(ctx, WithBoxes(boxes), WithTimeout(time.Minute), WithAnytime(true))
+		require.NoError(t, err)
+
+		got, err := p.PackOrder(ctx, 1000)
+		require.NoError(t, err)
+
+		assert.False(t, got.Interrupted)
+	})
 }
 
 func compareSlices(t *testing.T, expected, actual []uint) {
@@ -143,7 +1103,10 @@ func TestNewPacker(t *testing.T) {
 				opts: []PackerOption{},
 			},
 			want: &Packer{
-				boxes: DefaultBoxes,
+				boxes:     SizedBoxes(DefaultBoxes),
+				strategy:  StrategyExact,
+				solver:    exactSolver{},
+				objective: ObjectivePacks,
 			},
 			wantErr: assert.NoError,
 		},
@@ -155,10 +1118,200 @@ func TestNewPacker(t *testing.T) {
 				},
 			},
 			want: &Packer{
-				boxes: []uint{1, 2, 4, 8, 16, 32},
+				boxes:     SizedBoxes([]uint{1, 2, 4, 8, 16, 32}),
+				strategy:  StrategyExact,
+				solver:    exactSolver{},
+				objective: ObjectivePacks,
 			},
 			wantErr: assert.NoError,
 		},
+		{
+			name: "custom strategy",
+			args: args{
+				opts: []PackerOption{
+					WithDefaultBoxes(),
+					WithStrategy(StrategyGreedy),
+				},
+			},
+			want: &Packer{
+				boxes:     SizedBoxes(DefaultBoxes),
+				strategy:  StrategyGreedy,
+				solver:    greedySolver{},
+				objective: ObjectivePacks,
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "custom solver",
+			args: args{
+				opts: []PackerOption{
+					WithDefaultBoxes(),
+					WithStrategy(StrategyGreedy),
+					WithSolver(branchAndBoundSolver{}),
+				},
+			},
+			want: &Packer{
+				boxes:     SizedBoxes(DefaultBoxes),
+				strategy:  StrategyBranchAndBound,
+				solver:    branchAndBoundSolver{},
+				objective: ObjectivePacks,
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "box costs",
+			args: args{
+				opts: []PackerOption{
+					WithBoxSet([]Box{{Size: 500, Cost: 0.65}, {Size: 250, Cost: 0.4}, {Size: 500, Cost: 0.6}}),
+					WithObjective(ObjectiveCost),
+					WithOvershootPenalty(0.01),
+				},
+			},
+			want: &Packer{
+				boxes:     []Box{{Size: 250, Cost: 0.4}, {Size: 500, Cost: 0.6}},
+				strategy:  StrategyExact,
+				solver:    exactSolver{},
+				objective: ObjectiveCost,
+				penalty:   0.01,
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "negative cost - error",
+			args: args{
+				opts: []PackerOption{
+					WithBoxSet([]Box{{Size: 500, Cost: -1}}),
+				},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "negative max weight - error",
+			args: args{
+				opts: []PackerOption{
+					WithBoxSet([]Box{{Size: 500, MaxWeight: -1}}),
+				},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "negative penalty - error",
+			args: args{
+				opts: []PackerOption{
+					WithDefaultBoxes(),
+					WithOvershootPenalty(-0.5),
+				},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "negative timeout - error",
+			args: args{
+				opts: []PackerOption{
+					WithDefaultBoxes(),
+					WithTimeout(-time.Second),
+				},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "negative shipment max weight - error",
+			args: args{
+				opts: []PackerOption{
+					WithDefaultBoxes(),
+					WithShipmentLimits(ShipmentLimits{MaxWeight: -1}),
+				},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "min fill above one - error",
+			args: args{
+				opts: []PackerOption{
+					WithDefaultBoxes(),
+					WithFillLimits(FillLimits{MinFill: 1.5}),
+				},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "min quantity above max quantity - error",
+			args: args{
+				opts: []PackerOption{
+					WithBoxSet([]Box{{Size: 250, MinQuantity: 2, MaxQuantity: 1}, {Size: 500}}),
+				},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "same size with different rules - error",
+			args: args{
+				opts: []PackerOption{
+					WithBoxSet([]Box{{Size: 250, Cost: 1}, {Size: 250, Cost: 2, MaxQuantity: 1}, {Size: 500}}),
+				},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "same size with different limits - error",
+			args: args{
+				opts: []PackerOption{
+					WithBoxSet([]Box{{Size: 250, MaxWeight: 10}, {Size: 250, MaxWeight: 20}}),
+				},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "min order above max order - error",
+			args: args{
+				opts: []PackerOption{
+					WithBoxSet([]Box{{Size: 250, MinOrder: 1000, MaxOrder: 500}, {Size: 500}}),
+				},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "orders without eligible box - error",
+			args: args{
+				opts: []PackerOption{
+					WithBoxSet([]Box{{Size: 250, MaxOrder: 1000}, {Size: 5000, MinOrder: 2000}}),
+				},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "unknown objective - error",
+			args: args{
+				opts: []PackerOption{
+					WithDefaultBoxes(),
+					WithObjective("speed"),
+				},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "unknown strategy - error",
+			args: args{
+				opts: []PackerOption{
+					WithDefaultBoxes(),
+					WithStrategy("magic"),
+				},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
 		{
 			name: "custom boxes empty - error",
 			args: args{
@@ -188,6 +1341,12 @@ func TestNewPacker(t *testing.T) {
 				return
 			}
 
+			if got != nil {
+				assert.Equal(t, got.boxes, got.Boxes())
+
+				got.live = nil
+			}
+
 			assert.Equal(t, tt.want, got)
 		})
 	}
@@ -0,0 +1,173 @@
+package packer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	log "github.com/obalunenko/logger"
+)
+
+// ErrInvalidPolicy is returned for an unknown shipment policy.
+var ErrInvalidPolicy = errors.New("invalid shipment policy")
+
+// Policy is how much of an order ships when the boxes do not hold it exactly.
+type Policy string
+
+const (
+	// PolicyUp ships the whole order, the last box may be partly empty.
+	PolicyUp Policy = "up"
+	// PolicyDown ships only full boxes, items that do not fill a box are backordered.
+	PolicyDown Policy = "down"
+	// PolicyNearest ships whichever of up and down is closer to the order, the whole order on a tie.
+	PolicyNearest Policy = "nearest"
+)
+
+// DefaultPolicy is used when no policy is set.
+const DefaultPolicy = PolicyUp
+
+func (pl Policy) validate() error {
+	switch pl {
+	case "", PolicyUp, PolicyDown, PolicyNearest:
+		return nil
+	default:
+		return fmt.Errorf("%w %q, available: %s, %s, %s", ErrInvalidPolicy, pl, PolicyUp, PolicyDown, PolicyNearest)
+	}
+}
+
+// WithPolicy sets how much of the order ships, the rest is backordered.
+func WithPolicy(pl Policy) OrderOption {
+	return func(o *orderOptions) {
+		o.policy = pl
+	}
+}
+
+// solvePolicy returns box counts of the problem under the policy.
+func (p Packer) solvePolicy(ctx context.Context, prob Problem, pl Policy) ([]uint, bool, error) {
+	switch pl {
+	case PolicyDown:
+		return p.solveDown(ctx, prob)
+	case PolicyNearest:
+		up, upInterrupted, err := p.solve(ctx, prob)
+		if err != nil && !errors.Is(err, ErrInsufficientStock) {
+			return nil, false, err
+		}
+
+		down, downInterrupted, derr := p.solveDown(ctx, prob)
+		if derr != nil {
+			return nil, false, derr
+		}
+
+		if err != nil || prob.Items-shippedOf(prob, down) < shippedOf(prob, up)-prob.Items {
+			return down, downInterrupted, nil
+		}
+
+		return up, upInterrupted, nil
+	default:
+		return p.solve(ctx, prob)
+	}
+}
+
+// solveDown returns box counts of the largest total not above the order, found by a binary search
+// over orders the solver packs with the fewest items: ships(x) grows with x, so the largest x that ships
+// no more than the order ships the largest such total. Only full boxes ship, the rest is backordered.
+func (p Packer) solveDown(ctx context.Context, prob Problem) ([]uint, bool, error) {
+	q := prob
+	q.Objective = ObjectivePacks
+
+	// Boxes every packing uses ship even if they hold more than the order.
+	best := make([]uint, len(prob.Boxes))
+	copy(best, prob.Min)
+
+	fixed := shippedOf(prob, best)
+	if fixed >= prob.Items {
+		return best, false, nil
+	}
+
+	var (
+		lo, hi      = fixed, prob.Items
+		interrupted bool
+	)
+
+	for first := true; lo < hi; first = false {
+		// The whole order is tried first, it ships exactly when boxes hold it.
+		mid := hi
+		if !first {
+			mid = lo + (hi-lo+1)/2
+		}
+
+		q.Items = mid
+
+		counts, intr, err := p.solve(ctx, q)
+		if errors.Is(err, ErrInsufficientStock) {
+			hi = mid - 1
+
+			continue
+		}
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		if s := shippedOf(q, counts); s <= prob.Items {
+			lo, best = s, counts
+			interrupted = interrupted || intr
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	if lo == fixed {
+		return best, interrupted, nil
+	}
+
+	// Packing of the total itself may take fewer packs or cost less than the one found by the search,
+	// unless it ships more than the order, as the cheapest one may.
+	q = prob
+	q.Items = lo
+
+	counts, intr, err := p.solve(ctx, q)
+	if err != nil || shippedOf(q, counts) > prob.Items {
+		return best, interrupted, nil
+	}
+
+	return counts, interrupted || intr, nil
+}
+
+// solve returns box counts of the problem, interrupted is set when solving ran out of time
+// and the anytime packer returns the best counts found by then.
+func (p Packer) solve(ctx context.Context, prob Problem) ([]uint, bool, error) {
+	if prob.Items == 0 {
+		return make([]uint, len(prob.Boxes)), false, nil
+	}
+
+	// Boxes every packing uses are taken first, so that any solver honours them.
+	rest, fixed := prob.residual()
+
+	counts, err := p.solver.Solve(ctx, rest)
+	if counts != nil {
+		counts = prob.cheapest(addCounts(counts, fixed))
+	}
+
+	switch {
+	case err == nil:
+		return counts, false, nil
+	case p.anytime && errors.Is(err, ErrInterrupted):
+		log.WithError(ctx, err).WithField("items", prob.Items).Warn("Solving interrupted, packing may be not optimal")
+
+		return counts, true, nil
+	default:
+		return nil, false, fmt.Errorf("failed to solve with %s strategy: %w", p.strategy, err)
+	}
+}
+
+// shippedOf returns the number of items the boxes of the problem hold.
+func shippedOf(prob Problem, counts []uint) uint {
+	var s uint
+
+	for i, n := range counts {
+		s += n * prob.Boxes[i].Size
+	}
+
+	return s
+}
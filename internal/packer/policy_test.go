@@ -0,0 +1,168 @@
+package packer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obalunenko/orderpacker/internal/inventory"
+	"github.com/obalunenko/orderpacker/internal/testlogger"
+)
+
+func TestPacker_PackOrder_policy(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	type want struct {
+		packs       []BoxQuantity
+		shipped     uint
+		backordered uint
+	}
+
+	tests := []struct {
+		name    string
+		boxes   []uint
+		items   uint
+		policy  Policy
+		want    want
+		wantErr error
+	}{
+		{
+			name:   "up ships the whole order",
+			boxes:  []uint{250, 500, 1000, 2000, 5000},
+			items:  251,
+			policy: PolicyUp,
+			want:   want{packs: []BoxQuantity{{Box: 500, Quantity: 1}}, shipped: 500},
+		},
+		{
+			name:   "down ships full boxes",
+			boxes:  []uint{250, 500, 1000, 2000, 5000},
+			items:  251,
+			policy: PolicyDown,
+			want:   want{packs: []BoxQuantity{{Box: 250, Quantity: 1}}, shipped: 250, backordered: 1},
+		},
+		{
+			name:   "down ships the largest total not above the order",
+			boxes:  []uint{250, 500, 1000, 2000, 5000},
+			items:  10999,
+			policy: PolicyDown,
+			want: want{
+				packs:       []BoxQuantity{{Box: 5000, Quantity: 2}, {Box: 500, Quantity: 1}, {Box: 250, Quantity: 1}},
+				shipped:     10750,
+				backordered: 249,
+			},
+		},
+		{
+			name:   "down ships the exact order",
+			boxes:  []uint{250, 500, 1000, 2000, 5000},
+			items:  750,
+			policy: PolicyDown,
+			want:   want{packs: []BoxQuantity{{Box: 500, Quantity: 1}, {Box: 250, Quantity: 1}}, shipped: 750},
+		},
+		{
+			name:   "down ships nothing below the smallest box",
+			boxes:  []uint{250, 500},
+			items:  100,
+			policy: PolicyDown,
+			want:   want{packs: []BoxQuantity{}, backordered: 100},
+		},
+		{
+			name:   "nearest rounds down",
+			boxes:  []uint{250, 500, 1000, 2000, 5000},
+			items:  251,
+			policy: PolicyNearest,
+			want:   want{packs: []BoxQuantity{{Box: 250, Quantity: 1}}, shipped: 250, backordered: 1},
+		},
+		{
+			name:   "nearest rounds up",
+			boxes:  []uint{250, 500, 1000, 2000, 5000},
+			items:  499,
+			policy: PolicyNearest,
+			want:   want{packs: []BoxQuantity{{Box: 500, Quantity: 1}}, shipped: 500},
+		},
+		{
+			name:   "nearest rounds up on a tie",
+			boxes:  []uint{250},
+			items:  375,
+			policy: PolicyNearest,
+			want:   want{packs: []BoxQuantity{{Box: 250, Quantity: 2}}, shipped: 500},
+		},
+		{
+			name:    "invalid policy",
+			boxes:   []uint{250},
+			items:   1,
+			policy:  "sideways",
+			wantErr: ErrInvalidPolicy,
+		},
+	}
+
+	for _, strategy := range Strategies() {
+		t.Run(strategy, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					p, err := NewPacker(ctx, WithBoxes(tt.boxes), WithStrategy(strategy))
+					require.NoError(t, err)
+
+					got, err := p.PackOrder(ctx, tt.items, WithPolicy(tt.policy))
+					if tt.wantErr != nil {
+						require.ErrorIs(t, err, tt.wantErr)
+
+						return
+					}
+
+					require.NoError(t, err)
+
+					assert.Equal(t, tt.want.packs, got.Packs)
+					assert.Equal(t, tt.want.shipped, got.Shipped)
+					assert.Equal(t, tt.want.backordered, got.Backordered)
+					assert.Equal(t, tt.items, got.Shipped+got.Backordered-got.Overshoot)
+				})
+			}
+		})
+	}
+}
+
+func TestPacker_PackOrder_policyStock(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	for _, strategy := range Strategies() {
+		t.Run(strategy, func(t *testing.T) {
+			inv := inventory.New(map[uint]uint{250: 2, 500: 0})
+
+			p, err := NewPacker(ctx, WithBoxes([]uint{250, 500}), WithStrategy(strategy), WithInventory(inv))
+			require.NoError(t, err)
+
+			_, err = p.PackOrder(ctx, 1000)
+			require.ErrorIs(t, err, ErrInsufficientStock)
+
+			got, err := p.PackOrder(ctx, 1000, WithPolicy(PolicyDown), WithCommit())
+			require.NoError(t, err)
+
+			assert.Equal(t, []BoxQuantity{{Box: 250, Quantity: 2}}, got.Packs)
+			assert.Equal(t, uint(500), got.Backordered)
+			assert.Equal(t, map[uint]uint{250: 0, 500: 0}, inv.Stock())
+
+			got, err = p.PackOrder(ctx, 1000, WithPolicy(PolicyNearest))
+			require.NoError(t, err)
+			assert.Equal(t, uint(1000), got.Backordered, "nothing left in stock ships nothing")
+		})
+	}
+}
+
+func TestPacker_PackOrder_policyCost(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := NewPacker(ctx,
+		WithBoxSet([]Box{{Size: 250, Cost: 1}, {Size: 500, Cost: 1.5}, {Size: 1000, Cost: 5}}),
+		WithObjective(ObjectiveCost),
+	)
+	require.NoError(t, err)
+
+	got, err := p.PackOrder(ctx, 1100, WithPolicy(PolicyDown))
+	require.NoError(t, err)
+
+	assert.Equal(t, []BoxQuantity{{Box: 500, Quantity: 2}}, got.Packs)
+	assert.Equal(t, uint(100), got.Backordered)
+	assert.InDelta(t, 3.0, got.Cost, 1e-9)
+}
@@ -0,0 +1,328 @@
+package packer
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"slices"
+)
+
+// Objective is what solvers minimize.
+type Objective string
+
+const (
+	// ObjectivePacks ships the fewest items not less than the order and, among those, uses the fewest packs.
+	ObjectivePacks Objective = "packs"
+	// ObjectiveCost minimizes boxes cost plus overshoot penalty, then shipped items, then packs.
+	ObjectiveCost Objective = "cost"
+)
+
+// DefaultObjective is used when no objective is set.
+const DefaultObjective = ObjectivePacks
+
+func (o Objective) validate() error {
+	switch o {
+	case ObjectivePacks, ObjectiveCost:
+		return nil
+	default:
+		return fmt.Errorf("unknown objective %q", o)
+	}
+}
+
+// Unlimited is the maximum number of boxes of a size with unlimited supply.
+const Unlimited = math.MaxUint
+
+// ErrInsufficientStock is returned when available boxes cannot hold the order.
+var ErrInsufficientStock = errors.New("insufficient stock of boxes")
+
+// Problem is an order to pack.
+type Problem struct {
+	// Boxes are sorted ascending by size and non-zero, boxes of the same size differ in cost or limits.
+	Boxes []Box
+	// Max holds the maximum number of boxes of each size, indexed as Boxes.
+	// Nil means unlimited supply of every box.
+	Max []uint
+	// Min holds the least number of boxes of each size a packing uses, indexed as Boxes.
+	// Nil means none is required. Solvers of this package honour it, packers take those boxes
+	// before solving, so that other solvers never see it.
+	Min []uint
+	// Items is the number of ordered items.
+	Items uint
+	// Objective is what to minimize.
+	Objective Objective
+	// OvershootPenalty is the cost of each shipped item above the order.
+	// Only used with ObjectiveCost.
+	OvershootPenalty float64
+}
+
+// maxOf returns the maximum number of boxes of i-th size.
+func (prob Problem) maxOf(i int) uint {
+	if prob.Max == nil {
+		return Unlimited
+	}
+
+	return prob.Max[i]
+}
+
+// residual returns the problem left after the least numbers of boxes are taken, and those numbers,
+// nil if none is required. The order is shipped by those boxes and the residual packing.
+func (prob Problem) residual() (Problem, []uint) {
+	if prob.Min == nil {
+		return prob, nil
+	}
+
+	rest := prob
+	rest.Min = nil
+	rest.Max = make([]uint, len(prob.Boxes))
+
+	var fixed uint
+
+	for i, b := range prob.Boxes {
+		m := prob.Min[i]
+
+		rest.Max[i] = prob.maxOf(i)
+		if rest.Max[i] != Unlimited {
+			rest.Max[i] -= min(m, rest.Max[i])
+		}
+
+		fixed = satAdd(fixed, satMul(m, b.Size))
+	}
+
+	rest.Items -= min(fixed, rest.Items)
+
+	return rest, slices.Clone(prob.Min)
+}
+
+// addCounts adds the least numbers of boxes to the counts of the residual packing.
+func addCounts(counts, fixed []uint) []uint {
+	for i, n := range fixed {
+		counts[i] += n
+	}
+
+	return counts
+}
+
+// cheapest returns the counts with boxes of the same size swapped for the cheapest ones their limits allow.
+// Boxes of the same size hold the same items, so the packing ships as much and costs no more.
+func (prob Problem) cheapest(counts []uint) []uint {
+	for lo := 0; lo < len(prob.Boxes); {
+		hi := lo + 1
+		for hi < len(prob.Boxes) && prob.Boxes[hi].Size == prob.Boxes[lo].Size {
+			hi++
+		}
+
+		if hi-lo > 1 {
+			var n uint
+
+			for i := lo; i < hi; i++ {
+				n += counts[i]
+				counts[i] = 0
+
+				if prob.Min != nil {
+					counts[i] = prob.Min[i]
+					n -= prob.Min[i]
+				}
+			}
+
+			same := make([]int, 0, hi-lo)
+			for i := lo; i < hi; i++ {
+				same = append(same, i)
+			}
+
+			slices.SortStableFunc(same, func(a, b int) int {
+				return cmp.Compare(prob.Boxes[a].Cost, prob.Boxes[b].Cost)
+			})
+
+			for _, i := range same {
+				add := min(n, prob.maxOf(i)-counts[i])
+				counts[i] += add
+				n -= add
+			}
+		}
+
+		lo = hi
+	}
+
+	return counts
+}
+
+// CostEpsilon is the tolerance of costs comparison, costs closer than it are equal.
+const CostEpsilon = 1e-9
+
+// score ranks packings, the lower the better.
+type score struct {
+	// cost is zero for ObjectivePacks.
+	cost  float64
+	total uint
+	packs uint
+}
+
+func (a score) less(b score) bool {
+	if math.Abs(a.cost-b.cost) > CostEpsilon {
+		return a.cost < b.cost
+	}
+
+	if a.total != b.total {
+		return a.total < b.total
+	}
+
+	return a.packs < b.packs
+}
+
+// reduced is a problem scaled down by the gcd of box sizes, with the bulk of a large order
+// taken off by the most efficient box, so that exact solvers only search a small range of totals.
+//
+// A most efficient box E (the lowest cost per item, or simply the largest one for ObjectivePacks)
+// with unlimited supply can replace any E less efficient or smaller boxes in an optimal packing:
+// some of them add up to a multiple of E, and swapping them for E boxes never makes the packing worse.
+// So an optimal packing has fewer than E such boxes, the rest of limited boxes holds at most their
+// stock, and everything above that bound goes into E.
+type reduced struct {
+	// units are box sizes divided by gcd.
+	units []uint
+	// weights are box costs, zero for ObjectivePacks.
+	weights []float64
+	// max are maximum numbers of boxes.
+	max []uint
+	// penalty is the overshoot penalty per unit.
+	penalty float64
+	g       uint
+	// target is the least number of units to ship.
+	target uint
+	// capacity is the most units available boxes hold, Unlimited if any box is unlimited.
+	capacity uint
+	// eff is the index of the most efficient unlimited box (-1 if none) and shift is how many of them were taken off.
+	eff   int
+	shift uint
+	// fixed are the least numbers of boxes taken before reducing, nil if none is required.
+	fixed []uint
+}
+
+func (prob Problem) reduce() reduced {
+	prob, fixed := prob.residual()
+
+	r := reduced{
+		fixed:   fixed,
+		units:   make([]uint, len(prob.Boxes)),
+		weights: make([]float64, len(prob.Boxes)),
+		max:     make([]uint, len(prob.Boxes)),
+		g:       gcdOf(sizesOf(prob.Boxes)),
+		eff:     -1,
+	}
+
+	for i, b := range prob.Boxes {
+		r.units[i] = b.Size / r.g
+		r.max[i] = prob.maxOf(i)
+
+		if prob.Objective == ObjectiveCost {
+			r.weights[i] = b.Cost
+		}
+
+		r.capacity = satAdd(r.capacity, satMul(r.max[i], r.units[i]))
+	}
+
+	if prob.Objective == ObjectiveCost {
+		r.penalty = prob.OvershootPenalty * float64(r.g)
+	}
+
+	r.target = ceilDiv(prob.Items, r.g)
+
+	for i := range r.units {
+		if r.max[i] == Unlimited && (r.eff < 0 || r.moreEfficient(i, r.eff)) {
+			r.eff = i
+		}
+	}
+
+	if r.eff < 0 {
+		return r
+	}
+
+	e := r.units[r.eff]
+
+	if bound := r.bound(); r.target > bound {
+		r.shift = (r.target - bound - 1) / e
+		r.target -= r.shift * e
+	}
+
+	return r
+}
+
+// bound returns the most units an optimal packing holds in boxes other than the most efficient one,
+// which must be set.
+func (r reduced) bound() uint {
+	e := r.units[r.eff]
+
+	var replaceable, bound uint
+
+	for i, u := range r.units {
+		switch {
+		case i == r.eff:
+		case r.moreEfficient(i, r.eff):
+			bound = satAdd(bound, satMul(r.max[i], u))
+		default:
+			replaceable = max(replaceable, u)
+		}
+	}
+
+	return satAdd(bound, satMul(e-1, replaceable))
+}
+
+// moreEfficient reports whether i-th box is cheaper per unit than j-th one or as cheap and larger.
+func (r reduced) moreEfficient(i, j int) bool {
+	a := r.weights[i] / float64(r.units[i])
+	b := r.weights[j] / float64(r.units[j])
+
+	return a < b-CostEpsilon || (math.Abs(a-b) <= CostEpsilon && r.units[i] > r.units[j])
+}
+
+// limit returns the largest total worth searching: the best total is always below target+largest,
+// otherwise some box could be dropped.
+func (r reduced) limit() uint {
+	return min(r.target+r.largest()-1, r.capacity)
+}
+
+// score returns the score of packing of total units with given boxes cost and packs count.
+// The overshoot left by gcd rounding is the same for any packing, so it is not counted.
+func (r reduced) score(total uint, cost float64, packs uint) score {
+	return score{
+		cost:  cost + r.penalty*float64(total-r.target),
+		total: total,
+		packs: packs,
+	}
+}
+
+// expand returns the box counts for the original problem.
+func (r reduced) expand(counts []uint) []uint {
+	if r.shift != 0 {
+		counts[r.eff] += r.shift
+	}
+
+	return addCounts(counts, r.fixed)
+}
+
+// largest returns the size of the largest box in units.
+func (r reduced) largest() uint {
+	return r.units[len(r.units)-1]
+}
+
+// satAdd returns a+b, or Unlimited on overflow.
+func satAdd(a, b uint) uint {
+	sum, carry := bits.Add(a, b, 0)
+	if carry != 0 {
+		return Unlimited
+	}
+
+	return sum
+}
+
+// satMul returns a*b, or Unlimited on overflow.
+func satMul(a, b uint) uint {
+	hi, lo := bits.Mul(a, b)
+	if hi != 0 {
+		return Unlimited
+	}
+
+	return lo
+}
@@ -0,0 +1,78 @@
+package packer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+
+	log "github.com/obalunenko/logger"
+)
+
+// DefaultProfile is the name of the box profile of orders that select none.
+const DefaultProfile = "default"
+
+// ErrUnknownProfile is returned when an order selects a box profile that is not configured.
+var ErrUnknownProfile = errors.New("unknown box profile")
+
+// Profiles holds packers of named box profiles, e.g. of warehouses with different cartons,
+// so that one process serves all of them.
+type Profiles struct {
+	packers map[string]*Packer
+}
+
+// NewProfiles returns registry of the default packer and packers of named profiles.
+func NewProfiles(ctx context.Context, dflt *Packer, named map[string]*Packer) (*Profiles, error) {
+	if dflt == nil {
+		return nil, errors.New("default profile is not set")
+	}
+
+	ps := Profiles{
+		packers: make(map[string]*Packer, len(named)+1),
+	}
+
+	for name, p := range named {
+		if name == "" || name == DefaultProfile {
+			return nil, fmt.Errorf("invalid box profile name %q", name)
+		}
+
+		ps.packers[name] = p
+	}
+
+	ps.packers[DefaultProfile] = dflt
+
+	log.WithField(ctx, "profiles", ps.Names()).Info("Box profiles created")
+
+	return &ps, nil
+}
+
+// Get returns the packer of the named profile, the default one if the name is empty.
+func (ps *Profiles) Get(name string) (*Packer, error) {
+	if name == "" {
+		name = DefaultProfile
+	}
+
+	p, ok := ps.packers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q, available: %v", ErrUnknownProfile, name, ps.Names())
+	}
+
+	return p, nil
+}
+
+// Default returns the packer of the default profile.
+func (ps *Profiles) Default() *Packer {
+	return ps.packers[DefaultProfile]
+}
+
+// Names returns sorted names of all profiles, the default one included.
+func (ps *Profiles) Names() []string {
+	return slices.Sorted(maps.Keys(ps.packers))
+}
+
+// All returns an iterator over names and packers of all profiles, the default one included.
+func (ps *Profiles) All() iter.Seq2[string, *Packer] {
+	return maps.All(ps.packers)
+}
@@ -0,0 +1,50 @@
+package packer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obalunenko/orderpacker/internal/testlogger"
+)
+
+func TestNewProfiles(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	dflt, err := NewPacker(ctx, WithDefaultBoxes())
+	require.NoError(t, err)
+
+	east, err := NewPacker(ctx, WithBoxes([]uint{300, 600}))
+	require.NoError(t, err)
+
+	ps, err := NewProfiles(ctx, dflt, map[string]*Packer{"east": east})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{DefaultProfile, "east"}, ps.Names())
+	assert.Same(t, dflt, ps.Default())
+
+	for name, want := range map[string]*Packer{"": dflt, DefaultProfile: dflt, "east": east} {
+		got, err := ps.Get(name)
+		require.NoError(t, err)
+		assert.Same(t, want, got)
+	}
+
+	all := make(map[string]*Packer)
+
+	for name, p := range ps.All() {
+		all[name] = p
+	}
+
+	assert.Equal(t, map[string]*Packer{DefaultProfile: dflt, "east": east}, all)
+
+	_, err = ps.Get("west")
+	assert.ErrorIs(t, err, ErrUnknownProfile)
+
+	_, err = NewProfiles(ctx, dflt, map[string]*Packer{DefaultProfile: east})
+	assert.Error(t, err)
+
+	_, err = NewProfiles(ctx, nil, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,80 @@
+package packer
+
+// BoxQuantity is a number of boxes of the same size.
+type BoxQuantity struct {
+	Box      uint
+	Quantity uint
+	// Fill is how much of the box each pack takes up, set when the order has unit weight or volume.
+	Fill *Fill
+}
+
+// PackResult is a packing of an order.
+type PackResult struct {
+	// Items is the number of ordered items.
+	Items uint
+	// Packs holds quantities per box size, the box holding most items first.
+	Packs []BoxQuantity
+	// Shipped is the number of items the packs hold, which is less than box sizes when weight or volume limits bind.
+	Shipped uint
+	// Overshoot is the number of shipped items above the order.
+	Overshoot uint
+	// Backordered is the number of ordered items left unshipped by the policy of the order.
+	Backordered uint
+	// PackCount is the total number of packs.
+	PackCount uint
+	// Cost is the cost of the packs plus the overshoot penalty.
+	Cost float64
+	// Strategy is the name of the solver that produced the packing.
+	Strategy string
+	// Interrupted is set when solving ran out of time and the packing is the best found by then,
+	// it may be not optimal.
+	Interrupted bool
+	// Shipments split the packs within the shipment limits, set when the packer or the order has them.
+	Shipments []Shipment
+	// Explanation tells why the packing was chosen, set when the order asks for it.
+	Explanation *Explanation
+}
+
+// newPackResult builds result from the number of boxes of each fit.
+func (p Packer) newPackResult(fits []fit, counts []uint, items uint, u Unit) PackResult {
+	res := PackResult{
+		Items:    items,
+		Packs:    make([]BoxQuantity, 0, len(counts)),
+		Strategy: p.strategy,
+	}
+
+	for i := len(counts) - 1; i >= 0; i-- {
+		if counts[i] == 0 {
+			continue
+		}
+
+		f := fits[i]
+
+		q := BoxQuantity{
+			Box:      f.box.Size,
+			Quantity: counts[i],
+		}
+
+		if u.measured() {
+			fill := fillOf(f, u)
+			q.Fill = &fill
+		}
+
+		res.Packs = append(res.Packs, q)
+
+		res.Shipped += f.capacity * counts[i]
+		res.PackCount += counts[i]
+		res.Cost += f.box.Cost * float64(counts[i])
+	}
+
+	switch {
+	case res.Shipped > items:
+		res.Overshoot = res.Shipped - items
+	case res.Shipped < items:
+		res.Backordered = items - res.Shipped
+	}
+
+	res.Cost += p.penalty * float64(res.Overshoot)
+
+	return res
+}
@@ -0,0 +1,274 @@
+package packer
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// ErrShipmentLimit is returned when a single pack exceeds the limits of a shipment.
+var ErrShipmentLimit = errors.New("pack exceeds shipment limits")
+
+// ShipmentLimits cap a single shipment, zero means no limit.
+type ShipmentLimits struct {
+	// MaxPacks is the largest number of packs in a shipment.
+	MaxPacks uint
+	// MaxItems is the largest number of items in a shipment.
+	MaxItems uint
+	// MaxWeight is the heaviest load of a shipment, it binds only when the order sets the unit weight.
+	MaxWeight float64
+}
+
+func (l ShipmentLimits) set() bool {
+	return l.MaxPacks != 0 || l.MaxItems != 0 || l.MaxWeight != 0
+}
+
+func (l ShipmentLimits) validate() error {
+	if !validCost(l.MaxWeight) {
+		return fmt.Errorf("invalid shipment max weight %v", l.MaxWeight)
+	}
+
+	return nil
+}
+
+// Shipment is a number of identical shipments of packs.
+type Shipment struct {
+	// Quantity is the number of identical shipments.
+	Quantity uint
+	// Packs hold quantities per box size in each shipment, the box holding most items first.
+	Packs []BoxQuantity
+	// Items is the number of items in each shipment.
+	Items uint
+	// PackCount is the number of packs in each shipment.
+	PackCount uint
+	// Weight is the load of each shipment, zero when the order has no unit weight.
+	Weight float64
+}
+
+// WithShipmentLimits splits packings into the fewest shipments within the limits, balancing load across them.
+func WithShipmentLimits(l ShipmentLimits) PackerOption {
+	return func(p *Packer) {
+		p.shipment = l
+	}
+}
+
+// WithShipments splits the packing of the order into shipments within the limits instead of those of the packer.
+func WithShipments(l ShipmentLimits) OrderOption {
+	return func(o *orderOptions) {
+		o.shipment = &l
+	}
+}
+
+// packGroup is a number of packs of the same box holding the same number of items.
+type packGroup struct {
+	box      uint
+	items    uint
+	quantity uint
+}
+
+// shipmentClass is a number of shipments of the same packs.
+type shipmentClass struct {
+	n      uint
+	counts []uint
+	items  uint
+	packs  uint
+}
+
+// split returns the fewest shipments within the limits of the packing, the heaviest first.
+// Packs of each box are spread evenly, the remainder goes to the least loaded shipments,
+// so the load of shipments differs by about a pack. Identical shipments are grouped.
+func split(res PackResult, u Unit, l ShipmentLimits) ([]Shipment, error) {
+	if err := l.validate(); err != nil {
+		return nil, err
+	}
+
+	groups := packGroups(res)
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	for _, g := range groups {
+		if l.MaxItems != 0 && g.items > l.MaxItems || l.MaxWeight != 0 && float64(g.items)*u.Weight > l.MaxWeight {
+			return nil, fmt.Errorf("%w: box %d holds %d items", ErrShipmentLimit, g.box, g.items)
+		}
+	}
+
+	items := res.Shipped - res.Overshoot
+
+	k := uint(1)
+
+	if l.MaxPacks != 0 {
+		k = max(k, ceilDiv(res.PackCount, l.MaxPacks))
+	}
+
+	if l.MaxItems != 0 {
+		k = max(k, ceilDiv(items, l.MaxItems))
+	}
+
+	if l.MaxWeight != 0 && u.Weight != 0 {
+		k = max(k, uint(min(math.Ceil(float64(items)*u.Weight/l.MaxWeight), float64(res.PackCount))))
+	}
+
+	within := func(classes []shipmentClass) bool {
+		for _, c := range classes {
+			if l.MaxPacks != 0 && c.packs > l.MaxPacks ||
+				l.MaxItems != 0 && c.items > l.MaxItems ||
+				l.MaxWeight != 0 && float64(c.items)*u.Weight > l.MaxWeight {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	classes := distribute(groups, k)
+
+	if !within(classes) {
+		// A shipment per pack is within the limits, search the fewest shipments between.
+		lo, hi := k+1, res.PackCount
+
+		for lo < hi {
+			mid := lo + (hi-lo)/2
+
+			if within(distribute(groups, mid)) {
+				hi = mid
+			} else {
+				lo = mid + 1
+			}
+		}
+
+		classes = distribute(groups, hi)
+	}
+
+	shipments := make([]Shipment, 0, len(classes))
+
+	for _, c := range classes {
+		s := Shipment{
+			Quantity:  c.n,
+			Packs:     make([]BoxQuantity, 0, len(c.counts)),
+			Items:     c.items,
+			PackCount: c.packs,
+			Weight:    float64(c.items) * u.Weight,
+		}
+
+		for i, n := range c.counts {
+			if n == 0 {
+				continue
+			}
+
+			if j := slices.IndexFunc(s.Packs, func(q BoxQuantity) bool { return q.Box == groups[i].box }); j >= 0 {
+				s.Packs[j].Quantity += n
+
+				continue
+			}
+
+			s.Packs = append(s.Packs, BoxQuantity{Box: groups[i].box, Quantity: n})
+		}
+
+		shipments = append(shipments, s)
+	}
+
+	slices.SortStableFunc(shipments, func(a, b Shipment) int {
+		return cmp.Or(cmp.Compare(b.Items, a.Items), cmp.Compare(b.PackCount, a.PackCount))
+	})
+
+	return shipments, nil
+}
+
+// packGroups returns packs of the result by the number of items they hold. Packs hold as many items as they take
+// but the overshoot, which is left out of the packs holding fewest items.
+func packGroups(res PackResult) []packGroup {
+	groups := make([]packGroup, 0, len(res.Packs)+2)
+
+	for _, q := range res.Packs {
+		items := q.Box
+		if q.Fill != nil {
+			items = q.Fill.Items
+		}
+
+		groups = append(groups, packGroup{box: q.Box, items: items, quantity: q.Quantity})
+	}
+
+	slices.SortStableFunc(groups, func(a, b packGroup) int {
+		return cmp.Compare(b.items, a.items)
+	})
+
+	over := res.Overshoot
+
+	for i := len(groups) - 1; i >= 0 && over != 0; i-- {
+		g := groups[i]
+
+		empty := min(g.quantity, over/max(g.items, 1))
+		over -= empty * g.items
+
+		rest := []packGroup{{box: g.box, items: g.items, quantity: g.quantity - empty}}
+
+		if rest[0].quantity != 0 && over != 0 && over < g.items {
+			rest[0].quantity--
+			rest = append(rest, packGroup{box: g.box, items: g.items - over, quantity: 1})
+			over = 0
+		}
+
+		if empty != 0 {
+			rest = append(rest, packGroup{box: g.box, quantity: empty})
+		}
+
+		groups = slices.Replace(groups, i, i+1, slices.DeleteFunc(rest, func(g packGroup) bool { return g.quantity == 0 })...)
+	}
+
+	return groups
+}
+
+// distribute spreads groups of packs over k shipments, the packs holding most items first.
+func distribute(groups []packGroup, k uint) []shipmentClass {
+	classes := []shipmentClass{{n: k, counts: make([]uint, len(groups))}}
+
+	for i, g := range groups {
+		base, r := g.quantity/k, g.quantity%k
+
+		slices.SortStableFunc(classes, func(a, b shipmentClass) int {
+			return cmp.Or(cmp.Compare(a.items, b.items), cmp.Compare(a.packs, b.packs))
+		})
+
+		next := make([]shipmentClass, 0, len(classes)+1)
+
+		for _, c := range classes {
+			if r != 0 && r < c.n {
+				more := c
+				more.n = r
+				next = append(next, more.add(i, g, base+1))
+
+				c.n -= r
+				r = 0
+			}
+
+			if r != 0 {
+				r -= c.n
+				next = append(next, c.add(i, g, base+1))
+
+				continue
+			}
+
+			next = append(next, c.add(i, g, base))
+		}
+
+		classes = next
+	}
+
+	return classes
+}
+
+func (c shipmentClass) add(i int, g packGroup, n uint) shipmentClass {
+	if n == 0 {
+		return c
+	}
+
+	c.counts = slices.Clone(c.counts)
+	c.counts[i] += n
+	c.items += n * g.items
+	c.packs += n
+
+	return c
+}
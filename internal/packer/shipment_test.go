@@ -0,0 +1,145 @@
+package packer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obalunenko/orderpacker/internal/testlogger"
+)
+
+func TestPacker_PackOrder_shipments(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	tests := []struct {
+		name    string
+		boxes   []uint
+		limits  ShipmentLimits
+		items   uint
+		opts    []OrderOption
+		want    []Shipment
+		wantErr error
+	}{
+		{
+			name:  "no limits",
+			boxes: DefaultBoxes,
+			items: 12001,
+		},
+		{
+			name:   "max packs",
+			boxes:  DefaultBoxes,
+			limits: ShipmentLimits{MaxPacks: 2},
+			items:  12001,
+			want: []Shipment{
+				{Quantity: 1, Packs: []BoxQuantity{{Box: 5000, Quantity: 1}, {Box: 2000, Quantity: 1}}, Items: 7000, PackCount: 2},
+				{Quantity: 1, Packs: []BoxQuantity{{Box: 5000, Quantity: 1}, {Box: 250, Quantity: 1}}, Items: 5001, PackCount: 2},
+			},
+		},
+		{
+			name:   "max items balances load",
+			boxes:  []uint{10},
+			limits: ShipmentLimits{MaxItems: 35},
+			items:  100,
+			want: []Shipment{
+				{Quantity: 2, Packs: []BoxQuantity{{Box: 10, Quantity: 3}}, Items: 30, PackCount: 3},
+				{Quantity: 2, Packs: []BoxQuantity{{Box: 10, Quantity: 2}}, Items: 20, PackCount: 2},
+			},
+		},
+		{
+			name:   "max weight",
+			boxes:  []uint{10},
+			limits: ShipmentLimits{MaxWeight: 45},
+			items:  100,
+			opts:   []OrderOption{WithUnit(Unit{Weight: 1.5})},
+			want: []Shipment{
+				{Quantity: 2, Packs: []BoxQuantity{{Box: 10, Quantity: 3}}, Items: 30, PackCount: 3, Weight: 45},
+				{Quantity: 2, Packs: []BoxQuantity{{Box: 10, Quantity: 2}}, Items: 20, PackCount: 2, Weight: 30},
+			},
+		},
+		{
+			name:   "max weight without unit weight",
+			boxes:  []uint{10},
+			limits: ShipmentLimits{MaxWeight: 45},
+			items:  100,
+			want: []Shipment{
+				{Quantity: 1, Packs: []BoxQuantity{{Box: 10, Quantity: 10}}, Items: 100, PackCount: 10},
+			},
+		},
+		{
+			name:   "order limits take precedence",
+			boxes:  []uint{10},
+			limits: ShipmentLimits{MaxPacks: 1},
+			items:  100,
+			opts:   []OrderOption{WithShipments(ShipmentLimits{MaxPacks: 5})},
+			want: []Shipment{
+				{Quantity: 2, Packs: []BoxQuantity{{Box: 10, Quantity: 5}}, Items: 50, PackCount: 5},
+			},
+		},
+		{
+			name:   "large order",
+			boxes:  []uint{1},
+			limits: ShipmentLimits{MaxPacks: 1000},
+			items:  1_000_000_000_000,
+			want: []Shipment{
+				{Quantity: 1_000_000_000, Packs: []BoxQuantity{{Box: 1, Quantity: 1000}}, Items: 1000, PackCount: 1000},
+			},
+		},
+		{
+			name:    "pack exceeds limits",
+			boxes:   []uint{10},
+			limits:  ShipmentLimits{MaxItems: 5},
+			items:   100,
+			wantErr: ErrShipmentLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPacker(ctx, WithBoxes(tt.boxes), WithShipmentLimits(tt.limits))
+			require.NoError(t, err)
+
+			got, err := p.PackOrder(ctx, tt.items, tt.opts...)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Shipments)
+
+			var items, packs uint
+
+			for _, s := range got.Shipments {
+				items += s.Quantity * s.Items
+				packs += s.Quantity * s.PackCount
+			}
+
+			if tt.want != nil {
+				assert.Equal(t, tt.items, items)
+				assert.Equal(t, got.PackCount, packs)
+			}
+		})
+	}
+}
+
+func Test_split(t *testing.T) {
+	res := PackResult{
+		Items:     1,
+		Packs:     []BoxQuantity{{Box: 500, Quantity: 1}, {Box: 250, Quantity: 2}},
+		Shipped:   1000,
+		Overshoot: 999,
+		PackCount: 3,
+		Cost:      0,
+	}
+
+	got, err := split(res, Unit{}, ShipmentLimits{MaxPacks: 1})
+	require.NoError(t, err)
+
+	assert.Equal(t, []Shipment{
+		{Quantity: 1, Packs: []BoxQuantity{{Box: 500, Quantity: 1}}, Items: 1, PackCount: 1},
+		{Quantity: 2, Packs: []BoxQuantity{{Box: 250, Quantity: 1}}, Items: 0, PackCount: 1},
+	}, got, "overshoot is left out of the packs holding fewest items")
+}
@@ -0,0 +1,118 @@
+package packer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Known packing strategies.
+const (
+	// StrategyGreedy takes as many of the largest boxes as possible first. Fast, but may overship.
+	StrategyGreedy = "greedy"
+	// StrategyExact runs a dynamic programming over reachable totals. Always optimal.
+	StrategyExact = "exact"
+	// StrategyBranchAndBound searches box combinations with pruning. Always optimal.
+	StrategyBranchAndBound = "branch-and-bound"
+)
+
+// DefaultStrategy is used when no strategy is set.
+const DefaultStrategy = StrategyExact
+
+// ErrInterrupted is returned by solvers along with the best packing found so far
+// when the context is done before they finish.
+var ErrInterrupted = errors.New("solving interrupted")
+
+// Solver computes how many boxes of each size are needed to pack an order.
+type Solver interface {
+	// Name returns the name the solver is registered with.
+	Name() string
+	// Solve returns the number of boxes of each size for the problem, indexed as problem boxes.
+	// Solvers that take long stop when the context is done: they return the best packing found so far
+	// with an error wrapping ErrInterrupted and the context error, or only the context error if there is none.
+	Solve(ctx context.Context, prob Problem) ([]uint, error)
+}
+
+// checkEvery is the number of steps solvers take between checks of the context.
+const checkEvery = 1 << 12
+
+// stopper tells solvers to stop when the context is done. Checking the context takes a lock,
+// so it is checked once in checkEvery steps.
+type stopper struct {
+	ctx   context.Context
+	steps uint
+}
+
+func (s *stopper) stop() bool {
+	s.steps++
+
+	return s.steps%checkEvery == 0 && s.ctx.Err() != nil
+}
+
+// interrupted returns the error of a solver stopped by the context, found tells whether it has a packing to return.
+func interrupted(ctx context.Context, found bool) error {
+	if found {
+		return fmt.Errorf("%w: %w", ErrInterrupted, context.Cause(ctx))
+	}
+
+	return fmt.Errorf("no packing found: %w", context.Cause(ctx))
+}
+
+var (
+	solversMu sync.RWMutex
+	solvers   = make(map[string]Solver)
+)
+
+func init() {
+	RegisterSolver(greedySolver{})
+	RegisterSolver(exactSolver{})
+	RegisterSolver(branchAndBoundSolver{})
+}
+
+// RegisterSolver makes a solver available by its name.
+// It panics if a solver with the same name is already registered.
+func RegisterSolver(s Solver) {
+	solversMu.Lock()
+	defer solversMu.Unlock()
+
+	if _, exist := solvers[s.Name()]; exist {
+		panic(fmt.Errorf("solver %q already registered", s.Name()))
+	}
+
+	solvers[s.Name()] = s
+}
+
+// SolverByName returns registered solver by its name.
+func SolverByName(name string) (Solver, error) {
+	solversMu.RLock()
+	defer solversMu.RUnlock()
+
+	s, ok := solvers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q, available: %v", name, strategies())
+	}
+
+	return s, nil
+}
+
+// Strategies returns sorted names of all registered solvers.
+func Strategies() []string {
+	solversMu.RLock()
+	defer solversMu.RUnlock()
+
+	return strategies()
+}
+
+func strategies() []string {
+	names := make([]string, 0, len(solvers))
+
+	for name := range solvers {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
@@ -0,0 +1,103 @@
+package packer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// MaxTableItems limits the number of orders a packing table covers.
+const MaxTableItems = 100000
+
+// ErrInvalidRange is returned for a range of orders a packing table cannot cover.
+var ErrInvalidRange = errors.New("invalid range of orders")
+
+// Breakpoint is a range of orders packed the same way.
+type Breakpoint struct {
+	// From and To are the least and the largest number of items in the range.
+	From      uint
+	To        uint
+	Packs     []BoxQuantity
+	Shipped   uint
+	PackCount uint
+	Cost      float64
+}
+
+// Table returns the packings of orders from one to the other number of items, merged into ranges of
+// orders packed the same way. Orders are packed as PackOrder does with unlimited supply of boxes,
+// so the table does not change with stock levels. Fill limits, exact fit mode and shipment limits do not apply
+// either: the table shows how every order packs, also those they reject, and in which boxes.
+// The timeout of the packer bounds the whole table.
+func (p Packer) Table(ctx context.Context, from, to uint, opts ...OrderOption) ([]Breakpoint, error) {
+	p = p.loaded()
+	p.fill = FillLimits{}
+	p.shipment = ShipmentLimits{}
+
+	if from == 0 || from > to {
+		return nil, fmt.Errorf("%w: from %d to %d items", ErrInvalidRange, from, to)
+	}
+
+	if to-from >= MaxTableItems {
+		return nil, fmt.Errorf("%w: from %d to %d items, at most %d orders", ErrInvalidRange, from, to, MaxTableItems)
+	}
+
+	var o orderOptions
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// Packing table is a reference, it never takes boxes off the stock nor explains packings.
+	o.commit, o.explain = false, false
+	o.exactFit = new(bool)
+	o.shipment = nil
+
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var table []Breakpoint
+
+	// The loop stops at the largest order, so that it does not wrap around the maximum number of items.
+	for items := from; ; items++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		res, err := p.pack(ctx, items, o, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack %d items: %w", items, err)
+		}
+
+		if n := len(table); n != 0 && SamePacks(table[n-1].Packs, res.Packs) {
+			table[n-1].To = items
+		} else {
+			table = append(table, Breakpoint{
+				From:      items,
+				To:        items,
+				Packs:     res.Packs,
+				Shipped:   res.Shipped,
+				PackCount: res.PackCount,
+				Cost:      res.Cost,
+			})
+		}
+
+		if items == to {
+			break
+		}
+	}
+
+	return table, nil
+}
+
+// TableTo returns the largest order of a packing table from the given number of items by default:
+// the items n largest boxes hold, but at most limit orders.
+func (p Packer) TableTo(from, n, limit uint) uint {
+	boxes := p.Boxes()
+
+	to := satMul(n, boxes[len(boxes)-1].Size)
+	if limit != 0 {
+		to = min(to, satAdd(from, limit-1))
+	}
+
+	return to
+}
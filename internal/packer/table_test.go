@@ -0,0 +1,191 @@
+package packer
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obalunenko/orderpacker/internal/testlogger"
+)
+
+func TestPacker_Table(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := NewPacker(ctx, WithDefaultBoxes())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		from, to uint
+		want     []Breakpoint
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{
+			name: "first breakpoints",
+			from: 1,
+			to:   1000,
+			want: []Breakpoint{
+				{From: 1, To: 250, Packs: []BoxQuantity{{Box: 250, Quantity: 1}}, Shipped: 250, PackCount: 1},
+				{From: 251, To: 500, Packs: []BoxQuantity{{Box: 500, Quantity: 1}}, Shipped: 500, PackCount: 1},
+				{From: 501, To: 750, Packs: []BoxQuantity{{Box: 500, Quantity: 1}, {Box: 250, Quantity: 1}}, Shipped: 750, PackCount: 2},
+				{From: 751, To: 1000, Packs: []BoxQuantity{{Box: 1000, Quantity: 1}}, Shipped: 1000, PackCount: 1},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "range within breakpoint",
+			from: 260,
+			to:   270,
+			want: []Breakpoint{
+				{From: 260, To: 270, Packs: []BoxQuantity{{Box: 500, Quantity: 1}}, Shipped: 500, PackCount: 1},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "zero from",
+			from:    0,
+			to:      10,
+			wantErr: assertErrorIs(ErrInvalidRange),
+		},
+		{
+			name:    "reversed",
+			from:    10,
+			to:      1,
+			wantErr: assertErrorIs(ErrInvalidRange),
+		},
+		{
+			name:    "too many orders",
+			from:    1,
+			to:      MaxTableItems + 1,
+			wantErr: assertErrorIs(ErrInvalidRange),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.Table(ctx, tt.from, tt.to)
+			if !tt.wantErr(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPacker_Table_packOrder(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := NewPacker(ctx,
+		WithBoxSet([]Box{{Size: 23, Cost: 1}, {Size: 31, Cost: 1.5}, {Size: 53, Cost: 3}}),
+		WithObjective(ObjectiveCost),
+	)
+	require.NoError(t, err)
+
+	table, err := p.Table(ctx, 1, 1000)
+	require.NoError(t, err)
+
+	next := uint(1)
+
+	for _, bp := range table {
+		require.Equal(t, next, bp.From)
+
+		for items := bp.From; items <= bp.To; items++ {
+			res, err := p.PackOrder(ctx, items)
+			require.NoError(t, err)
+
+			require.Equalf(t, bp.Packs, res.Packs, "items %d", items)
+		}
+
+		next = bp.To + 1
+	}
+
+	assert.Equal(t, uint(1001), next)
+}
+
+func TestPacker_Table_fillLimits(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := NewPacker(ctx, WithDefaultBoxes())
+	require.NoError(t, err)
+
+	want, err := p.Table(ctx, 1, 1000)
+	require.NoError(t, err)
+
+	limited, err := NewPacker(ctx, WithDefaultBoxes(), WithFillLimits(FillLimits{MinFill: 0.6, MaxWaste: 0.2}))
+	require.NoError(t, err)
+
+	_, err = limited.PackOrder(ctx, 1)
+	require.ErrorIs(t, err, ErrFillLimit)
+
+	got, err := limited.Table(ctx, 1, 1000)
+	require.NoError(t, err)
+
+	assert.Equal(t, want, got)
+}
+
+func TestPacker_Table_exactFit(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := NewPacker(ctx, WithDefaultBoxes())
+	require.NoError(t, err)
+
+	want, err := p.Table(ctx, 1, 1000)
+	require.NoError(t, err)
+
+	exact, err := NewPacker(ctx, WithDefaultBoxes(), WithExactFitOnly(true))
+	require.NoError(t, err)
+
+	_, err = exact.PackOrder(ctx, 1)
+	require.ErrorIs(t, err, ErrNoExactFit)
+
+	got, err := exact.Table(ctx, 1, 1000)
+	require.NoError(t, err)
+
+	assert.Equal(t, want, got)
+}
+
+func TestPacker_Table_shipmentLimits(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := NewPacker(ctx, WithDefaultBoxes(), WithShipmentLimits(ShipmentLimits{MaxItems: 100}))
+	require.NoError(t, err)
+
+	got, err := p.Table(ctx, 501, 750)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Breakpoint{
+		{From: 501, To: 750, Packs: []BoxQuantity{{Box: 500, Quantity: 1}, {Box: 250, Quantity: 1}}, Shipped: 750, PackCount: 2},
+	}, got)
+}
+
+func TestPacker_Table_timeout(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := NewPacker(ctx, WithDefaultBoxes(), WithTimeout(time.Nanosecond))
+	require.NoError(t, err)
+
+	_, err = p.Table(ctx, 1, 1000)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestPacker_TableTo(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := NewPacker(ctx, WithBoxes([]uint{250, 5000}))
+	require.NoError(t, err)
+
+	assert.Equal(t, uint(20000), p.TableTo(1, 4, MaxTableItems))
+	assert.Equal(t, uint(10000), p.TableTo(1, 4, 10000))
+
+	large, err := NewPacker(ctx, WithBoxes([]uint{250, math.MaxUint / 2}))
+	require.NoError(t, err)
+
+	assert.Equal(t, uint(math.MaxUint), large.TableTo(1, 4, 0), "overflowing range is clamped")
+	assert.Equal(t, uint(math.MaxUint), large.TableTo(math.MaxUint-10, 4, MaxTableItems))
+	assert.Equal(t, uint(MaxTableItems), large.TableTo(1, 4, MaxTableItems))
+}
@@ -0,0 +1,154 @@
+package packer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	log "github.com/obalunenko/logger"
+)
+
+// ErrFillLimit is returned when no packing of the order keeps the fill limits, as a *FillLimitError.
+var ErrFillLimit = errors.New("packing breaks fill limits")
+
+// FillLimits bound the empty space of a packing, zero means no limit.
+type FillLimits struct {
+	// MinFill is the least share of a box the items in it take, e.g. 0.6 for boxes at least 60% full.
+	MinFill float64
+	// MaxWaste is the largest share of the shipped capacity left empty, e.g. 0.2 for at most 20% of it.
+	MaxWaste float64
+}
+
+func (l FillLimits) set() bool {
+	return l.MinFill != 0 || l.MaxWaste != 0
+}
+
+func (l FillLimits) validate() error {
+	if math.IsNaN(l.MinFill) || l.MinFill < 0 || l.MinFill > 1 {
+		return fmt.Errorf("invalid min fill %v, must be from 0 to 1", l.MinFill)
+	}
+
+	if math.IsNaN(l.MaxWaste) || l.MaxWaste < 0 || l.MaxWaste > 1 {
+		return fmt.Errorf("invalid max waste %v, must be from 0 to 1", l.MaxWaste)
+	}
+
+	return nil
+}
+
+// FillLimitError reports how a packing breaks the fill limits.
+type FillLimitError struct {
+	FillLimits
+	// Box is the size of the least filled box.
+	Box uint
+	// Fill is the share of the least filled box the items in it take.
+	Fill float64
+	// Waste is the share of the shipped capacity left empty.
+	Waste float64
+}
+
+func (e *FillLimitError) Error() string {
+	var reasons []string
+
+	if e.Fill+fitEpsilon < e.MinFill {
+		reasons = append(reasons, fmt.Sprintf("box %d is %.1f%% full, below %.1f%%", e.Box, e.Fill*100, e.MinFill*100))
+	}
+
+	if e.MaxWaste != 0 && e.Waste > e.MaxWaste+fitEpsilon {
+		reasons = append(reasons, fmt.Sprintf("%.1f%% of the shipped capacity is empty, above %.1f%%", e.Waste*100, e.MaxWaste*100))
+	}
+
+	return fmt.Sprintf("%v: %s", ErrFillLimit, strings.Join(reasons, ", "))
+}
+
+// Is reports whether the target is ErrFillLimit.
+func (e *FillLimitError) Is(target error) bool {
+	return target == ErrFillLimit
+}
+
+// WithFillLimits rejects packings with boxes filled less or more capacity left empty than the limits allow.
+// When the best packing breaks them, the best one within them is searched among all alternatives of the order,
+// within the timeout of the packer.
+func WithFillLimits(l FillLimits) PackerOption {
+	return func(p *Packer) {
+		p.fill = l
+	}
+}
+
+// check returns a *FillLimitError when the packing breaks the limits. The overshoot is left out of the packs
+// holding fewest items, as it is in shipments.
+func (l FillLimits) check(res PackResult) error {
+	if !l.set() || res.Shipped == 0 {
+		return nil
+	}
+
+	fe := &FillLimitError{
+		FillLimits: l,
+		Fill:       1,
+		Waste:      float64(res.Overshoot) / float64(res.Shipped),
+	}
+
+	capacity := make(map[uint]uint, len(res.Packs))
+
+	for _, q := range res.Packs {
+		capacity[q.Box] = q.Box
+		if q.Fill != nil {
+			capacity[q.Box] = q.Fill.Items
+		}
+	}
+
+	for _, g := range packGroups(res) {
+		if f := float64(g.items) / float64(capacity[g.box]); f < fe.Fill {
+			fe.Box, fe.Fill = g.box, f
+		}
+	}
+
+	if fe.Fill+fitEpsilon < l.MinFill || l.MaxWaste != 0 && fe.Waste > l.MaxWaste+fitEpsilon {
+		return fe
+	}
+
+	return nil
+}
+
+// withinFill returns the packing when it keeps the fill limits, otherwise the best alternative packing
+// of the whole order that keeps them. The error of the packing is returned when none does.
+func (p Packer) withinFill(ctx context.Context, prob Problem, fits []fit, res PackResult, u Unit) (PackResult, error) {
+	err := p.fill.check(res)
+	if err == nil {
+		return res, nil
+	}
+
+	// Packings that backorder items leave no box partly empty but those every packing uses.
+	if res.Backordered != 0 {
+		return PackResult{}, err
+	}
+
+	best, aerr := alternatives(ctx, prob, 1, func(counts []uint) bool {
+		return p.fill.check(p.newPackResult(fits, counts, prob.Items, u)) == nil
+	})
+
+	switch {
+	case aerr == nil:
+	case p.anytime && errors.Is(aerr, ErrInterrupted):
+		log.WithError(ctx, aerr).WithField("items", prob.Items).Warn("Search of packing within fill limits interrupted")
+	case errors.Is(aerr, ErrInsufficientStock):
+		return PackResult{}, err
+	default:
+		return PackResult{}, fmt.Errorf("failed to search packing within fill limits: %w", aerr)
+	}
+
+	if len(best) == 0 {
+		return PackResult{}, err
+	}
+
+	alt := p.newPackResult(fits, best[0], prob.Items, u)
+	alt.Interrupted = res.Interrupted || aerr != nil
+
+	log.WithFields(ctx, log.Fields{
+		"items": prob.Items,
+		"packs": alt.Packs,
+	}).Debug("Packing within fill limits found")
+
+	return alt, nil
+}
@@ -0,0 +1,159 @@
+package packer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obalunenko/orderpacker/internal/testlogger"
+)
+
+func TestPacker_PackOrder_fillLimits(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	sameCost := []Box{{Size: 250, Cost: 1}, {Size: 500, Cost: 1}, {Size: 1000, Cost: 1}, {Size: 2000, Cost: 1}, {Size: 5000, Cost: 1}}
+
+	tests := []struct {
+		name      string
+		boxes     []Box
+		objective Objective
+		limits    FillLimits
+		items     uint
+		want      []BoxQuantity
+		wantErr   *FillLimitError
+	}{
+		{
+			name:      "best packing within limits",
+			boxes:     sameCost,
+			objective: ObjectiveCost,
+			limits:    FillLimits{MinFill: 0.5},
+			items:     2600,
+			want:      []BoxQuantity{{Box: 5000, Quantity: 1}},
+		},
+		{
+			name:      "cheapest packing underfills a box",
+			boxes:     sameCost,
+			objective: ObjectiveCost,
+			limits:    FillLimits{MinFill: 0.55},
+			items:     2600,
+			want:      []BoxQuantity{{Box: 2000, Quantity: 1}, {Box: 1000, Quantity: 1}},
+		},
+		{
+			name:      "fewest items underfill the smallest box",
+			boxes:     SizedBoxes(DefaultBoxes),
+			objective: ObjectivePacks,
+			limits:    FillLimits{MinFill: 0.5},
+			items:     2600,
+			want:      []BoxQuantity{{Box: 2000, Quantity: 1}, {Box: 1000, Quantity: 1}},
+		},
+		{
+			name:      "every packing wastes too much",
+			boxes:     SizedBoxes(DefaultBoxes),
+			objective: ObjectivePacks,
+			limits:    FillLimits{MaxWaste: 0.3},
+			items:     251,
+			wantErr: &FillLimitError{
+				FillLimits: FillLimits{MaxWaste: 0.3},
+				Box:        500,
+				Fill:       0.502,
+				Waste:      0.498,
+			},
+		},
+	}
+
+	for _, strategy := range Strategies() {
+		t.Run(strategy, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					p, err := NewPacker(ctx,
+						WithBoxSet(tt.boxes),
+						WithStrategy(strategy),
+						WithObjective(tt.objective),
+						WithFillLimits(tt.limits),
+					)
+					require.NoError(t, err)
+
+					got, err := p.PackOrder(ctx, tt.items)
+					if tt.wantErr != nil {
+						require.ErrorIs(t, err, ErrFillLimit)
+
+						var fe *FillLimitError
+
+						require.ErrorAs(t, err, &fe)
+						assert.Equal(t, tt.wantErr.FillLimits, fe.FillLimits)
+						assert.Equal(t, tt.wantErr.Box, fe.Box)
+						assert.InDelta(t, tt.wantErr.Fill, fe.Fill, 1e-9)
+						assert.InDelta(t, tt.wantErr.Waste, fe.Waste, 1e-9)
+
+						return
+					}
+
+					require.NoError(t, err)
+					assert.Equal(t, tt.want, got.Packs)
+				})
+			}
+		})
+	}
+}
+
+func TestPacker_PackOrder_fillLimitsWaste(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := NewPacker(ctx,
+		WithBoxSet([]Box{{Size: 250, Cost: 1}, {Size: 500, Cost: 1}, {Size: 1000, Cost: 1}, {Size: 2000, Cost: 1}, {Size: 5000, Cost: 1}}),
+		WithObjective(ObjectiveCost),
+		WithFillLimits(FillLimits{MaxWaste: 0.2}),
+	)
+	require.NoError(t, err)
+
+	got, err := p.PackOrder(ctx, 2600)
+	require.NoError(t, err)
+
+	assert.Equal(t, []BoxQuantity{{Box: 2000, Quantity: 1}, {Box: 1000, Quantity: 1}}, got.Packs)
+	assert.InDelta(t, 2.0, got.Cost, 1e-9)
+}
+
+func TestPacker_PackOrder_fillLimitsRankedLow(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	// Hundreds of cheaper packings add small boxes to the large one, all of them leave half of it empty.
+	p, err := NewPacker(ctx,
+		WithBoxSet([]Box{{Size: 1, Cost: 1}, {Size: 1000, Cost: 1}}),
+		WithObjective(ObjectiveCost),
+		WithFillLimits(FillLimits{MaxWaste: 0.2}),
+	)
+	require.NoError(t, err)
+
+	got, err := p.PackOrder(ctx, 500)
+	require.NoError(t, err)
+
+	assert.Equal(t, []BoxQuantity{{Box: 1, Quantity: 500}}, got.Packs)
+}
+
+func TestPacker_PackOrder_fillLimitsExplain(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := NewPacker(ctx, WithDefaultBoxes(), WithFillLimits(FillLimits{MinFill: 0.5}))
+	require.NoError(t, err)
+
+	got, err := p.PackOrder(ctx, 2600, WithExplain())
+	require.NoError(t, err)
+	require.NotNil(t, got.Explanation)
+
+	assert.Equal(t, "Keeps the fill limits; the best candidate ships 250 fewer items "+
+		"but packing breaks fill limits: box 250 is 40.0% full, below 50.0%.", got.Explanation.Decision)
+}
+
+func TestFillLimitError_Error(t *testing.T) {
+	err := &FillLimitError{
+		FillLimits: FillLimits{MinFill: 0.6, MaxWaste: 0.2},
+		Box:        5000,
+		Fill:       0.52,
+		Waste:      0.48,
+	}
+
+	assert.EqualError(t, err, "packing breaks fill limits: box 5000 is 52.0% full, below 60.0%, "+
+		"48.0% of the shipped capacity is empty, above 20.0%")
+}
@@ -0,0 +1,151 @@
+package recommend
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidHistogram is returned for a histogram of orders that cannot be read.
+var ErrInvalidHistogram = errors.New("invalid histogram")
+
+// Bucket is the number of past orders of a size.
+type Bucket struct {
+	Items uint `json:"items"`
+	Count uint `json:"count"`
+}
+
+// Histogram is the distribution of past order sizes, sorted by items with one bucket per size.
+type Histogram []Bucket
+
+// Orders returns the number of orders in the histogram.
+func (h Histogram) Orders() uint {
+	var n uint
+
+	for _, b := range h {
+		n += b.Count
+	}
+
+	return n
+}
+
+// ParseCSV reads records of "items,count", e.g. "12001,3". The count may be left out for a list of single orders
+// and the first record may be a header.
+func ParseCSV(r io.Reader) (Histogram, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var buckets []Bucket
+
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidHistogram, err)
+		}
+
+		if len(rec) > 2 {
+			return nil, fmt.Errorf("%w: line %d: expected items and count, got %d fields", ErrInvalidHistogram, line, len(rec))
+		}
+
+		items, err := strconv.ParseUint(strings.TrimSpace(rec[0]), 10, 0)
+		if err != nil {
+			if line == 1 {
+				// Header.
+				continue
+			}
+
+			return nil, fmt.Errorf("%w: line %d: invalid items %q", ErrInvalidHistogram, line, rec[0])
+		}
+
+		count := uint64(1)
+
+		if len(rec) == 2 {
+			count, err = strconv.ParseUint(strings.TrimSpace(rec[1]), 10, 0)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: invalid count %q", ErrInvalidHistogram, line, rec[1])
+			}
+		}
+
+		buckets = append(buckets, Bucket{Items: uint(items), Count: uint(count)})
+	}
+
+	return newHistogram(buckets)
+}
+
+// ParseJSONL reads one bucket per line, e.g. {"items": 12001, "count": 3}. The count may be left out
+// for a list of single orders.
+func ParseJSONL(r io.Reader) (Histogram, error) {
+	sc := bufio.NewScanner(r)
+
+	var buckets []Bucket
+
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+
+		b := Bucket{Count: 1}
+
+		if err := json.Unmarshal([]byte(text), &b); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidHistogram, line, err)
+		}
+
+		buckets = append(buckets, b)
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidHistogram, err)
+	}
+
+	return newHistogram(buckets)
+}
+
+// newHistogram merges buckets of the same size and drops empty ones.
+func newHistogram(buckets []Bucket) (Histogram, error) {
+	counts := make(map[uint]uint, len(buckets))
+
+	for _, b := range buckets {
+		if b.Items == 0 {
+			return nil, fmt.Errorf("%w: order of zero items", ErrInvalidHistogram)
+		}
+
+		count, carry := bits.Add(counts[b.Items], b.Count, 0)
+		if carry != 0 {
+			return nil, fmt.Errorf("%w: count of orders of %d items overflows", ErrInvalidHistogram, b.Items)
+		}
+
+		if count != 0 {
+			counts[b.Items] = count
+		}
+	}
+
+	if len(counts) == 0 {
+		return nil, fmt.Errorf("%w: no orders", ErrInvalidHistogram)
+	}
+
+	h := make(Histogram, 0, len(counts))
+
+	for items, count := range counts {
+		h = append(h, Bucket{Items: items, Count: count})
+	}
+
+	slices.SortFunc(h, func(a, b Bucket) int {
+		return cmp.Compare(a.Items, b.Items)
+	})
+
+	return h, nil
+}
@@ -0,0 +1,115 @@
+package recommend
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    Histogram
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "with header",
+			in:      "items,count\n500,2\n250,3\n500,1\n",
+			want:    Histogram{{Items: 250, Count: 3}, {Items: 500, Count: 3}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "single orders",
+			in:      "12001\n251\n251\n",
+			want:    Histogram{{Items: 251, Count: 2}, {Items: 12001, Count: 1}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "empty buckets are dropped",
+			in:      "10,0\n20,1\n",
+			want:    Histogram{{Items: 20, Count: 1}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "invalid items",
+			in:      "10,1\nten,1\n",
+			wantErr: assertErrorIs(ErrInvalidHistogram),
+		},
+		{
+			name:    "invalid count",
+			in:      "10,many\n",
+			wantErr: assertErrorIs(ErrInvalidHistogram),
+		},
+		{
+			name:    "zero items",
+			in:      "0,1\n",
+			wantErr: assertErrorIs(ErrInvalidHistogram),
+		},
+		{
+			name:    "no orders",
+			in:      "items,count\n",
+			wantErr: assertErrorIs(ErrInvalidHistogram),
+		},
+		{
+			name:    "too many fields",
+			in:      "10,1,2\n",
+			wantErr: assertErrorIs(ErrInvalidHistogram),
+		},
+		{
+			name:    "count overflows",
+			in:      "10,18446744073709551615\n10,1\n",
+			wantErr: assertErrorIs(ErrInvalidHistogram),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCSV(strings.NewReader(tt.in))
+			if !tt.wantErr(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseJSONL(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    Histogram
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "buckets",
+			in:      `{"items": 500, "count": 2}` + "\n\n" + `{"items": 250}` + "\n",
+			want:    Histogram{{Items: 250, Count: 1}, {Items: 500, Count: 2}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "invalid line",
+			in:      `{"items": "many"}`,
+			wantErr: assertErrorIs(ErrInvalidHistogram),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJSONL(strings.NewReader(tt.in))
+			if !tt.wantErr(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func assertErrorIs(target error) assert.ErrorAssertionFunc {
+	return func(t assert.TestingT, err error, msgAndArgs ...any) bool {
+		return assert.ErrorIs(t, err, target, msgAndArgs...)
+	}
+}
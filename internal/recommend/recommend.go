@@ -0,0 +1,445 @@
+// Package recommend searches for a box set that packs a distribution of past orders best.
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/bits"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/obalunenko/logger"
+
+	"github.com/obalunenko/orderpacker/internal/packer"
+)
+
+const (
+	// MaxSizes limits the number of box sizes to recommend.
+	MaxSizes = 10
+	// maxCandidates limits the number of box sizes the search picks from.
+	maxCandidates = 64
+	// maxRounds limits the number of swap rounds of the search.
+	maxRounds = 50
+)
+
+// Metric is what the recommended box set minimizes over all orders.
+type Metric string
+
+const (
+	// MetricOvershoot minimizes shipped items above orders, then packs.
+	MetricOvershoot Metric = "overshoot"
+	// MetricCost minimizes boxes cost plus overshoot penalty, then overshoot, then packs.
+	MetricCost Metric = "cost"
+)
+
+// Options configure the search.
+type Options struct {
+	// Sizes is the number of box sizes to recommend.
+	Sizes  int
+	Metric Metric
+	// Step rounds candidate box sizes up to its multiples, e.g. 50 recommends sizes like 250 and 300.
+	Step uint
+	// BoxCost and ItemCost price a box of size s at BoxCost + ItemCost*s.
+	BoxCost  float64
+	ItemCost float64
+	// OvershootPenalty is the cost of each shipped item above the order, only used with MetricCost.
+	OvershootPenalty float64
+	// Strategy is the solver boxes sets are evaluated with, the default one if empty.
+	Strategy string
+	// Logger logs the packers of evaluated box sets, the context logger if nil. They are many,
+	// so a caller may pass a logger writing to io.Discard for their logs not to bury the result.
+	Logger log.Logger
+}
+
+func (o Options) validate() error {
+	if o.Sizes < 1 || o.Sizes > MaxSizes {
+		return fmt.Errorf("number of box sizes %d is out of range [1, %d]", o.Sizes, MaxSizes)
+	}
+
+	switch o.Metric {
+	case MetricOvershoot, MetricCost:
+	default:
+		return fmt.Errorf("unknown metric %q", o.Metric)
+	}
+
+	if o.Step == 0 {
+		return fmt.Errorf("step must be positive")
+	}
+
+	for _, v := range []float64{o.BoxCost, o.ItemCost, o.OvershootPenalty} {
+		if v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("invalid cost %v", v)
+		}
+	}
+
+	return nil
+}
+
+// Box returns the box of the size priced by the options.
+func (o Options) Box(size uint) packer.Box {
+	return packer.Box{Size: size, Cost: o.BoxCost + o.ItemCost*float64(size)}
+}
+
+// Evaluation is how a box set packs all orders of a histogram.
+type Evaluation struct {
+	Boxes     []packer.Box
+	Orders    uint
+	Items     uint
+	Shipped   uint
+	Overshoot uint
+	Packs     uint
+	Cost      float64
+}
+
+// better reports whether e packs orders better than other by the metric.
+func (e Evaluation) better(other Evaluation, m Metric) bool {
+	if m == MetricCost && math.Abs(e.Cost-other.Cost) > packer.CostEpsilon {
+		return e.Cost < other.Cost
+	}
+
+	if e.Overshoot != other.Overshoot {
+		return e.Overshoot < other.Overshoot
+	}
+
+	return e.Packs < other.Packs
+}
+
+// Evaluate packs every order of the histogram with the box set as the packer does.
+func Evaluate(ctx context.Context, boxes []packer.Box, h Histogram, opts Options) (Evaluation, error) {
+	objective := packer.ObjectivePacks
+	if opts.Metric == MetricCost {
+		objective = packer.ObjectiveCost
+	}
+
+	if opts.Logger != nil {
+		ctx = log.ContextWithLogger(ctx, opts.Logger)
+	}
+
+	p, err := packer.NewPacker(ctx,
+		packer.WithBoxSet(boxes),
+		packer.WithStrategy(opts.Strategy),
+		packer.WithObjective(objective),
+		packer.WithOvershootPenalty(opts.OvershootPenalty),
+	)
+	if err != nil {
+		return Evaluation{}, err
+	}
+
+	e := Evaluation{Boxes: p.Boxes()}
+
+	for _, b := range h {
+		res, err := p.PackOrder(ctx, b.Items)
+		if err != nil {
+			return Evaluation{}, fmt.Errorf("failed to pack %d items: %w", b.Items, err)
+		}
+
+		ok := true
+
+		for _, t := range []struct {
+			total *uint
+			n     uint
+		}{
+			{&e.Orders, 1},
+			{&e.Items, b.Items},
+			{&e.Shipped, res.Shipped},
+			{&e.Overshoot, res.Overshoot},
+			{&e.Packs, res.PackCount},
+		} {
+			*t.total, ok = addMul(*t.total, b.Count, t.n)
+			if !ok {
+				return Evaluation{}, fmt.Errorf("%w: totals of %d orders of %d items overflow", ErrInvalidHistogram, b.Count, b.Items)
+			}
+		}
+
+		e.Cost += float64(b.Count) * res.Cost
+	}
+
+	return e, nil
+}
+
+// addMul returns sum + count*n and whether it did not overflow.
+func addMul(sum, count, n uint) (uint, bool) {
+	hi, lo := bits.Mul(count, n)
+	if hi != 0 {
+		return 0, false
+	}
+
+	sum, carry := bits.Add(sum, lo, 0)
+
+	return sum, carry == 0
+}
+
+// Recommend searches for the set of box sizes that packs orders of the histogram best by the metric.
+//
+// Candidate sizes are order sizes rounded up to the step, as a box that ships an order exactly holds
+// no overshoot. The search starts with the largest candidate, greedily adds the candidate that improves
+// the set most and then swaps sizes for other candidates while any swap improves it. Every set is
+// evaluated by the packer, so the recommendation holds for the configured strategy.
+func Recommend(ctx context.Context, h Histogram, opts Options) (Evaluation, error) {
+	if err := opts.validate(); err != nil {
+		return Evaluation{}, fmt.Errorf("invalid options: %w", err)
+	}
+
+	if len(h) == 0 {
+		return Evaluation{}, fmt.Errorf("%w: no orders", ErrInvalidHistogram)
+	}
+
+	s := search{
+		h:     h,
+		opts:  opts,
+		cache: make(map[string]Evaluation),
+	}
+
+	cands, err := candidates(h, opts.Step)
+	if err != nil {
+		return Evaluation{}, err
+	}
+
+	best, err := s.evaluate(ctx, []uint{cands[len(cands)-1]})
+	if err != nil {
+		return Evaluation{}, err
+	}
+
+	set := []uint{cands[len(cands)-1]}
+
+	for len(set) < min(opts.Sizes, len(cands)) {
+		var next [][]uint
+
+		for _, c := range cands {
+			if !slices.Contains(set, c) {
+				next = append(next, sorted(append(slices.Clone(set), c)))
+			}
+		}
+
+		// Adding a size never makes the set worse, the best one is taken even if it does not help.
+		set, best, err = s.best(ctx, next, Evaluation{}, false)
+		if err != nil {
+			return Evaluation{}, err
+		}
+
+		logProgress(ctx, "Box size added", best)
+	}
+
+	for range maxRounds {
+		var next [][]uint
+
+		for i := range set {
+			for _, c := range cands {
+				if slices.Contains(set, c) {
+					continue
+				}
+
+				swapped := slices.Clone(set)
+				swapped[i] = c
+
+				next = append(next, sorted(swapped))
+			}
+		}
+
+		if len(next) == 0 {
+			break
+		}
+
+		swapped, e, err := s.best(ctx, next, best, true)
+		if err != nil {
+			return Evaluation{}, err
+		}
+
+		if swapped == nil {
+			break
+		}
+
+		set, best = swapped, e
+
+		logProgress(ctx, "Box size swapped", best)
+	}
+
+	return best, nil
+}
+
+func logProgress(ctx context.Context, msg string, e Evaluation) {
+	log.WithFields(ctx, log.Fields{
+		"boxes":     e.Boxes,
+		"overshoot": e.Overshoot,
+		"packs":     e.Packs,
+		"cost":      e.Cost,
+	}).Info(msg)
+}
+
+// candidates returns distinct order sizes rounded up to the step. When there are too many of them,
+// they are picked at even quantiles of orders, so that frequent sizes are more likely to be candidates.
+// The largest one is always a candidate, so that any order fits a box.
+func candidates(h Histogram, step uint) ([]uint, error) {
+	var sizes []uint
+
+	for _, b := range h {
+		size, ok := roundUp(b.Items, step)
+		if !ok {
+			return nil, fmt.Errorf("%w: order of %d items rounded up to step %d overflows", ErrInvalidHistogram, b.Items, step)
+		}
+
+		if len(sizes) == 0 || sizes[len(sizes)-1] != size {
+			sizes = append(sizes, size)
+		}
+	}
+
+	if len(sizes) <= maxCandidates {
+		return sizes, nil
+	}
+
+	var (
+		picked []uint
+		seen   uint
+		total  uint
+		ok     bool
+	)
+
+	for _, b := range h {
+		if total, ok = addMul(total, b.Count, 1); !ok {
+			return nil, fmt.Errorf("%w: number of orders overflows", ErrInvalidHistogram)
+		}
+	}
+
+	for _, b := range h {
+		seen += b.Count
+
+		// The bucket crosses the next quantile. Seen orders never exceed the total, so the quotient fits.
+		hi, lo := bits.Mul(seen, maxCandidates)
+		if quantile, _ := bits.Div(hi, lo, total); uint(len(picked)) < quantile {
+			size, _ := roundUp(b.Items, step)
+			if len(picked) == 0 || picked[len(picked)-1] != size {
+				picked = append(picked, size)
+			}
+		}
+	}
+
+	if largest := sizes[len(sizes)-1]; picked[len(picked)-1] != largest {
+		picked = append(picked, largest)
+	}
+
+	return picked, nil
+}
+
+// roundUp returns items rounded up to a multiple of the step and whether it did not overflow.
+func roundUp(items, step uint) (uint, bool) {
+	r := items % step
+	if r == 0 {
+		return items, true
+	}
+
+	size, carry := bits.Add(items, step-r, 0)
+
+	return size, carry == 0
+}
+
+type search struct {
+	h     Histogram
+	opts  Options
+	mu    sync.Mutex
+	cache map[string]Evaluation
+}
+
+// best evaluates the sets concurrently and returns the best of them, in order of sets on ties.
+// With strict it returns a nil set if none is better than current.
+func (s *search) best(ctx context.Context, sets [][]uint, current Evaluation, strict bool) ([]uint, Evaluation, error) {
+	evals := make([]Evaluation, len(sets))
+	errs := make([]error, len(sets))
+
+	var wg sync.WaitGroup
+
+	jobs := make(chan int)
+
+	for range runtime.GOMAXPROCS(0) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				evals[i], errs[i] = s.evaluate(ctx, sets[i])
+			}
+		}()
+	}
+
+	for i := range sets {
+		jobs <- i
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	var (
+		set  []uint
+		best Evaluation
+	)
+
+	for i, e := range evals {
+		if errs[i] != nil {
+			return nil, Evaluation{}, errs[i]
+		}
+
+		if strict && !e.better(current, s.opts.Metric) {
+			continue
+		}
+
+		if set == nil || e.better(best, s.opts.Metric) {
+			set, best = sets[i], e
+		}
+	}
+
+	return set, best, nil
+}
+
+// evaluate returns the evaluation of box sizes, each set is evaluated once.
+func (s *search) evaluate(ctx context.Context, sizes []uint) (Evaluation, error) {
+	if err := ctx.Err(); err != nil {
+		return Evaluation{}, err
+	}
+
+	key := keyOf(sizes)
+
+	s.mu.Lock()
+	e, ok := s.cache[key]
+	s.mu.Unlock()
+
+	if ok {
+		return e, nil
+	}
+
+	boxes := make([]packer.Box, 0, len(sizes))
+
+	for _, size := range sizes {
+		boxes = append(boxes, s.opts.Box(size))
+	}
+
+	e, err := Evaluate(ctx, boxes, s.h, s.opts)
+	if err != nil {
+		return Evaluation{}, fmt.Errorf("failed to evaluate boxes %v: %w", sizes, err)
+	}
+
+	s.mu.Lock()
+	s.cache[key] = e
+	s.mu.Unlock()
+
+	return e, nil
+}
+
+func sorted(sizes []uint) []uint {
+	slices.Sort(sizes)
+
+	return sizes
+}
+
+func keyOf(sizes []uint) string {
+	parts := make([]string, 0, len(sizes))
+
+	for _, size := range sizes {
+		parts = append(parts, strconv.FormatUint(uint64(size), 10))
+	}
+
+	return strings.Join(parts, ",")
+}
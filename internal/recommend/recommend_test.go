@@ -0,0 +1,156 @@
+package recommend
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obalunenko/orderpacker/internal/packer"
+	"github.com/obalunenko/orderpacker/internal/testlogger"
+)
+
+func TestRecommend(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	tests := []struct {
+		name      string
+		h         Histogram
+		opts      Options
+		wantSizes []uint
+		check     func(t *testing.T, e Evaluation)
+		wantErr   assert.ErrorAssertionFunc
+	}{
+		{
+			name: "overshoot",
+			h:    Histogram{{Items: 100, Count: 3}, {Items: 230, Count: 5}, {Items: 470, Count: 2}},
+			opts: Options{Sizes: 2, Metric: MetricOvershoot, Step: 10},
+			// 470 items ship in 5x100 with overshoot 30.
+			wantSizes: []uint{100, 230},
+			check: func(t *testing.T, e Evaluation) {
+				assert.Equal(t, uint(10), e.Orders)
+				assert.Equal(t, uint(60), e.Overshoot)
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:      "step rounds sizes up",
+			h:         Histogram{{Items: 91, Count: 3}, {Items: 222, Count: 5}},
+			opts:      Options{Sizes: 2, Metric: MetricOvershoot, Step: 50},
+			wantSizes: []uint{100, 250},
+			wantErr:   assert.NoError,
+		},
+		{
+			name:      "cost of boxes",
+			h:         Histogram{{Items: 100, Count: 10}, {Items: 200, Count: 10}},
+			opts:      Options{Sizes: 1, Metric: MetricCost, Step: 1, BoxCost: 1},
+			wantSizes: []uint{200},
+			check: func(t *testing.T, e Evaluation) {
+				assert.InDelta(t, 20, e.Cost, packer.CostEpsilon)
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:      "cost with overshoot penalty",
+			h:         Histogram{{Items: 100, Count: 10}, {Items: 200, Count: 10}},
+			opts:      Options{Sizes: 1, Metric: MetricCost, Step: 1, BoxCost: 1, OvershootPenalty: 0.1},
+			wantSizes: []uint{100},
+			wantErr:   assert.NoError,
+		},
+		{
+			name:      "fewer candidates than sizes",
+			h:         Histogram{{Items: 100, Count: 1}},
+			opts:      Options{Sizes: 3, Metric: MetricOvershoot, Step: 1},
+			wantSizes: []uint{100},
+			wantErr:   assert.NoError,
+		},
+		{
+			name:    "too many sizes",
+			h:       Histogram{{Items: 100, Count: 1}},
+			opts:    Options{Sizes: MaxSizes + 1, Metric: MetricOvershoot, Step: 1},
+			wantErr: assert.Error,
+		},
+		{
+			name:    "unknown metric",
+			h:       Histogram{{Items: 100, Count: 1}},
+			opts:    Options{Sizes: 1, Metric: "volume", Step: 1},
+			wantErr: assert.Error,
+		},
+		{
+			name:    "size rounded up to step overflows",
+			h:       Histogram{{Items: math.MaxUint, Count: 1}},
+			opts:    Options{Sizes: 1, Metric: MetricOvershoot, Step: 2},
+			wantErr: assertErrorIs(ErrInvalidHistogram),
+		},
+		{
+			name:    "totals overflow",
+			h:       Histogram{{Items: 100, Count: math.MaxUint / 50}},
+			opts:    Options{Sizes: 1, Metric: MetricOvershoot, Step: 1},
+			wantErr: assertErrorIs(ErrInvalidHistogram),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Recommend(ctx, tt.h, tt.opts)
+			if !tt.wantErr(t, err) {
+				return
+			}
+
+			var sizes []uint
+
+			for _, b := range got.Boxes {
+				sizes = append(sizes, b.Size)
+			}
+
+			assert.Equal(t, tt.wantSizes, sizes)
+
+			if tt.check != nil {
+				tt.check(t, got)
+			}
+		})
+	}
+}
+
+func TestRecommend_notWorseThanCurrent(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	var h Histogram
+
+	for items := uint(1); items <= 3000; items += 37 {
+		h = append(h, Bucket{Items: items, Count: items%7 + 1})
+	}
+
+	opts := Options{Sizes: 5, Metric: MetricOvershoot, Step: 50}
+
+	current, err := Evaluate(ctx, packer.SizedBoxes(packer.DefaultBoxes), h, opts)
+	require.NoError(t, err)
+
+	got, err := Recommend(ctx, h, opts)
+	require.NoError(t, err)
+
+	assert.Len(t, got.Boxes, 5)
+	assert.Equal(t, current.Orders, got.Orders)
+	assert.Equal(t, current.Items, got.Items)
+	assert.Less(t, got.Overshoot, current.Overshoot)
+}
+
+func TestEvaluate(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	h := Histogram{{Items: 1, Count: 2}, {Items: 251, Count: 1}, {Items: 12001, Count: 1}}
+
+	got, err := Evaluate(ctx, packer.SizedBoxes(packer.DefaultBoxes), h, Options{Metric: MetricOvershoot})
+	require.NoError(t, err)
+
+	assert.Equal(t, Evaluation{
+		Boxes:     packer.SizedBoxes(packer.DefaultBoxes),
+		Orders:    4,
+		Items:     2 + 251 + 12001,
+		Shipped:   2*250 + 500 + 12250,
+		Overshoot: 2*249 + 249 + 249,
+		Packs:     2 + 1 + 4,
+	}, got)
+}
@@ -1,6 +1,14 @@
 package service
 
-import "sort"
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/obalunenko/orderpacker/internal/packer"
+	"github.com/obalunenko/orderpacker/internal/packer/geometry"
+)
 
 func fromAPIRequest(req PackRequest) (uint, error) {
 	if req.Items == 0 {
@@ -10,24 +18,448 @@ func fromAPIRequest(req PackRequest) (uint, error) {
 	return req.Items, nil
 }
 
-func toAPIResponse(boxes []uint) PackResponse {
-	var resp PackResponse
+func fromAPIAlternativesRequest(req PackRequest) (uint, error) {
+	if len(req.Lines) != 0 {
+		return 0, ErrLinesNotSupported
+	}
+
+	if req.Commit {
+		return 0, errors.New("alternatives are never committed")
+	}
+
+	if req.Policy != "" {
+		return 0, errors.New("alternatives ship the whole order")
+	}
+
+	if req.Shipment != nil {
+		return 0, errors.New("alternatives are not split into shipments")
+	}
+
+	if req.ExactFit != nil {
+		return 0, errors.New("alternatives are not restricted to exact fits")
+	}
+
+	return fromAPIRequest(req)
+}
+
+func fromAPINestedRequest(req PackRequest) (uint, error) {
+	if len(req.Lines) != 0 {
+		return 0, ErrLinesNotSupported
+	}
+
+	return fromAPIRequest(req)
+}
+
+func fromAPILines(req PackRequest) ([]packer.LineItem, error) {
+	if req.Items != 0 {
+		return nil, errors.New("items and lines are mutually exclusive")
+	}
+
+	if req.UnitWeight != 0 || req.UnitVolume != 0 {
+		return nil, errors.New("unit weight and volume are set per line item")
+	}
+
+	lines := make([]packer.LineItem, 0, len(req.Lines))
+
+	for _, l := range req.Lines {
+		if l.SKU == "" {
+			return nil, ErrEmptySKU
+		}
+
+		if l.Quantity == 0 {
+			return nil, fmt.Errorf("%w: sku %q", ErrEmptyItems, l.SKU)
+		}
+
+		lines = append(lines, packer.LineItem{
+			SKU:      l.SKU,
+			Quantity: l.Quantity,
+			Unit: packer.Unit{
+				Weight: l.UnitWeight,
+				Volume: l.UnitVolume,
+			},
+		})
+	}
+
+	return lines, nil
+}
+
+func toAPIResponse(res packer.PackResult) PackResponse {
+	return PackResponse{
+		Packs:       toAPIPacks(res.Packs),
+		Items:       res.Items,
+		Shipped:     res.Shipped,
+		Overshoot:   res.Overshoot,
+		Backordered: res.Backordered,
+		PackCount:   res.PackCount,
+		Cost:        res.Cost,
+		Strategy:    res.Strategy,
+		Interrupted: res.Interrupted,
+		Shipments:   toAPIShipments(res.Shipments),
+		Explanation: toAPIExplanation(res.Explanation),
+	}
+}
+
+func fromAPIShipmentLimits(l ShipmentLimits) (packer.ShipmentLimits, error) {
+	if l.MaxWeight < 0 {
+		return packer.ShipmentLimits{}, fmt.Errorf("negative shipment max weight %v", l.MaxWeight)
+	}
+
+	return packer.ShipmentLimits{
+		MaxPacks:  l.MaxPacks,
+		MaxItems:  l.MaxItems,
+		MaxWeight: l.MaxWeight,
+	}, nil
+}
+
+func toAPIShipments(shipments []packer.Shipment) []Shipment {
+	if len(shipments) == 0 {
+		return nil
+	}
+
+	resp := make([]Shipment, 0, len(shipments))
+
+	for _, s := range shipments {
+		resp = append(resp, Shipment{
+			Quantity:  s.Quantity,
+			Packs:     toAPIPacks(s.Packs),
+			Items:     s.Items,
+			PackCount: s.PackCount,
+			Weight:    s.Weight,
+		})
+	}
+
+	return resp
+}
+
+func toAPIExplanation(e *packer.Explanation) *Explanation {
+	if e == nil {
+		return nil
+	}
+
+	resp := Explanation{
+		Objective:  string(e.Objective),
+		Strategy:   e.Strategy,
+		Considered: e.Considered,
+		Candidates: make([]Candidate, 0, len(e.Candidates)),
+		Decision:   e.Decision,
+	}
+
+	for _, c := range e.Candidates {
+		resp.Candidates = append(resp.Candidates, Candidate{
+			Packs:     toAPIPacks(c.Packs),
+			Shipped:   c.Shipped,
+			Overshoot: c.Overshoot,
+			PackCount: c.PackCount,
+			Cost:      c.Cost,
+			Chosen:    c.Chosen,
+			Reason:    c.Reason,
+		})
+	}
+
+	return &resp
+}
+
+func toAPIAlternatives(results []packer.PackResult) AlternativesResponse {
+	resp := AlternativesResponse{
+		Alternatives: make([]PackResponse, 0, len(results)),
+	}
+
+	for _, res := range results {
+		resp.Alternatives = append(resp.Alternatives, toAPIResponse(res))
+	}
+
+	return resp
+}
+
+func toAPIOrderResponse(res packer.OrderResult) PackResponse {
+	resp := PackResponse{
+		Lines:       make([]LinePacks, 0, len(res.Lines)),
+		Items:       res.Items,
+		Shipped:     res.Shipped,
+		Overshoot:   res.Overshoot,
+		Backordered: res.Backordered,
+		PackCount:   res.PackCount,
+		Cost:        res.Cost,
+	}
+
+	for _, l := range res.Lines {
+		resp.Lines = append(resp.Lines, LinePacks{
+			SKU:         l.SKU,
+			Family:      l.Family,
+			Packs:       toAPIPacks(l.Packs),
+			Items:       l.Items,
+			Shipped:     l.Shipped,
+			Overshoot:   l.Overshoot,
+			Backordered: l.Backordered,
+			PackCount:   l.PackCount,
+			Cost:        l.Cost,
+			Strategy:    l.Strategy,
+			Shipments:   toAPIShipments(l.Shipments),
+			Explanation: toAPIExplanation(l.Explanation),
+		})
+	}
+
+	return resp
+}
+
+func toAPINestedResponse(res packer.NestedResult) NestedPackResponse {
+	resp := NestedPackResponse{
+		Items:  res.Items,
+		Levels: make([]LevelPacks, 0, len(res.Levels)),
+		Packs:  toAPINestedPacks(res.Packs),
+	}
+
+	for _, l := range res.Levels {
+		resp.Levels = append(resp.Levels, LevelPacks{
+			Level:        l.Level,
+			PackResponse: toAPIResponse(l.PackResult),
+		})
+	}
+
+	return resp
+}
+
+func toAPINestedPacks(packs []packer.NestedPack) []NestedPack {
+	if len(packs) == 0 {
+		return nil
+	}
+
+	resp := make([]NestedPack, 0, len(packs))
+
+	for _, np := range packs {
+		resp = append(resp, NestedPack{
+			Level:    np.Level,
+			Box:      np.Box,
+			Quantity: np.Quantity,
+			Contents: toAPINestedPacks(np.Contents),
+		})
+	}
+
+	return resp
+}
+
+func toAPIMixedResponse(res packer.MixedResult) MixedPackResponse {
+	resp := MixedPackResponse{
+		Boxes:     make([]MixedBox, 0, len(res.Boxes)),
+		Items:     res.Items,
+		PackCount: res.PackCount,
+		Cost:      res.Cost,
+	}
 
-	orderMap := make(map[uint]uint)
-	for i := range boxes {
-		orderMap[boxes[i]]++
+	for _, b := range res.Boxes {
+		box := MixedBox{
+			Box:      b.Box,
+			Contents: make([]LineQuantity, 0, len(b.Contents)),
+			Fill:     toAPIFill(b.Fill),
+		}
+
+		for _, c := range b.Contents {
+			box.Contents = append(box.Contents, LineQuantity{
+				SKU:      c.SKU,
+				Quantity: c.Quantity,
+			})
+		}
+
+		resp.Boxes = append(resp.Boxes, box)
+	}
+
+	return resp
+}
+
+func toAPIFill(f packer.Fill) PackFill {
+	return PackFill{
+		Items:       f.Items,
+		Weight:      f.Weight,
+		Volume:      f.Volume,
+		WeightRatio: f.WeightRatio,
+		VolumeRatio: f.VolumeRatio,
 	}
+}
+
+func toAPIPacks(packs []packer.BoxQuantity) []Pack {
+	var res []Pack
+
+	for _, p := range packs {
+		pack := Pack{
+			Box:      p.Box,
+			Quantity: p.Quantity,
+		}
 
-	for k, v := range orderMap {
-		resp.Packs = append(resp.Packs, Pack{
-			Box:      k,
-			Quantity: v,
+		if p.Fill != nil {
+			fill := toAPIFill(*p.Fill)
+			pack.Fill = &fill
+		}
+
+		res = append(res, pack)
+	}
+
+	return res
+}
+
+func fromAPIGeometryRequest(req GeometryPackRequest) ([]geometry.Item, error) {
+	if len(req.Items) == 0 {
+		return nil, ErrEmptyItems
+	}
+
+	items := make([]geometry.Item, 0, len(req.Items))
+
+	for _, it := range req.Items {
+		if it.Quantity == 0 {
+			return nil, fmt.Errorf("%w: item %q", ErrEmptyItems, it.ID)
+		}
+
+		items = append(items, geometry.Item{
+			ID: it.ID,
+			Dimensions: geometry.Dimensions{
+				Length: it.Length,
+				Width:  it.Width,
+				Height: it.Height,
+			},
+			Quantity: it.Quantity,
+			Rotation: geometry.Rotation(it.Rotation),
 		})
 	}
 
-	sort.Slice(resp.Packs, func(i, j int) bool {
-		return resp.Packs[i].Box > resp.Packs[j].Box
+	return items, nil
+}
+
+func toAPIGeometryResponse(res geometry.Result) GeometryPackResponse {
+	resp := GeometryPackResponse{
+		Boxes:     make([]GeometryBox, 0, len(res.Boxes)),
+		Units:     res.Units,
+		PackCount: uint(len(res.Boxes)),
+	}
+
+	for _, b := range res.Boxes {
+		box := GeometryBox{
+			Box:        b.Box.Name,
+			Length:     b.Box.Length,
+			Width:      b.Box.Width,
+			Height:     b.Box.Height,
+			Placements: make([]Placement, 0, len(b.Placements)),
+			Fill:       b.Fill,
+		}
+
+		for _, pl := range b.Placements {
+			box.Placements = append(box.Placements, Placement{
+				Item:   pl.Item,
+				X:      pl.Position.X,
+				Y:      pl.Position.Y,
+				Z:      pl.Position.Z,
+				Length: pl.Dimensions.Length,
+				Width:  pl.Dimensions.Width,
+				Height: pl.Dimensions.Height,
+			})
+		}
+
+		resp.Boxes = append(resp.Boxes, box)
+	}
+
+	return resp
+}
+
+func toAPIBoxSet(boxes, previous []packer.Box) BoxSet {
+	return BoxSet{
+		Boxes:    toAPIBoxes(boxes),
+		Previous: toAPIBoxes(previous),
+	}
+}
+
+func toAPIBoxes(boxes []packer.Box) []BoxDefinition {
+	if boxes == nil {
+		return nil
+	}
+
+	defs := make([]BoxDefinition, 0, len(boxes))
+
+	for _, b := range boxes {
+		defs = append(defs, BoxDefinition{
+			Size:        b.Size,
+			Cost:        b.Cost,
+			MaxWeight:   b.MaxWeight,
+			MaxVolume:   b.MaxVolume,
+			MinQuantity: b.MinQuantity,
+			MaxQuantity: b.MaxQuantity,
+			MinOrder:    b.MinOrder,
+			MaxOrder:    b.MaxOrder,
+		})
+	}
+
+	return defs
+}
+
+func fromAPIBoxSet(set BoxSet) []packer.Box {
+	boxes := make([]packer.Box, 0, len(set.Boxes))
+
+	for _, d := range set.Boxes {
+		boxes = append(boxes, packer.Box{
+			Size:        d.Size,
+			Cost:        d.Cost,
+			MaxWeight:   d.MaxWeight,
+			MaxVolume:   d.MaxVolume,
+			MinQuantity: d.MinQuantity,
+			MaxQuantity: d.MaxQuantity,
+			MinOrder:    d.MinOrder,
+			MaxOrder:    d.MaxOrder,
+		})
+	}
+
+	return boxes
+}
+
+func toAPIInventory(stock map[uint]uint) Inventory {
+	inv := Inventory{
+		Stock: make([]StockLevel, 0, len(stock)),
+	}
+
+	for box, qty := range stock {
+		inv.Stock = append(inv.Stock, StockLevel{
+			Box:      box,
+			Quantity: qty,
+		})
+	}
+
+	slices.SortFunc(inv.Stock, func(a, b StockLevel) int {
+		return cmp.Compare(b.Box, a.Box)
 	})
 
+	return inv
+}
+
+func fromAPIInventory(inv Inventory) (map[uint]uint, error) {
+	stock := make(map[uint]uint, len(inv.Stock))
+
+	for _, l := range inv.Stock {
+		if l.Box == 0 {
+			return nil, errors.New("box with zero volume")
+		}
+
+		if _, exist := stock[l.Box]; exist {
+			return nil, fmt.Errorf("duplicated stock of box %d", l.Box)
+		}
+
+		stock[l.Box] = l.Quantity
+	}
+
+	return stock, nil
+}
+
+func toAPIAnalysis(a packer.Analysis) BoxAnalysis {
+	resp := BoxAnalysis{
+		Sizes:     a.Sizes,
+		GCD:       a.GCD,
+		Frobenius: a.Frobenius,
+		Dominated: a.Dominated,
+		Warnings:  a.Warnings,
+	}
+
+	if resp.Dominated == nil {
+		resp.Dominated = []uint{}
+	}
+
+	if resp.Warnings == nil {
+		resp.Warnings = []string{}
+	}
+
 	return resp
 }
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -8,17 +9,41 @@ import (
 	"html/template"
 	"io"
 	"net/http"
+	"slices"
+	"strconv"
 
 	log "github.com/obalunenko/logger"
 
+	"github.com/obalunenko/orderpacker/internal/chart"
+	"github.com/obalunenko/orderpacker/internal/inventory"
 	"github.com/obalunenko/orderpacker/internal/packer"
+	"github.com/obalunenko/orderpacker/internal/packer/geometry"
 	"github.com/obalunenko/orderpacker/internal/service/assets"
 )
 
-// ErrEmptyItems is returned when items is zero or empty.
-var ErrEmptyItems = errors.New("empty items")
+var (
+	// ErrEmptyItems is returned when items is zero or empty.
+	ErrEmptyItems = errors.New("empty items")
+	// ErrEmptySKU is returned when a line item has no sku.
+	ErrEmptySKU = errors.New("empty sku")
+	// ErrLinesNotSupported is returned for line items when the service has no catalog of products.
+	ErrLinesNotSupported = errors.New("line items are not supported")
+)
+
+// profileHeader selects the box profile of api/v1 requests that have no profile in the path.
+const profileHeader = "X-Box-Profile"
 
-func NewRouter(p *packer.Packer) *http.ServeMux {
+// NewRouter returns router of the service. Orders are packed by the packer of the box profile the request selects
+// by the path, e.g. api/v1/profiles/east/pack, or the X-Box-Profile header, the default one if it selects none;
+// api/v1 endpoints are served when ps is not nil. Orders of several products are packed when c is not nil,
+// in the boxes of their families by the default profile and in the boxes of the selected one by the others,
+// 3D packing of api/v2 is served when g is not nil. Inventory endpoints are served when invs is not nil,
+// each profile with the inventory of its name in invs.
+// Endpoints changing the box set or the stock take adminToken as a bearer token and answer no CORS requests,
+// they are refused when adminToken is empty.
+func NewRouter(ps *packer.Profiles, c *packer.Catalog, g *geometry.Packer, invs map[string]*inventory.Inventory,
+	adminToken string,
+) *http.ServeMux {
 	mux := http.NewServeMux()
 
 	mw := []func(http.Handler) http.Handler{
@@ -27,10 +52,9 @@ func NewRouter(p *packer.Packer) *http.ServeMux {
 		requestIDMiddleware,
 		recoverMiddleware,
 		loggerMiddleware,
-		corsMiddleware,
 	}
 
-	mwApply := func(h http.Handler) http.Handler {
+	chain := func(h http.Handler, mw []func(http.Handler) http.Handler) http.Handler {
 		for i := range mw {
 			h = mw[i](h)
 		}
@@ -38,15 +62,140 @@ func NewRouter(p *packer.Packer) *http.ServeMux {
 		return h
 	}
 
+	public := slices.Concat(mw, []func(http.Handler) http.Handler{corsMiddleware})
+	admin := slices.Concat([]func(http.Handler) http.Handler{adminMiddleware(adminToken)}, mw)
+
+	mwApply := func(h http.Handler) http.Handler {
+		return chain(h, public)
+	}
+
 	mux.Handle("/", mwApply(indexHandler()))
 	mux.Handle("/favicon.ico", mwApply(faviconHandler()))
 
 	// Group api/v1 routes.
-	mux.Handle("/api/v1/pack", mwApply(packHandler(p)))
+	if ps != nil {
+		type route struct {
+			// method restricts the route to requests of the method, any method when empty.
+			method  string
+			path    string
+			admin   bool
+			handler func(name string, p *packer.Packer) http.Handler
+		}
+
+		routes := []route{
+			{path: "/pack", handler: func(name string, p *packer.Packer) http.Handler { return packHandler(p, c.Profile(name, p)) }},
+			{path: "/pack/mixed", handler: func(_ string, p *packer.Packer) http.Handler { return mixedPackHandler(p) }},
+			{path: "/pack/nested", handler: func(_ string, p *packer.Packer) http.Handler { return nestedPackHandler(p) }},
+			{path: "/pack/alternatives", handler: func(_ string, p *packer.Packer) http.Handler { return alternativesHandler(p) }},
+			{path: "/pack/table", handler: func(_ string, p *packer.Packer) http.Handler { return tableHandler(p) }},
+			{path: "/boxes", handler: func(_ string, p *packer.Packer) http.Handler { return getBoxesHandler(p) }},
+			{method: http.MethodPut, path: "/boxes", admin: true, handler: func(_ string, p *packer.Packer) http.Handler { return putBoxesHandler(p) }},
+			{path: "/boxes/rollback", admin: true, handler: func(_ string, p *packer.Packer) http.Handler { return rollbackBoxesHandler(p) }},
+			{path: "/boxes/analysis", handler: func(_ string, p *packer.Packer) http.Handler { return analysisHandler(p) }},
+		}
+
+		if invs != nil {
+			routes = append(routes,
+				route{path: "/inventory", handler: func(name string, _ *packer.Packer) http.Handler {
+					return inventoryHandler(name, invs[name], getInventoryHandler)
+				}},
+				route{method: http.MethodPut, path: "/inventory", admin: true, handler: func(name string, _ *packer.Packer) http.Handler {
+					return inventoryHandler(name, invs[name], putInventoryHandler)
+				}},
+			)
+		}
+
+		for _, rt := range routes {
+			mw := public
+			if rt.admin {
+				mw = admin
+			}
+
+			h := chain(profileHandler(ps, rt.handler), mw)
+
+			method := ""
+			if rt.method != "" {
+				method = rt.method + " "
+			}
+
+			mux.Handle(method+"/api/v1"+rt.path, h)
+			mux.Handle(method+"/api/v1/profiles/{name}"+rt.path, h)
+		}
+
+		mux.Handle("/api/v1/profiles", mwApply(profilesHandler(ps)))
+	}
+
+	// Group api/v2 routes.
+	if g != nil {
+		mux.Handle("/api/v2/pack", mwApply(geometryPackHandler(g)))
+	}
 
 	return mux
 }
 
+// profileHandler serves requests with the handler of the box profile they select.
+func profileHandler(ps *packer.Profiles, newHandler func(name string, p *packer.Packer) http.Handler) http.HandlerFunc {
+	handlers := make(map[string]http.Handler)
+
+	for name, p := range ps.All() {
+		handlers[name] = newHandler(name, p)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if name == "" {
+			name = r.Header.Get(profileHeader)
+		}
+
+		if name == "" {
+			name = packer.DefaultProfile
+		}
+
+		h, ok := handlers[name]
+		if !ok {
+			makeResponse(
+				r.Context(),
+				w,
+				http.StatusNotFound,
+				nil,
+				fmt.Errorf("%w %q, available: %v", packer.ErrUnknownProfile, name, ps.Names()),
+			)
+
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	}
+}
+
+// profilesHandler - handler for /profiles endpoint.
+//
+//	@Summary		Get box profiles
+//	@Tags			profiles
+//	@Description	Returns names of box profiles requests select by the api/v1/profiles/{name} path or the X-Box-Profile header
+//	@ID				orderpacker-profiles	get
+//	@Produce		json
+//	@Success		200	{object}	ProfilesResponse		"Box profiles"
+//	@Failure		405	{object}	methodNotAllowedError	"Method not allowed"
+//	@Router			/api/v1/profiles [get]
+func profilesHandler(ps *packer.Profiles) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			makeResponse(
+				r.Context(),
+				w,
+				http.StatusMethodNotAllowed,
+				ProfilesResponse{},
+				errors.New(http.StatusText(http.StatusMethodNotAllowed)),
+			)
+
+			return
+		}
+
+		makeResponse(r.Context(), w, http.StatusOK, ProfilesResponse{Profiles: ps.Names(), Default: packer.DefaultProfile}, nil)
+	}
+}
+
 func indexHandler() http.HandlerFunc {
 	homePageHTML := string(assets.MustLoad("index.gohtml"))
 	homePageTmpl := template.Must(template.New("index").Parse(homePageHTML))
@@ -72,17 +221,27 @@ func faviconHandler() http.HandlerFunc {
 //
 //	@Summary		Get the number of packs needed to ship to a customer
 //	@Tags			pack
-//	@Description	Calculates the number of packs needed to ship to a customer
+//	@Description	Calculates the number of packs needed to ship to a customer.
+//	@Description	An order of several products is packed per line item in boxes of the product family, or of the selected box profile when it is not the default one.
+//	@Description	With unit weight or volume boxes hold no more items than their limits allow.
+//	@Description	The policy down ships only full boxes and backorders the rest, nearest ships whichever is closer to the order.
+//	@Description	With shipment limits, requested or configured, packs are split into the fewest shipments balanced by load
 //	@ID				orderpacker-pack	post
 //	@Accept			json
 //	@Produce		json
-//	@Param			data	body		PackRequest				true	"Request data"
-//	@Success		200		{object}	PackResponse			"Successful response with packs data"
-//	@Failure		400		{object}	badRequestError			"Invalid request data
-//	@Failure		405		{object}	methodNotAllowedError	"Method not allowed"
-//	@Failure		500		{object}	internalServerError		"Internal server error"
+//	@Param			data			body		PackRequest					true	"Request data"
+//	@Param			explain			query		bool						false	"Explain why the packing was chosen"
+//	@Param			X-Box-Profile	header		string						false	"Box profile, the default one if not set"
+//	@Success		200				{object}	PackResponse				"Successful response with packs data"
+//	@Failure		400				{object}	badRequestError				"Invalid request data"
+//	@Failure		405				{object}	methodNotAllowedError		"Method not allowed"
+//	@Failure		404				{object}	notFoundError				"Unknown box profile"
+//	@Failure		409				{object}	conflictError				"Not enough boxes in stock"
+//	@Failure		422				{object}	unprocessableEntityError	"No exact fit of the order or no packing within fill limits"
+//	@Failure		500				{object}	internalServerError			"Internal server error"
+//	@Failure		503				{object}	serviceUnavailableError		"Solving ran out of time"
 //	@Router			/api/v1/pack [post]
-func packHandler(p *packer.Packer) http.HandlerFunc {
+func packHandler(p *packer.Packer, c *packer.Catalog) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			makeResponse(
@@ -123,22 +282,58 @@ func packHandler(p *packer.Packer) http.HandlerFunc {
 			return
 		}
 
-		items, err := fromAPIRequest(req)
+		var opts []packer.OrderOption
+
+		if req.Commit {
+			opts = append(opts, packer.WithCommit())
+		}
+
+		if req.Policy != "" {
+			opts = append(opts, packer.WithPolicy(packer.Policy(req.Policy)))
+		}
+
+		if req.Shipment != nil {
+			limits, err := fromAPIShipmentLimits(*req.Shipment)
+			if err != nil {
+				makeResponse(r.Context(), w, http.StatusBadRequest, PackResponse{}, fmt.Errorf("invalid request: %w", err))
+
+				return
+			}
+
+			opts = append(opts, packer.WithShipments(limits))
+		}
+
+		if req.ExactFit != nil {
+			opts = append(opts, packer.WithExactFit(*req.ExactFit))
+		}
+
+		explain, err := queryBool(r, "explain")
 		if err != nil {
-			makeResponse(
-				r.Context(),
-				w,
-				http.StatusBadRequest,
-				PackResponse{},
-				fmt.Errorf("invalid request: %w", err),
-			)
+			makeResponse(r.Context(), w, http.StatusBadRequest, PackResponse{}, fmt.Errorf("invalid request: %w", err))
 
 			return
 		}
 
-		order := p.PackOrder(r.Context(), items)
+		if explain {
+			opts = append(opts, packer.WithExplain())
+		}
 
-		resp := toAPIResponse(order)
+		var (
+			resp PackResponse
+			code int
+		)
+
+		if len(req.Lines) != 0 {
+			resp, code, err = packLines(r.Context(), c, req, opts)
+		} else {
+			resp, code, err = packItems(r.Context(), p, req, opts)
+		}
+
+		if err != nil {
+			makeResponse(r.Context(), w, code, PackResponse{}, err)
+
+			return
+		}
 
 		b, err = json.Marshal(resp)
 		if err != nil {
@@ -159,7 +354,733 @@ func packHandler(p *packer.Packer) http.HandlerFunc {
 	}
 }
 
-func makeResponse(ctx context.Context, w http.ResponseWriter, code int, resp PackResponse, err error) {
+// queryBool returns boolean query parameter, false when it is not set.
+func queryBool(r *http.Request, key string) (bool, error) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s query parameter %q", key, v)
+	}
+
+	return b, nil
+}
+
+func queryUint(r *http.Request, key string, dflt uint) (uint, error) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return dflt, nil
+	}
+
+	n, err := strconv.ParseUint(v, 10, 0)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s query parameter %q", key, v)
+	}
+
+	return uint(n), nil
+}
+
+// packItems packs an order of items of the default box set.
+func packItems(ctx context.Context, p *packer.Packer, req PackRequest, opts []packer.OrderOption) (PackResponse, int, error) {
+	items, err := fromAPIRequest(req)
+	if err != nil {
+		return PackResponse{}, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err)
+	}
+
+	if req.UnitWeight != 0 || req.UnitVolume != 0 {
+		opts = append(opts, packer.WithUnit(packer.Unit{
+			Weight: req.UnitWeight,
+			Volume: req.UnitVolume,
+		}))
+	}
+
+	res, err := p.PackOrder(ctx, items, opts...)
+	if err != nil {
+		return PackResponse{}, packErrorCode(err), fmt.Errorf("failed to pack order: %w", err)
+	}
+
+	return toAPIResponse(res), http.StatusOK, nil
+}
+
+// packLines packs an order of several products.
+func packLines(ctx context.Context, c *packer.Catalog, req PackRequest, opts []packer.OrderOption) (PackResponse, int, error) {
+	if c == nil {
+		return PackResponse{}, http.StatusBadRequest, fmt.Errorf("invalid request: %w", ErrLinesNotSupported)
+	}
+
+	lines, err := fromAPILines(req)
+	if err != nil {
+		return PackResponse{}, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err)
+	}
+
+	res, err := c.PackLines(ctx, lines, opts...)
+	if err != nil {
+		return PackResponse{}, packErrorCode(err), fmt.Errorf("failed to pack order: %w", err)
+	}
+
+	return toAPIOrderResponse(res), http.StatusOK, nil
+}
+
+// defaultAlternatives is the number of alternatives returned when the request does not set it.
+const defaultAlternatives = 5
+
+// alternativesHandler - handler for /pack/alternatives endpoint.
+//
+//	@Summary		Get the best distinct packings of an order
+//	@Tags			pack
+//	@Description	Returns up to n best distinct packings ranked by the objective, the best first, so that an operator can pick a near-optimal one
+//	@ID				orderpacker-pack-alternatives	post
+//	@Accept			json
+//	@Produce		json
+//	@Param			data	body		PackRequest				true	"Request data"
+//	@Param			n		query		int						false	"Number of alternatives, 5 by default, at most 20"
+//	@Success		200		{object}	AlternativesResponse	"Successful response with alternatives"
+//	@Failure		400		{object}	badRequestError			"Invalid request data"
+//	@Failure		405		{object}	methodNotAllowedError	"Method not allowed"
+//	@Failure		409		{object}	conflictError			"Not enough boxes in stock"
+//	@Failure		500		{object}	internalServerError		"Internal server error"
+//	@Router			/api/v1/pack/alternatives [post]
+func alternativesHandler(p *packer.Packer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			makeResponse(
+				r.Context(),
+				w,
+				http.StatusMethodNotAllowed,
+				AlternativesResponse{},
+				errors.New(http.StatusText(http.StatusMethodNotAllowed)),
+			)
+
+			return
+		}
+
+		n := defaultAlternatives
+
+		if v := r.URL.Query().Get("n"); v != "" {
+			var err error
+
+			n, err = strconv.Atoi(v)
+			if err != nil || n < 1 || n > packer.MaxAlternatives {
+				makeResponse(
+					r.Context(),
+					w,
+					http.StatusBadRequest,
+					AlternativesResponse{},
+					fmt.Errorf("invalid request: n query parameter %q must be from 1 to %d", v, packer.MaxAlternatives),
+				)
+
+				return
+			}
+		}
+
+		var req PackRequest
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			makeResponse(r.Context(), w, http.StatusBadRequest, AlternativesResponse{}, fmt.Errorf("failed to unmarshal request: %w", err))
+
+			return
+		}
+
+		items, err := fromAPIAlternativesRequest(req)
+		if err != nil {
+			makeResponse(r.Context(), w, http.StatusBadRequest, AlternativesResponse{}, fmt.Errorf("invalid request: %w", err))
+
+			return
+		}
+
+		var opts []packer.OrderOption
+
+		if req.UnitWeight != 0 || req.UnitVolume != 0 {
+			opts = append(opts, packer.WithUnit(packer.Unit{
+				Weight: req.UnitWeight,
+				Volume: req.UnitVolume,
+			}))
+		}
+
+		res, err := p.Alternatives(r.Context(), items, n, opts...)
+		if err != nil {
+			makeResponse(r.Context(), w, packErrorCode(err), AlternativesResponse{}, fmt.Errorf("failed to pack order: %w", err))
+
+			return
+		}
+
+		makeResponse(r.Context(), w, http.StatusOK, toAPIAlternatives(res), nil)
+	}
+}
+
+// mixedPackHandler - handler for /pack/mixed endpoint.
+//
+//	@Summary		Pack items of several products into shared boxes
+//	@Tags			pack
+//	@Description	Consolidates line items into shared boxes of the default box set and returns contents of each box
+//	@ID				orderpacker-pack-mixed	post
+//	@Accept			json
+//	@Produce		json
+//	@Param			data	body		PackRequest				true	"Line items"
+//	@Success		200		{object}	MixedPackResponse		"Successful response with boxes contents"
+//	@Failure		400		{object}	badRequestError			"Invalid request data"
+//	@Failure		405		{object}	methodNotAllowedError	"Method not allowed"
+//	@Failure		409		{object}	conflictError			"Not enough boxes in stock"
+//	@Failure		500		{object}	internalServerError		"Internal server error"
+//	@Router			/api/v1/pack/mixed [post]
+func mixedPackHandler(p *packer.Packer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			makeResponse(
+				r.Context(),
+				w,
+				http.StatusMethodNotAllowed,
+				MixedPackResponse{},
+				errors.New(http.StatusText(http.StatusMethodNotAllowed)),
+			)
+
+			return
+		}
+
+		var req PackRequest
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			makeResponse(r.Context(), w, http.StatusBadRequest, MixedPackResponse{}, fmt.Errorf("failed to unmarshal request: %w", err))
+
+			return
+		}
+
+		if len(req.Lines) == 0 {
+			makeResponse(r.Context(), w, http.StatusBadRequest, MixedPackResponse{}, fmt.Errorf("invalid request: %w", ErrEmptyItems))
+
+			return
+		}
+
+		lines, err := fromAPILines(req)
+		if err != nil {
+			makeResponse(r.Context(), w, http.StatusBadRequest, MixedPackResponse{}, fmt.Errorf("invalid request: %w", err))
+
+			return
+		}
+
+		var opts []packer.OrderOption
+
+		if req.Commit {
+			opts = append(opts, packer.WithCommit())
+		}
+
+		res, err := p.PackMixed(r.Context(), lines, opts...)
+		if err != nil {
+			makeResponse(r.Context(), w, packErrorCode(err), MixedPackResponse{}, fmt.Errorf("failed to pack order: %w", err))
+
+			return
+		}
+
+		makeResponse(r.Context(), w, http.StatusOK, toAPIMixedResponse(res), nil)
+	}
+}
+
+// nestedPackHandler - handler for /pack/nested endpoint.
+//
+//	@Summary		Pack an order in nested packaging levels
+//	@Tags			pack
+//	@Description	Packs items in boxes, boxes in packs of the next packaging level and so on, e.g. cartons and pallets.
+//	@Description	Returns packings of each level and the tree of the outermost packs with their contents
+//	@ID				orderpacker-pack-nested	post
+//	@Accept			json
+//	@Produce		json
+//	@Param			data			body		PackRequest					true	"Request data"
+//	@Param			X-Box-Profile	header		string						false	"Box profile, the default one if not set"
+//	@Success		200				{object}	NestedPackResponse			"Successful response with packs of each level"
+//	@Failure		400				{object}	badRequestError				"Invalid request data"
+//	@Failure		404				{object}	notFoundError				"Unknown box profile or no packaging levels"
+//	@Failure		405				{object}	methodNotAllowedError		"Method not allowed"
+//	@Failure		409				{object}	conflictError				"Not enough boxes in stock"
+//	@Failure		422				{object}	unprocessableEntityError	"No exact fit of the order or no packing within fill limits"
+//	@Failure		500				{object}	internalServerError			"Internal server error"
+//	@Failure		503				{object}	serviceUnavailableError		"Solving ran out of time"
+//	@Router			/api/v1/pack/nested [post]
+func nestedPackHandler(p *packer.Packer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			makeResponse(
+				r.Context(),
+				w,
+				http.StatusMethodNotAllowed,
+				NestedPackResponse{},
+				errors.New(http.StatusText(http.StatusMethodNotAllowed)),
+			)
+
+			return
+		}
+
+		var req PackRequest
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			makeResponse(r.Context(), w, http.StatusBadRequest, NestedPackResponse{}, fmt.Errorf("failed to unmarshal request: %w", err))
+
+			return
+		}
+
+		items, err := fromAPINestedRequest(req)
+		if err != nil {
+			makeResponse(r.Context(), w, http.StatusBadRequest, NestedPackResponse{}, fmt.Errorf("invalid request: %w", err))
+
+			return
+		}
+
+		var opts []packer.OrderOption
+
+		if req.Commit {
+			opts = append(opts, packer.WithCommit())
+		}
+
+		if req.Policy != "" {
+			opts = append(opts, packer.WithPolicy(packer.Policy(req.Policy)))
+		}
+
+		if req.Shipment != nil {
+			limits, err := fromAPIShipmentLimits(*req.Shipment)
+			if err != nil {
+				makeResponse(r.Context(), w, http.StatusBadRequest, NestedPackResponse{}, fmt.Errorf("invalid request: %w", err))
+
+				return
+			}
+
+			opts = append(opts, packer.WithShipments(limits))
+		}
+
+		if req.ExactFit != nil {
+			opts = append(opts, packer.WithExactFit(*req.ExactFit))
+		}
+
+		if req.UnitWeight != 0 || req.UnitVolume != 0 {
+			opts = append(opts, packer.WithUnit(packer.Unit{
+				Weight: req.UnitWeight,
+				Volume: req.UnitVolume,
+			}))
+		}
+
+		res, err := p.PackNested(r.Context(), items, opts...)
+		if err != nil {
+			makeResponse(r.Context(), w, packErrorCode(err), NestedPackResponse{}, fmt.Errorf("failed to pack order: %w", err))
+
+			return
+		}
+
+		makeResponse(r.Context(), w, http.StatusOK, toAPINestedResponse(res), nil)
+	}
+}
+
+// geometryPackHandler - handler for v2 /pack endpoint.
+//
+//	@Summary		Pack items into boxes by their dimensions
+//	@Tags			pack
+//	@Description	Places units of items into 3D boxes respecting rotation rules and returns coordinates of each unit
+//	@ID				orderpacker-pack-v2	post
+//	@Accept			json
+//	@Produce		json
+//	@Param			data	body		GeometryPackRequest		true	"Items with dimensions"
+//	@Success		200		{object}	GeometryPackResponse	"Successful response with placements"
+//	@Failure		400		{object}	badRequestError			"Invalid request data"
+//	@Failure		405		{object}	methodNotAllowedError	"Method not allowed"
+//	@Failure		500		{object}	internalServerError		"Internal server error"
+//	@Router			/api/v2/pack [post]
+func geometryPackHandler(g *geometry.Packer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			makeResponse(
+				r.Context(),
+				w,
+				http.StatusMethodNotAllowed,
+				GeometryPackResponse{},
+				errors.New(http.StatusText(http.StatusMethodNotAllowed)),
+			)
+
+			return
+		}
+
+		var req GeometryPackRequest
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			makeResponse(r.Context(), w, http.StatusBadRequest, GeometryPackResponse{}, fmt.Errorf("failed to unmarshal request: %w", err))
+
+			return
+		}
+
+		items, err := fromAPIGeometryRequest(req)
+		if err != nil {
+			makeResponse(r.Context(), w, http.StatusBadRequest, GeometryPackResponse{}, fmt.Errorf("invalid request: %w", err))
+
+			return
+		}
+
+		res, err := g.Pack(r.Context(), items)
+		if err != nil {
+			makeResponse(r.Context(), w, packErrorCode(err), GeometryPackResponse{}, fmt.Errorf("failed to pack order: %w", err))
+
+			return
+		}
+
+		makeResponse(r.Context(), w, http.StatusOK, toAPIGeometryResponse(res), nil)
+	}
+}
+
+// packErrorCode returns HTTP status code for the error of packing an order.
+func packErrorCode(err error) int {
+	switch {
+	case errors.Is(err, packer.ErrOrderTooLarge),
+		errors.Is(err, packer.ErrUnknownSKU),
+		errors.Is(err, packer.ErrUnitTooLarge),
+		errors.Is(err, packer.ErrInvalidUnit),
+		errors.Is(err, packer.ErrInvalidPolicy),
+		errors.Is(err, packer.ErrShipmentLimit),
+		errors.Is(err, packer.ErrInvalidRange),
+		errors.Is(err, packer.ErrNoEligibleBox),
+		errors.Is(err, geometry.ErrItemTooLarge),
+		errors.Is(err, geometry.ErrInvalidItem),
+		errors.Is(err, geometry.ErrTooManyItems):
+		return http.StatusBadRequest
+	case errors.Is(err, packer.ErrNoLevels):
+		return http.StatusNotFound
+	case errors.Is(err, packer.ErrInsufficientStock):
+		return http.StatusConflict
+	case errors.Is(err, packer.ErrNoExactFit),
+		errors.Is(err, packer.ErrFillLimit):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, context.Canceled):
+		return statusClientClosedRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+const (
+	// tableOrders is how many largest boxes the packing table covers by default.
+	tableOrders = 4
+	// maxTableOrders limits the number of orders a packing table of a request covers, far fewer than the command
+	// prints, so that a request does not hold up a CPU for minutes.
+	maxTableOrders = 10000
+)
+
+// tableHandler - handler for /pack/table endpoint.
+//
+//	@Summary		Get the packing table
+//	@Tags			pack
+//	@Description	Returns ranges of orders packed the same way, computed by the same solver as orders, to print for the floor.
+//	@Description	Boxes are in unlimited supply, so the table does not change with stock levels.
+//	@Description	A table covers at most 10000 orders and is computed within the packer timeout
+//	@ID				orderpacker-pack-table	get
+//	@Produce		json
+//	@Produce		text/csv
+//	@Produce		text/html
+//	@Param			from	query		int						false	"The least number of items, 1 by default"
+//	@Param			to		query		int						false	"The largest number of items, four largest boxes but at most 10000 orders by default"
+//	@Param			format	query		string					false	"Format of the table: json (default), csv or html"
+//	@Success		200		{object}	chart.Chart				"Packing table"
+//	@Failure		400		{object}	badRequestError			"Invalid request data"
+//	@Failure		405		{object}	methodNotAllowedError	"Method not allowed"
+//	@Failure		500		{object}	internalServerError		"Internal server error"
+//	@Failure		503		{object}	serviceUnavailableError	"Computing ran out of time"
+//	@Router			/api/v1/pack/table [get]
+func tableHandler(p *packer.Packer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			makeResponse(
+				r.Context(),
+				w,
+				http.StatusMethodNotAllowed,
+				chart.Chart{},
+				errors.New(http.StatusText(http.StatusMethodNotAllowed)),
+			)
+
+			return
+		}
+
+		boxes := p.Boxes()
+
+		from, err := queryUint(r, "from", 1)
+		if err != nil {
+			makeResponse(r.Context(), w, http.StatusBadRequest, chart.Chart{}, fmt.Errorf("invalid request: %w", err))
+
+			return
+		}
+
+		to, err := queryUint(r, "to", p.TableTo(from, tableOrders, maxTableOrders))
+		if err != nil {
+			makeResponse(r.Context(), w, http.StatusBadRequest, chart.Chart{}, fmt.Errorf("invalid request: %w", err))
+
+			return
+		}
+
+		if from <= to && to-from >= maxTableOrders {
+			makeResponse(
+				r.Context(),
+				w,
+				http.StatusBadRequest,
+				chart.Chart{},
+				fmt.Errorf("invalid request: %w: from %d to %d items, at most %d orders", packer.ErrInvalidRange, from, to, maxTableOrders),
+			)
+
+			return
+		}
+
+		format := chart.FormatJSON
+
+		if v := r.URL.Query().Get("format"); v != "" {
+			format, err = chart.ParseFormat(v)
+			if err != nil {
+				makeResponse(r.Context(), w, http.StatusBadRequest, chart.Chart{}, fmt.Errorf("invalid request: %w", err))
+
+				return
+			}
+		}
+
+		table, err := p.Table(r.Context(), from, to)
+		if err != nil {
+			makeResponse(r.Context(), w, packErrorCode(err), chart.Chart{}, fmt.Errorf("failed to compute packing table: %w", err))
+
+			return
+		}
+
+		var buf bytes.Buffer
+
+		c := chart.New(fmt.Sprintf("Packing table for %d-%d items", from, to), boxes, table)
+
+		if err = c.Write(&buf, format); err != nil {
+			makeResponse(r.Context(), w, http.StatusInternalServerError, chart.Chart{}, fmt.Errorf("failed to render packing table: %w", err))
+
+			return
+		}
+
+		w.Header().Set("Content-Type", format.ContentType())
+		w.WriteHeader(http.StatusOK)
+
+		if _, err = buf.WriteTo(w); err != nil {
+			log.WithError(r.Context(), err).Error("Failed to write packing table")
+		}
+	}
+}
+
+// analysisHandler - handler for /boxes/analysis endpoint.
+//
+//	@Summary		Analyze the box set
+//	@Tags			boxes
+//	@Description	Returns the gcd of box sizes, the largest order that cannot be packed exactly,
+//	@Description	sizes the optimal solver never uses and warnings about sizes that are likely typos
+//	@ID				orderpacker-boxes-analysis	get
+//	@Produce		json
+//	@Success		200	{object}	BoxAnalysis				"Analysis of the box set"
+//	@Failure		405	{object}	methodNotAllowedError	"Method not allowed"
+//	@Router			/api/v1/boxes/analysis [get]
+func analysisHandler(p *packer.Packer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			makeResponse(
+				r.Context(),
+				w,
+				http.StatusMethodNotAllowed,
+				BoxAnalysis{},
+				errors.New(http.StatusText(http.StatusMethodNotAllowed)),
+			)
+
+			return
+		}
+
+		makeResponse(r.Context(), w, http.StatusOK, toAPIAnalysis(p.Analyze()), nil)
+	}
+}
+
+// getBoxesHandler - handler for GET /boxes endpoint.
+//
+//	@Summary		Get the box set
+//	@Tags			boxes
+//	@Description	Returns the box set orders are packed with and the one it replaced
+//	@ID				orderpacker-boxes-get	get
+//	@Produce		json
+//	@Success		200	{object}	BoxSet					"Box set"
+//	@Failure		405	{object}	methodNotAllowedError	"Method not allowed"
+//	@Router			/api/v1/boxes [get]
+func getBoxesHandler(p *packer.Packer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			makeResponse(
+				r.Context(),
+				w,
+				http.StatusMethodNotAllowed,
+				BoxSet{},
+				errors.New(http.StatusText(http.StatusMethodNotAllowed)),
+			)
+
+			return
+		}
+
+		makeResponse(r.Context(), w, http.StatusOK, toAPIBoxSet(p.Boxes(), p.PreviousBoxes()), nil)
+	}
+}
+
+// putBoxesHandler - handler for PUT /boxes endpoint.
+//
+//	@Summary		Replace the box set
+//	@Tags			boxes
+//	@Description	Replaces the box set without restart. Orders being packed finish with the boxes they started with.
+//	@Description	The new set is validated before it goes live, the replaced one is kept for rollback
+//	@ID				orderpacker-boxes-put	put
+//	@Accept			json
+//	@Produce		json
+//	@Security		AdminToken
+//	@Param			data	body		BoxSet					true	"Box set, previous is ignored"
+//	@Success		200		{object}	BoxSet					"Box set"
+//	@Failure		400		{object}	badRequestError			"Invalid request data"
+//	@Failure		401		{object}	unauthorizedError		"Invalid admin token"
+//	@Failure		403		{object}	forbiddenError			"Admin endpoints are disabled"
+//	@Failure		405		{object}	methodNotAllowedError	"Method not allowed"
+//	@Router			/api/v1/boxes [put]
+func putBoxesHandler(p *packer.Packer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req BoxSet
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			makeResponse(r.Context(), w, http.StatusBadRequest, BoxSet{}, fmt.Errorf("failed to unmarshal request: %w", err))
+
+			return
+		}
+
+		if err := p.SetBoxes(r.Context(), fromAPIBoxSet(req)); err != nil {
+			makeResponse(r.Context(), w, http.StatusBadRequest, BoxSet{}, fmt.Errorf("invalid request: %w", err))
+
+			return
+		}
+
+		makeResponse(r.Context(), w, http.StatusOK, toAPIBoxSet(p.Boxes(), p.PreviousBoxes()), nil)
+	}
+}
+
+// rollbackBoxesHandler - handler for /boxes/rollback endpoint.
+//
+//	@Summary		Roll back the box set
+//	@Tags			boxes
+//	@Description	Swaps the box set with the one it replaced, rolling back twice restores it
+//	@ID				orderpacker-boxes-rollback	post
+//	@Produce		json
+//	@Security		AdminToken
+//	@Success		200	{object}	BoxSet					"Box set"
+//	@Failure		401	{object}	unauthorizedError		"Invalid admin token"
+//	@Failure		403	{object}	forbiddenError			"Admin endpoints are disabled"
+//	@Failure		405	{object}	methodNotAllowedError	"Method not allowed"
+//	@Failure		409	{object}	conflictError			"The box set was never replaced"
+//	@Router			/api/v1/boxes/rollback [post]
+func rollbackBoxesHandler(p *packer.Packer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			makeResponse(
+				r.Context(),
+				w,
+				http.StatusMethodNotAllowed,
+				BoxSet{},
+				errors.New(http.StatusText(http.StatusMethodNotAllowed)),
+			)
+
+			return
+		}
+
+		if err := p.Rollback(r.Context()); err != nil {
+			makeResponse(r.Context(), w, http.StatusConflict, BoxSet{}, fmt.Errorf("failed to roll back: %w", err))
+
+			return
+		}
+
+		makeResponse(r.Context(), w, http.StatusOK, toAPIBoxSet(p.Boxes(), p.PreviousBoxes()), nil)
+	}
+}
+
+// inventoryHandler serves requests with the handler of the inventory of the box profile, if it has one.
+func inventoryHandler(profile string, inv *inventory.Inventory,
+	newHandler func(inv *inventory.Inventory) http.HandlerFunc,
+) http.HandlerFunc {
+	if inv == nil {
+		return func(w http.ResponseWriter, r *http.Request) {
+			makeResponse(r.Context(), w, http.StatusNotFound, Inventory{}, fmt.Errorf("box profile %q has no inventory", profile))
+		}
+	}
+
+	return newHandler(inv)
+}
+
+// getInventoryHandler - handler for GET /inventory endpoint.
+//
+//	@Summary		Get the stock of boxes
+//	@Tags			inventory
+//	@Description	Returns the number of boxes in stock per box size of the box profile, each profile has its own stock
+//	@ID				orderpacker-inventory-get	get
+//	@Produce		json
+//	@Param			X-Box-Profile	header		string					false	"Box profile, the default one if not set"
+//	@Success		200				{object}	Inventory				"Stock of boxes"
+//	@Failure		404				{object}	notFoundError			"Unknown box profile"
+//	@Failure		405				{object}	methodNotAllowedError	"Method not allowed"
+//	@Router			/api/v1/inventory [get]
+func getInventoryHandler(inv *inventory.Inventory) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			makeResponse(
+				r.Context(),
+				w,
+				http.StatusMethodNotAllowed,
+				Inventory{},
+				errors.New(http.StatusText(http.StatusMethodNotAllowed)),
+			)
+
+			return
+		}
+
+		makeResponse(r.Context(), w, http.StatusOK, toAPIInventory(inv.Stock()), nil)
+	}
+}
+
+// putInventoryHandler - handler for PUT /inventory endpoint.
+//
+//	@Summary		Set the stock of boxes
+//	@Tags			inventory
+//	@Description	Replaces the number of boxes in stock per box size of the box profile. Box sizes left out are in unlimited supply
+//	@ID				orderpacker-inventory-put	put
+//	@Accept			json
+//	@Produce		json
+//	@Security		AdminToken
+//	@Param			data			body		Inventory				true	"Stock of boxes"
+//	@Param			X-Box-Profile	header		string					false	"Box profile, the default one if not set"
+//	@Success		200				{object}	Inventory				"Stock of boxes"
+//	@Failure		400				{object}	badRequestError			"Invalid request data"
+//	@Failure		401				{object}	unauthorizedError		"Invalid admin token"
+//	@Failure		403				{object}	forbiddenError			"Admin endpoints are disabled"
+//	@Failure		404				{object}	notFoundError			"Unknown box profile"
+//	@Failure		405				{object}	methodNotAllowedError	"Method not allowed"
+//	@Router			/api/v1/inventory [put]
+func putInventoryHandler(inv *inventory.Inventory) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req Inventory
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			makeResponse(r.Context(), w, http.StatusBadRequest, Inventory{}, fmt.Errorf("failed to unmarshal request: %w", err))
+
+			return
+		}
+
+		stock, err := fromAPIInventory(req)
+		if err != nil {
+			makeResponse(r.Context(), w, http.StatusBadRequest, Inventory{}, fmt.Errorf("invalid request: %w", err))
+
+			return
+		}
+
+		inv.Set(stock)
+
+		log.WithField(r.Context(), "stock", stock).Info("Inventory updated")
+
+		makeResponse(r.Context(), w, http.StatusOK, toAPIInventory(inv.Stock()), nil)
+	}
+}
+
+func makeResponse(ctx context.Context, w http.ResponseWriter, code int, resp any, err error) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
 
@@ -168,9 +1089,14 @@ func makeResponse(ctx context.Context, w http.ResponseWriter, code int, resp Pac
 	response = resp
 
 	if err != nil {
-		log.WithError(ctx, err).Error("Error processing request")
+		// A request the client cancelled is not an error of the service.
+		if code == statusClientClosedRequest {
+			log.WithError(ctx, err).Debug("Request cancelled")
+		} else {
+			log.WithError(ctx, err).Error("Error processing request")
+		}
 
-		response = newHTTPError(ctx, code, err.Error())
+		response = newHTTPError(ctx, code, err)
 	}
 
 	if err = json.NewEncoder(w).Encode(response); err != nil {
@@ -1,14 +1,29 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obalunenko/orderpacker/internal/chart"
+	"github.com/obalunenko/orderpacker/internal/inventory"
+	"github.com/obalunenko/orderpacker/internal/packer"
+	"github.com/obalunenko/orderpacker/internal/packer/geometry"
+	"github.com/obalunenko/orderpacker/internal/testlogger"
 )
 
 func Test_toAPIResponse(t *testing.T) {
 	type args struct {
-		boxes []uint
+		res packer.PackResult
 	}
 	tests := []struct {
 		name string
@@ -18,7 +33,17 @@ func Test_toAPIResponse(t *testing.T) {
 		{
 			name: "[500, 500, 500]",
 			args: args{
-				boxes: []uint{500, 500, 500},
+				res: packer.PackResult{
+					Items: 1400,
+					Packs: []packer.BoxQuantity{
+						{Box: 500, Quantity: 3},
+					},
+					Shipped:   1500,
+					Overshoot: 100,
+					PackCount: 3,
+					Cost:      1.95,
+					Strategy:  packer.StrategyExact,
+				},
 			},
 			want: PackResponse{
 				Packs: []Pack{
@@ -27,12 +52,27 @@ func Test_toAPIResponse(t *testing.T) {
 						Quantity: 3,
 					},
 				},
+				Items:     1400,
+				Shipped:   1500,
+				Overshoot: 100,
+				PackCount: 3,
+				Cost:      1.95,
+				Strategy:  packer.StrategyExact,
 			},
 		},
 		{
-			name: "[500, 2000, 500]",
+			name: "[2000, 500, 500]",
 			args: args{
-				boxes: []uint{500, 2000, 500},
+				res: packer.PackResult{
+					Items: 3000,
+					Packs: []packer.BoxQuantity{
+						{Box: 2000, Quantity: 1},
+						{Box: 500, Quantity: 2},
+					},
+					Shipped:   3000,
+					PackCount: 3,
+					Strategy:  packer.StrategyGreedy,
+				},
 			},
 			want: PackResponse{
 				Packs: []Pack{
@@ -45,12 +85,1457 @@ func Test_toAPIResponse(t *testing.T) {
 						Quantity: 2,
 					},
 				},
+				Items:     3000,
+				Shipped:   3000,
+				PackCount: 3,
+				Strategy:  packer.StrategyGreedy,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := toAPIResponse(tt.args.res)
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_packHandler(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := packer.NewPacker(ctx, packer.WithBoxes([]uint{1, 250}))
+	require.NoError(t, err)
+
+	small, err := packer.NewPacker(ctx, packer.WithBoxes([]uint{10, 20}))
+	require.NoError(t, err)
+
+	heavy, err := packer.NewPacker(ctx, packer.WithBoxSet([]packer.Box{{Size: 10, MaxWeight: 20}}))
+	require.NoError(t, err)
+
+	c, err := packer.NewCatalog(ctx,
+		map[string]*packer.Packer{"small": small, "large": p, "heavy": heavy},
+		map[string]string{"apple": "small", "tv": "large", "anvil": "heavy"},
+		nil,
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+		want     PackResponse
+	}{
+		{
+			name:     "501",
+			body:     `{"items": 501}`,
+			wantCode: http.StatusOK,
+			want: PackResponse{
+				Packs: []Pack{
+					{Box: 250, Quantity: 2},
+					{Box: 1, Quantity: 1},
+				},
+				Items:     501,
+				Shipped:   501,
+				PackCount: 3,
+				Strategy:  packer.StrategyExact,
+			},
+		},
+		{
+			name:     "largest order",
+			body:     `{"items": 18446744073709551366}`,
+			wantCode: http.StatusOK,
+			want: PackResponse{
+				Packs: []Pack{
+					{Box: 250, Quantity: 73786976294838205},
+					{Box: 1, Quantity: 116},
+				},
+				Items:     18446744073709551366,
+				Shipped:   18446744073709551366,
+				PackCount: 73786976294838321,
+				Strategy:  packer.StrategyExact,
+			},
+		},
+		{
+			name:     "empty items",
+			body:     `{"items": 0}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "too large",
+			body:     `{"items": 18446744073709551367}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "overflow",
+			body:     `{"items": 18446744073709551616}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "lines",
+			body:     `{"lines": [{"sku": "apple", "quantity": 25}, {"sku": "tv", "quantity": 250}]}`,
+			wantCode: http.StatusOK,
+			want: PackResponse{
+				Lines: []LinePacks{
+					{
+						SKU:    "apple",
+						Family: "small",
+						Packs: []Pack{
+							{Box: 20, Quantity: 1},
+							{Box: 10, Quantity: 1},
+						},
+						Items:     25,
+						Shipped:   30,
+						Overshoot: 5,
+						PackCount: 2,
+						Strategy:  packer.StrategyExact,
+					},
+					{
+						SKU:    "tv",
+						Family: "large",
+						Packs: []Pack{
+							{Box: 250, Quantity: 1},
+						},
+						Items:     250,
+						Shipped:   250,
+						PackCount: 1,
+						Strategy:  packer.StrategyExact,
+					},
+				},
+				Items:     275,
+				Shipped:   280,
+				Overshoot: 5,
+				PackCount: 3,
+			},
+		},
+		{
+			name:     "unknown sku",
+			body:     `{"lines": [{"sku": "pear", "quantity": 1}]}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "empty sku",
+			body:     `{"lines": [{"quantity": 1}]}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "empty line quantity",
+			body:     `{"lines": [{"sku": "apple", "quantity": 0}]}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "weight limit",
+			body:     `{"lines": [{"sku": "anvil", "quantity": 3, "unit_weight": 5}]}`,
+			wantCode: http.StatusOK,
+			want: PackResponse{
+				Lines: []LinePacks{
+					{
+						SKU:    "anvil",
+						Family: "heavy",
+						Packs: []Pack{
+							{Box: 10, Quantity: 1, Fill: &PackFill{Items: 4, Weight: 20, WeightRatio: 1}},
+						},
+						Items:     3,
+						Shipped:   4,
+						Overshoot: 1,
+						PackCount: 1,
+						Strategy:  packer.StrategyExact,
+					},
+				},
+				Items:     3,
+				Shipped:   4,
+				Overshoot: 1,
+				PackCount: 1,
+			},
+		},
+		{
+			name:     "unit too large",
+			body:     `{"lines": [{"sku": "anvil", "quantity": 1, "unit_weight": 25}]}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid unit",
+			body:     `{"items": 1, "unit_weight": -1}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "unit weight overflows",
+			body:     `{"items": 1, "unit_weight": 1e308}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "items and lines",
+			body:     `{"items": 1, "lines": [{"sku": "apple", "quantity": 1}]}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "policy down",
+			body:     `{"lines": [{"sku": "apple", "quantity": 25}, {"sku": "tv", "quantity": 250}], "policy": "down"}`,
+			wantCode: http.StatusOK,
+			want: PackResponse{
+				Lines: []LinePacks{
+					{
+						SKU:    "apple",
+						Family: "small",
+						Packs: []Pack{
+							{Box: 20, Quantity: 1},
+						},
+						Items:       25,
+						Shipped:     20,
+						Backordered: 5,
+						PackCount:   1,
+						Strategy:    packer.StrategyExact,
+					},
+					{
+						SKU:    "tv",
+						Family: "large",
+						Packs: []Pack{
+							{Box: 250, Quantity: 1},
+						},
+						Items:     250,
+						Shipped:   250,
+						PackCount: 1,
+						Strategy:  packer.StrategyExact,
+					},
+				},
+				Items:       275,
+				Shipped:     270,
+				Backordered: 5,
+				PackCount:   2,
+			},
+		},
+		{
+			name:     "invalid policy",
+			body:     `{"items": 1, "policy": "sideways"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "shipment limits",
+			body:     `{"items": 501, "shipment": {"max_packs": 2}}`,
+			wantCode: http.StatusOK,
+			want: PackResponse{
+				Packs: []Pack{
+					{Box: 250, Quantity: 2},
+					{Box: 1, Quantity: 1},
+				},
+				Items:     501,
+				Shipped:   501,
+				PackCount: 3,
+				Strategy:  packer.StrategyExact,
+				Shipments: []Shipment{
+					{Quantity: 1, Packs: []Pack{{Box: 250, Quantity: 1}, {Box: 1, Quantity: 1}}, Items: 251, PackCount: 2},
+					{Quantity: 1, Packs: []Pack{{Box: 250, Quantity: 1}}, Items: 250, PackCount: 1},
+				},
+			},
+		},
+		{
+			name:     "invalid shipment limits",
+			body:     `{"items": 501, "shipment": {"max_weight": -1}}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "pack exceeds shipment limits",
+			body:     `{"items": 501, "shipment": {"max_items": 100}}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "exact fit",
+			body:     `{"lines": [{"sku": "apple", "quantity": 30}], "exact_fit": true}`,
+			wantCode: http.StatusOK,
+			want: PackResponse{
+				Lines: []LinePacks{
+					{
+						SKU:    "apple",
+						Family: "small",
+						Packs: []Pack{
+							{Box: 20, Quantity: 1},
+							{Box: 10, Quantity: 1},
+						},
+						Items:     30,
+						Shipped:   30,
+						PackCount: 2,
+						Strategy:  packer.StrategyExact,
+					},
+				},
+				Items:     30,
+				Shipped:   30,
+				PackCount: 2,
 			},
 		},
+		{
+			name:     "no exact fit",
+			body:     `{"lines": [{"sku": "apple", "quantity": 25}], "exact_fit": true}`,
+			wantCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/pack", strings.NewReader(tt.body)).WithContext(ctx)
+			rec := httptest.NewRecorder()
+
+			packHandler(p, c).ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantCode, rec.Code)
+
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var got PackResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_packHandler_fillLimits(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := packer.NewPacker(ctx, packer.WithDefaultBoxes(), packer.WithFillLimits(packer.FillLimits{MaxWaste: 0.3}))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/pack", strings.NewReader(`{"items": 251}`)).WithContext(ctx)
+	rec := httptest.NewRecorder()
+
+	packHandler(p, nil).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	var got unprocessableEntityError
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+
+	assert.Equal(t, "failed to pack order: packing breaks fill limits: "+
+		"49.8% of the shipped capacity is empty, above 30.0%", got.Msg)
+	assert.Equal(t, uint(500), got.Box)
+	assert.InDelta(t, 0.502, got.Fill, 1e-9)
+	assert.InDelta(t, 0.498, got.Waste, 1e-9)
+}
+
+func Test_packHandler_exactFit(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := packer.NewPacker(ctx, packer.WithDefaultBoxes())
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/pack", strings.NewReader(`{"items": 10999, "exact_fit": true}`)).WithContext(ctx)
+	rec := httptest.NewRecorder()
+
+	packHandler(p, nil).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	var got unprocessableEntityError
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+
+	assert.Equal(t, unprocessableEntityError{
+		Code:  http.StatusUnprocessableEntity,
+		Msg:   "failed to pack order: no exact fit for 10999 items: nearest totals are 10750 below and 11000 above",
+		Items: 10999,
+		Below: 10750,
+		Above: 11000,
+	}, got)
+}
+
+func Test_packHandler_explain(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := packer.NewPacker(ctx, packer.WithDefaultBoxes())
+	require.NoError(t, err)
+
+	do := func(t *testing.T, target string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(`{"items": 12001}`)).WithContext(ctx)
+		rec := httptest.NewRecorder()
+
+		packHandler(p, nil).ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	rec := do(t, "/api/v1/pack?explain=true")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var got PackResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	require.NotNil(t, got.Explanation)
+
+	assert.Equal(t, "packs", got.Explanation.Objective)
+	assert.Equal(t, Candidate{
+		Packs:     []Pack{{Box: 5000, Quantity: 2}, {Box: 2000, Quantity: 1}, {Box: 250, Quantity: 1}},
+		Shipped:   12250,
+		Overshoot: 249,
+		PackCount: 4,
+		Chosen:    true,
+	}, got.Explanation.Candidates[0])
+	assert.Equal(t, "ships 250 more items", got.Explanation.Candidates[1].Reason)
+	assert.NotEmpty(t, got.Explanation.Decision)
+
+	rec = do(t, "/api/v1/pack")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	got = PackResponse{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	assert.Nil(t, got.Explanation)
+
+	rec = do(t, "/api/v1/pack?explain=maybe")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func Test_packHandler_timeout(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	boxes := []uint{9973, 10007, 10009, 10037}
+
+	strict, err := packer.NewPacker(ctx, packer.WithBoxes(boxes), packer.WithTimeout(time.Nanosecond))
+	require.NoError(t, err)
+
+	anytime, err := packer.NewPacker(ctx,
+		packer.WithBoxes(boxes),
+		packer.WithStrategy(packer.StrategyBranchAndBound),
+		packer.WithTimeout(time.Nanosecond),
+		packer.WithAnytime(true),
+	)
+	require.NoError(t, err)
+
+	body := `{"items": 5000000}`
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/pack", strings.NewReader(body)).WithContext(ctx)
+	rec := httptest.NewRecorder()
+
+	packHandler(strict, nil).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	c, err := packer.NewCatalog(ctx, map[string]*packer.Packer{"primes": strict}, map[string]string{"prime": "primes"}, nil)
+	require.NoError(t, err)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/pack",
+		strings.NewReader(`{"lines": [{"sku": "prime", "quantity": 5000000}]}`)).WithContext(ctx)
+	rec = httptest.NewRecorder()
+
+	packHandler(strict, c).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/pack", strings.NewReader(body)).WithContext(ctx)
+	rec = httptest.NewRecorder()
+
+	packHandler(anytime, nil).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var got PackResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+
+	assert.True(t, got.Interrupted)
+	assert.GreaterOrEqual(t, got.Shipped, uint(5000000))
+}
+
+func Test_packErrorCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "order too large",
+			err:  fmt.Errorf("failed to pack: %w", packer.ErrOrderTooLarge),
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "no levels",
+			err:  packer.ErrNoLevels,
+			want: http.StatusNotFound,
+		},
+		{
+			name: "insufficient stock",
+			err:  packer.ErrInsufficientStock,
+			want: http.StatusConflict,
+		},
+		{
+			name: "no exact fit",
+			err:  packer.ErrNoExactFit,
+			want: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "deadline exceeded",
+			err:  fmt.Errorf("failed to pack: %w", context.DeadlineExceeded),
+			want: http.StatusServiceUnavailable,
+		},
+		{
+			name: "canceled",
+			err:  fmt.Errorf("failed to pack: %w", context.Canceled),
+			want: statusClientClosedRequest,
+		},
+		{
+			name: "unknown",
+			err:  errors.New("boom"),
+			want: http.StatusInternalServerError,
+		},
 	}
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := toAPIResponse(tt.args.boxes)
+			assert.Equal(t, tt.want, packErrorCode(tt.err))
+		})
+	}
+}
+
+func Test_packHandler_canceled(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := packer.NewPacker(ctx, packer.WithBoxes([]uint{9973, 10007, 10009, 10037}))
+	require.NoError(t, err)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/pack", strings.NewReader(`{"items": 5000000}`)).WithContext(canceled)
+	rec := httptest.NewRecorder()
+
+	packHandler(p, nil).ServeHTTP(rec, req)
+
+	require.Equal(t, statusClientClosedRequest, rec.Code)
+
+	var got clientClosedRequestError
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+
+	assert.Equal(t, statusClientClosedRequest, got.Code)
+}
+
+func Test_inventory(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	inv := inventory.New(map[uint]uint{5000: 1})
+
+	p, err := packer.NewPacker(ctx, packer.WithDefaultBoxes(), packer.WithInventory(inv))
+	require.NoError(t, err)
+
+	ps, err := packer.NewProfiles(ctx, p, nil)
+	require.NoError(t, err)
+
+	router := NewRouter(ps, nil, nil, map[string]*inventory.Inventory{packer.DefaultProfile: inv}, "secret")
+
+	do := func(t *testing.T, method, target, body string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(method, target, strings.NewReader(body)).WithContext(ctx)
+		req.Header.Set("Authorization", "Bearer secret")
+
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	decode := func(t *testing.T, rec *httptest.ResponseRecorder, v any) {
+		t.Helper()
+
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(v))
+	}
+
+	rec := do(t, http.MethodPut, "/api/v1/inventory", `{"stock": [{"box": 250, "quantity": 1}, {"box": 5000, "quantity": 1}]}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = do(t, http.MethodPost, "/api/v1/pack", `{"items": 5001, "commit": true}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp PackResponse
+	decode(t, rec, &resp)
+	assert.Equal(t, []Pack{{Box: 5000, Quantity: 1}, {Box: 250, Quantity: 1}}, resp.Packs)
+
+	rec = do(t, http.MethodGet, "/api/v1/inventory", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var got Inventory
+	decode(t, rec, &got)
+	assert.Equal(t, Inventory{Stock: []StockLevel{{Box: 5000, Quantity: 0}, {Box: 250, Quantity: 0}}}, got)
+
+	rec = do(t, http.MethodPost, "/api/v1/pack", `{"items": 5001}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	decode(t, rec, &resp)
+	assert.Equal(t, []Pack{{Box: 2000, Quantity: 2}, {Box: 1000, Quantity: 1}, {Box: 500, Quantity: 1}}, resp.Packs)
+
+	rec = do(t, http.MethodPut, "/api/v1/inventory", `{"stock": [{"box": 250, "quantity": 0}, {"box": 500, "quantity": 0}, {"box": 1000, "quantity": 0}, {"box": 2000, "quantity": 0}, {"box": 5000, "quantity": 0}]}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = do(t, http.MethodPost, "/api/v1/pack", `{"items": 1}`)
+	require.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = do(t, http.MethodPut, "/api/v1/inventory", `{"stock": [{"box": 0, "quantity": 1}]}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(t, http.MethodDelete, "/api/v1/inventory", "")
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/inventory", strings.NewReader(`{"stock": []}`)).WithContext(ctx)
+	rec = httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "replacing the stock takes the admin token")
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, map[uint]uint{250: 0, 500: 0, 1000: 0, 2000: 0, 5000: 0}, inv.Stock())
+}
+
+func Test_boxes(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := packer.NewPacker(ctx, packer.WithBoxes([]uint{250, 500}))
+	require.NoError(t, err)
+
+	ps, err := packer.NewProfiles(ctx, p, nil)
+	require.NoError(t, err)
+
+	router := NewRouter(ps, nil, nil, nil, "secret")
+
+	do := func(t *testing.T, method, target, body string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(method, target, strings.NewReader(body)).WithContext(ctx)
+		req.Header.Set("Authorization", "Bearer secret")
+
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	decode := func(t *testing.T, rec *httptest.ResponseRecorder, v any) {
+		t.Helper()
+
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(v))
+	}
+
+	rec := do(t, http.MethodPost, "/api/v1/boxes/rollback", "")
+	require.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = do(t, http.MethodPut, "/api/v1/boxes", `{"boxes": [{"size": 300, "cost": 0.5}, {"size": 100}]}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var got BoxSet
+	decode(t, rec, &got)
+	assert.Equal(t, BoxSet{
+		Boxes:    []BoxDefinition{{Size: 100}, {Size: 300, Cost: 0.5}},
+		Previous: []BoxDefinition{{Size: 250}, {Size: 500}},
+	}, got)
+
+	rec = do(t, http.MethodPost, "/api/v1/pack", `{"items": 400}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp PackResponse
+	decode(t, rec, &resp)
+	assert.Equal(t, []Pack{{Box: 300, Quantity: 1}, {Box: 100, Quantity: 1}}, resp.Packs)
+
+	rec = do(t, http.MethodPut, "/api/v1/boxes", `{"boxes": [{"size": 0}]}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(t, http.MethodPut, "/api/v1/boxes", `{"boxes": [{"size": 100, "max_order": 1000}, {"size": 300, "min_order": 2000}]}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(t, http.MethodPost, "/api/v1/boxes/rollback", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = do(t, http.MethodGet, "/api/v1/boxes", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	got = BoxSet{}
+	decode(t, rec, &got)
+	assert.Equal(t, BoxSet{
+		Boxes:    []BoxDefinition{{Size: 250}, {Size: 500}},
+		Previous: []BoxDefinition{{Size: 100}, {Size: 300, Cost: 0.5}},
+	}, got)
+
+	rec = do(t, http.MethodDelete, "/api/v1/boxes", "")
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = do(t, http.MethodGet, "/api/v1/boxes/rollback", "")
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func Test_boxes_admin(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := packer.NewPacker(ctx, packer.WithBoxes([]uint{250, 500}))
+	require.NoError(t, err)
+
+	ps, err := packer.NewProfiles(ctx, p, nil)
+	require.NoError(t, err)
+
+	do := func(t *testing.T, router http.Handler, method, target, auth string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(method, target, strings.NewReader(`{"boxes": [{"size": 100}]}`)).WithContext(ctx)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	disabled := NewRouter(ps, nil, nil, nil, "")
+
+	for _, target := range []string{"/api/v1/boxes", "/api/v1/profiles/default/boxes"} {
+		rec := do(t, disabled, http.MethodPut, target, "Bearer secret")
+		assert.Equal(t, http.StatusForbidden, rec.Code, target)
+	}
+
+	rec := do(t, disabled, http.MethodPost, "/api/v1/boxes/rollback", "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	router := NewRouter(ps, nil, nil, nil, "secret")
+
+	for _, auth := range []string{"", "secret", "Bearer other", "Basic c2VjcmV0"} {
+		rec = do(t, router, http.MethodPut, "/api/v1/boxes", auth)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, auth)
+		assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+	}
+
+	rec = do(t, router, http.MethodOptions, "/api/v1/boxes/rollback", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "admin endpoints answer no preflight requests")
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+
+	rec = do(t, router, http.MethodPut, "/api/v1/boxes", "Bearer secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+
+	rec = do(t, router, http.MethodGet, "/api/v1/boxes", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+
+	assert.Equal(t, []packer.Box{{Size: 100}}, p.Boxes())
+}
+
+func Test_profiles(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	dflt, err := packer.NewPacker(ctx, packer.WithBoxes([]uint{250, 500}))
+	require.NoError(t, err)
+
+	east, err := packer.NewPacker(ctx, packer.WithBoxes([]uint{100, 300}))
+	require.NoError(t, err)
+
+	ps, err := packer.NewProfiles(ctx, dflt, map[string]*packer.Packer{"east": east})
+	require.NoError(t, err)
+
+	router := NewRouter(ps, nil, nil, nil, "")
+
+	tests := []struct {
+		name     string
+		target   string
+		profile  string
+		wantCode int
+		want     []Pack
+	}{
+		{
+			name:     "default",
+			target:   "/api/v1/pack",
+			wantCode: http.StatusOK,
+			want:     []Pack{{Box: 500, Quantity: 1}},
+		},
+		{
+			name:     "default by path",
+			target:   "/api/v1/profiles/default/pack",
+			wantCode: http.StatusOK,
+			want:     []Pack{{Box: 500, Quantity: 1}},
+		},
+		{
+			name:     "path",
+			target:   "/api/v1/profiles/east/pack",
+			wantCode: http.StatusOK,
+			want:     []Pack{{Box: 300, Quantity: 1}, {Box: 100, Quantity: 1}},
+		},
+		{
+			name:     "header",
+			target:   "/api/v1/pack",
+			profile:  "east",
+			wantCode: http.StatusOK,
+			want:     []Pack{{Box: 300, Quantity: 1}, {Box: 100, Quantity: 1}},
+		},
+		{
+			name:     "path takes precedence over header",
+			target:   "/api/v1/profiles/default/pack",
+			profile:  "east",
+			wantCode: http.StatusOK,
+			want:     []Pack{{Box: 500, Quantity: 1}},
+		},
+		{
+			name:     "unknown by path",
+			target:   "/api/v1/profiles/west/pack",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "unknown by header",
+			target:   "/api/v1/pack",
+			profile:  "west",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(`{"items": 400}`)).WithContext(ctx)
+			if tt.profile != "" {
+				req.Header.Set(profileHeader, tt.profile)
+			}
+
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantCode, rec.Code)
+
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var got PackResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+
+			assert.Equal(t, tt.want, got.Packs)
+		})
+	}
+
+	t.Run("boxes by path", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/profiles/east/boxes", nil).WithContext(ctx)
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var got BoxSet
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+
+		assert.Equal(t, BoxSet{Boxes: []BoxDefinition{{Size: 100}, {Size: 300}}}, got)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/profiles", nil).WithContext(ctx)
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var got ProfilesResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+
+		assert.Equal(t, ProfilesResponse{Profiles: []string{"default", "east"}, Default: "default"}, got)
+	})
+}
+
+func Test_profiles_lines(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	inv := inventory.New(map[uint]uint{20: 1})
+	eastInv := inventory.New(map[uint]uint{100: 1})
+
+	dflt, err := packer.NewPacker(ctx, packer.WithBoxes([]uint{250, 500}), packer.WithInventory(inv))
+	require.NoError(t, err)
+
+	small, err := packer.NewPacker(ctx, packer.WithBoxes([]uint{10, 20}), packer.WithInventory(inv))
+	require.NoError(t, err)
+
+	east, err := packer.NewPacker(ctx, packer.WithBoxes([]uint{100, 300}), packer.WithInventory(eastInv))
+	require.NoError(t, err)
+
+	ps, err := packer.NewProfiles(ctx, dflt, map[string]*packer.Packer{"east": east})
+	require.NoError(t, err)
+
+	c, err := packer.NewCatalog(ctx, map[string]*packer.Packer{"small": small}, map[string]string{"apple": "small"}, inv)
+	require.NoError(t, err)
+
+	router := NewRouter(ps, c, nil, map[string]*inventory.Inventory{packer.DefaultProfile: inv, "east": eastInv}, "")
+
+	pack := func(t *testing.T, target, profile string) PackResponse {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodPost, target,
+			strings.NewReader(`{"lines": [{"sku": "apple", "quantity": 20}], "commit": true}`)).WithContext(ctx)
+		if profile != "" {
+			req.Header.Set(profileHeader, profile)
+		}
+
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var got PackResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+		require.Len(t, got.Lines, 1)
+
+		return got
+	}
+
+	got := pack(t, "/api/v1/pack", "")
+	assert.Equal(t, []Pack{{Box: 20, Quantity: 1}}, got.Lines[0].Packs)
+	assert.Equal(t, map[uint]uint{100: 1}, eastInv.Stock(), "the default profile must not take boxes of another")
+
+	got = pack(t, "/api/v1/profiles/east/pack", "")
+	assert.Equal(t, []Pack{{Box: 100, Quantity: 1}}, got.Lines[0].Packs)
+
+	got = pack(t, "/api/v1/pack", "east")
+	assert.Equal(t, []Pack{{Box: 300, Quantity: 1}}, got.Lines[0].Packs, "the box 100 is out of stock")
+
+	assert.Equal(t, map[uint]uint{20: 0}, inv.Stock())
+	assert.Equal(t, map[uint]uint{100: 0}, eastInv.Stock())
+}
+
+func Test_profiles_inventory(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	inv := inventory.New(map[uint]uint{500: 1})
+	eastInv := inventory.New(nil)
+
+	dflt, err := packer.NewPacker(ctx, packer.WithBoxes([]uint{250, 500}), packer.WithInventory(inv))
+	require.NoError(t, err)
+
+	east, err := packer.NewPacker(ctx, packer.WithBoxes([]uint{250, 500}), packer.WithInventory(eastInv))
+	require.NoError(t, err)
+
+	ps, err := packer.NewProfiles(ctx, dflt, map[string]*packer.Packer{"east": east})
+	require.NoError(t, err)
+
+	router := NewRouter(ps, nil, nil, map[string]*inventory.Inventory{packer.DefaultProfile: inv, "east": eastInv}, "secret")
+
+	do := func(t *testing.T, method, target, body string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(method, target, strings.NewReader(body)).WithContext(ctx)
+		req.Header.Set("Authorization", "Bearer secret")
+
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	rec := do(t, http.MethodPut, "/api/v1/profiles/east/inventory", `{"stock": [{"box": 500, "quantity": 2}]}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = do(t, http.MethodPost, "/api/v1/profiles/east/pack", `{"items": 500, "commit": true}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Equal(t, map[uint]uint{500: 1}, eastInv.Stock())
+	assert.Equal(t, map[uint]uint{500: 1}, inv.Stock(), "committing to a profile must not change the stock of another")
+
+	rec = do(t, http.MethodGet, "/api/v1/inventory", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var got Inventory
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	assert.Equal(t, Inventory{Stock: []StockLevel{{Box: 500, Quantity: 1}}}, got)
+
+	rec = do(t, http.MethodGet, "/api/v1/profiles/west/inventory", "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func Test_mixedPackHandler(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := packer.NewPacker(ctx, packer.WithBoxSet([]packer.Box{{Size: 10, MaxWeight: 10}, {Size: 50}}))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		method   string
+		body     string
+		wantCode int
+		want     MixedPackResponse
+	}{
+		{
+			name:     "shared box",
+			method:   http.MethodPost,
+			body:     `{"lines": [{"sku": "apple", "quantity": 4, "unit_weight": 2}, {"sku": "pear", "quantity": 3}]}`,
+			wantCode: http.StatusOK,
+			want: MixedPackResponse{
+				Boxes: []MixedBox{
+					{
+						Box:      10,
+						Contents: []LineQuantity{{SKU: "apple", Quantity: 4}, {SKU: "pear", Quantity: 3}},
+						Fill:     PackFill{Items: 7, Weight: 8, WeightRatio: 0.8},
+					},
+				},
+				Items:     7,
+				PackCount: 1,
+			},
+		},
+		{
+			name:     "heavy unit in box without weight limit",
+			method:   http.MethodPost,
+			body:     `{"lines": [{"sku": "anvil", "quantity": 1, "unit_weight": 11}]}`,
+			wantCode: http.StatusOK,
+			want: MixedPackResponse{
+				Boxes: []MixedBox{
+					{
+						Box:      50,
+						Contents: []LineQuantity{{SKU: "anvil", Quantity: 1}},
+						Fill:     PackFill{Items: 1, Weight: 11},
+					},
+				},
+				Items:     1,
+				PackCount: 1,
+			},
+		},
+		{
+			name:     "no lines",
+			method:   http.MethodPost,
+			body:     `{"items": 10}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "method not allowed",
+			method:   http.MethodGet,
+			wantCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v1/pack/mixed", strings.NewReader(tt.body)).WithContext(ctx)
+			rec := httptest.NewRecorder()
+
+			mixedPackHandler(p).ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantCode, rec.Code)
+
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var got MixedPackResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_nestedPackHandler(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := packer.NewPacker(ctx,
+		packer.WithBoxes([]uint{10}),
+		packer.WithLevels(packer.Level{Name: "carton", Boxes: packer.SizedBoxes([]uint{4})}),
+	)
+	require.NoError(t, err)
+
+	flat, err := packer.NewPacker(ctx, packer.WithBoxes([]uint{10}))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		p        *packer.Packer
+		method   string
+		body     string
+		wantCode int
+		want     NestedPackResponse
+	}{
+		{
+			name:     "boxes in a carton",
+			p:        p,
+			method:   http.MethodPost,
+			body:     `{"items": 30}`,
+			wantCode: http.StatusOK,
+			want: NestedPackResponse{
+				Items: 30,
+				Levels: []LevelPacks{
+					{
+						Level: packer.BoxLevel,
+						PackResponse: PackResponse{
+							Packs:     []Pack{{Box: 10, Quantity: 3}},
+							Items:     30,
+							Shipped:   30,
+							PackCount: 3,
+							Strategy:  packer.StrategyExact,
+						},
+					},
+					{
+						Level: "carton",
+						PackResponse: PackResponse{
+							Packs:     []Pack{{Box: 4, Quantity: 1}},
+							Items:     3,
+							Shipped:   4,
+							Overshoot: 1,
+							PackCount: 1,
+							Strategy:  packer.StrategyExact,
+						},
+					},
+				},
+				Packs: []NestedPack{
+					{Level: "carton", Box: 4, Quantity: 1, Contents: []NestedPack{{Level: packer.BoxLevel, Box: 10, Quantity: 3}}},
+				},
+			},
+		},
+		{
+			name:     "no packaging levels",
+			p:        flat,
+			method:   http.MethodPost,
+			body:     `{"items": 30}`,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "lines",
+			p:        p,
+			method:   http.MethodPost,
+			body:     `{"lines": [{"sku": "apple", "quantity": 1}]}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "empty items",
+			p:        p,
+			method:   http.MethodPost,
+			body:     `{}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "method not allowed",
+			p:        p,
+			method:   http.MethodGet,
+			wantCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v1/pack/nested", strings.NewReader(tt.body)).WithContext(ctx)
+			rec := httptest.NewRecorder()
+
+			nestedPackHandler(tt.p).ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantCode, rec.Code)
+
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var got NestedPackResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_alternativesHandler(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := packer.NewPacker(ctx)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		method   string
+		query    string
+		body     string
+		wantCode int
+		want     AlternativesResponse
+	}{
+		{
+			name:     "two best",
+			method:   http.MethodPost,
+			query:    "?n=2",
+			body:     `{"items": 12001}`,
+			wantCode: http.StatusOK,
+			want: AlternativesResponse{
+				Alternatives: []PackResponse{
+					{
+						Packs:     []Pack{{Box: 5000, Quantity: 2}, {Box: 2000, Quantity: 1}, {Box: 250, Quantity: 1}},
+						Items:     12001,
+						Shipped:   12250,
+						Overshoot: 249,
+						PackCount: 4,
+					},
+					{
+						Packs:     []Pack{{Box: 5000, Quantity: 2}, {Box: 1000, Quantity: 2}, {Box: 250, Quantity: 1}},
+						Items:     12001,
+						Shipped:   12250,
+						Overshoot: 249,
+						PackCount: 5,
+					},
+				},
+			},
+		},
+		{
+			name:     "invalid n",
+			method:   http.MethodPost,
+			query:    "?n=0",
+			body:     `{"items": 12001}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "commit",
+			method:   http.MethodPost,
+			body:     `{"items": 12001, "commit": true}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "exact fit",
+			method:   http.MethodPost,
+			body:     `{"items": 12001, "exact_fit": false}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "method not allowed",
+			method:   http.MethodGet,
+			wantCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v1/pack/alternatives"+tt.query, strings.NewReader(tt.body)).WithContext(ctx)
+			rec := httptest.NewRecorder()
+
+			alternativesHandler(p).ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantCode, rec.Code)
+
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var got AlternativesResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_tableHandler(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := packer.NewPacker(ctx, packer.WithDefaultBoxes())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		method          string
+		query           string
+		wantCode        int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "csv",
+			method:          http.MethodGet,
+			query:           "?from=1&to=600&format=csv",
+			wantCode:        http.StatusOK,
+			wantContentType: "text/csv",
+			wantBody: `from,to,packs,shipped,pack_count,cost
+1,250,1x250,250,1,0
+251,500,1x500,500,1,0
+501,600,1x500 + 1x250,750,2,0
+`,
+		},
+		{
+			name:            "json",
+			method:          http.MethodGet,
+			query:           "?from=240&to=260",
+			wantCode:        http.StatusOK,
+			wantContentType: "application/json",
+			wantBody: `{"title":"Packing table for 240-260 items","boxes":[250,500,1000,2000,5000],"rows":[` +
+				`{"from":240,"to":250,"packs":[{"box":250,"quantity":1}],"shipped":250,"pack_count":1,"cost":0},` +
+				`{"from":251,"to":260,"packs":[{"box":500,"quantity":1}],"shipped":500,"pack_count":1,"cost":0}]}
+`,
+		},
+		{
+			name:     "invalid range",
+			method:   http.MethodGet,
+			query:    "?from=10&to=1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "too many orders",
+			method:   http.MethodGet,
+			query:    "?from=1&to=10001",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid format",
+			method:   http.MethodGet,
+			query:    "?format=pdf",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "method not allowed",
+			method:   http.MethodPost,
+			wantCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v1/pack/table"+tt.query, nil).WithContext(ctx)
+			rec := httptest.NewRecorder()
+
+			tableHandler(p).ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantCode, rec.Code)
+
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, tt.wantContentType, rec.Header().Get("Content-Type"))
+			assert.Equal(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+func Test_tableHandler_limits(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	large, err := packer.NewPacker(ctx, packer.WithBoxes([]uint{250, 50000}))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/pack/table?from=5", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+
+	tableHandler(large).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var got chart.Chart
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+
+	assert.Equal(t, "Packing table for 5-10004 items", got.Title, "default range is clamped to the orders limit")
+
+	slow, err := packer.NewPacker(ctx, packer.WithDefaultBoxes(), packer.WithTimeout(time.Nanosecond))
+	require.NoError(t, err)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/pack/table", nil).WithContext(ctx)
+	rec = httptest.NewRecorder()
+
+	tableHandler(slow).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func Test_analysisHandler(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	p, err := packer.NewPacker(ctx, packer.WithBoxes([]uint{250, 500, 1001, 2000}))
+	require.NoError(t, err)
+
+	ps, err := packer.NewProfiles(ctx, p, nil)
+	require.NoError(t, err)
+
+	router := NewRouter(ps, nil, nil, nil, "")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/boxes/analysis", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var got BoxAnalysis
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+
+	frobenius := uint(248999)
+
+	assert.Equal(t, BoxAnalysis{
+		Sizes:     []uint{250, 500, 1001, 2000},
+		GCD:       1,
+		Frobenius: &frobenius,
+		Dominated: []uint{},
+		Warnings:  []string{"size 1001 is not a multiple of 250 unlike all other sizes, likely a typo"},
+	}, got)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/boxes/analysis", nil).WithContext(ctx)
+	rec = httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func Test_geometryPackHandler(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	g, err := geometry.NewPacker(ctx, []geometry.Box{
+		{Name: "large", Dimensions: geometry.Dimensions{Length: 10, Width: 10, Height: 10}},
+	})
+	require.NoError(t, err)
+
+	router := NewRouter(nil, nil, g, nil, "")
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+		want     GeometryPackResponse
+	}{
+		{
+			name:     "placements",
+			body:     `{"items": [{"id": "tv", "length": 5, "width": 10, "height": 10, "quantity": 2, "rotation": "upright"}]}`,
+			wantCode: http.StatusOK,
+			want: GeometryPackResponse{
+				Boxes: []GeometryBox{
+					{
+						Box:    "large",
+						Length: 10,
+						Width:  10,
+						Height: 10,
+						Placements: []Placement{
+							{Item: "tv", X: 0, Y: 0, Z: 0, Length: 5, Width: 10, Height: 10},
+							{Item: "tv", X: 5, Y: 0, Z: 0, Length: 5, Width: 10, Height: 10},
+						},
+						Fill: 1,
+					},
+				},
+				Units:     2,
+				PackCount: 1,
+			},
+		},
+		{
+			name:     "item too large",
+			body:     `{"items": [{"id": "sofa", "length": 20, "width": 10, "height": 10, "quantity": 1}]}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "unknown rotation",
+			body:     `{"items": [{"id": "tv", "length": 5, "width": 5, "height": 5, "quantity": 1, "rotation": "sideways"}]}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "empty items",
+			body:     `{"items": []}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v2/pack", strings.NewReader(tt.body)).WithContext(ctx)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantCode, rec.Code)
+
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var got GeometryPackResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
 
 			assert.Equal(t, tt.want, got)
 		})
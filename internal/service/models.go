@@ -2,25 +2,278 @@ package service
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	log "github.com/obalunenko/logger"
+
+	"github.com/obalunenko/orderpacker/internal/packer"
 )
 
 // PackRequest represents a request to pack items.
+// An order of several products sets Lines instead of Items.
 type PackRequest struct {
-	Items uint `json:"items" format:"uint" example:"543"`
+	Items uint       `json:"items,omitempty" format:"uint" example:"543"`
+	Lines []LineItem `json:"lines,omitempty"`
+	// UnitWeight and UnitVolume measure a single item, boxes hold no more items than their limits allow.
+	UnitWeight float64 `json:"unit_weight,omitempty" example:"0.5"`
+	UnitVolume float64 `json:"unit_volume,omitempty" example:"0.002"`
+	// Commit takes the packs off the inventory stock.
+	Commit bool `json:"commit,omitempty" example:"false"`
+	// Policy is how much of the order ships: up ships the whole order, down ships only full boxes
+	// and backorders the rest, nearest ships whichever is closer to the order.
+	Policy string `json:"policy,omitempty" enums:"up,down,nearest" example:"up"`
+	// Shipment limits split the packing into shipments instead of the configured ones.
+	Shipment *ShipmentLimits `json:"shipment,omitempty"`
+	// ExactFit ships exactly the ordered items instead of the configured mode, an order boxes do not hold exactly
+	// is rejected with the nearest totals they hold.
+	ExactFit *bool `json:"exact_fit,omitempty" example:"true"`
+}
+
+// ShipmentLimits represents caps of a single shipment, zero means no limit.
+type ShipmentLimits struct {
+	MaxPacks uint `json:"max_packs,omitempty" format:"uint" example:"20"`
+	MaxItems uint `json:"max_items,omitempty" format:"uint" example:"50000"`
+	// MaxWeight binds only when the request sets the unit weight.
+	MaxWeight float64 `json:"max_weight,omitempty" example:"1000"`
+}
+
+// Shipment represents a number of identical shipments of packs.
+type Shipment struct {
+	Quantity  uint    `json:"quantity" format:"uint" example:"2"`
+	Packs     []Pack  `json:"packs"`
+	Items     uint    `json:"items" format:"uint" example:"5000"`
+	PackCount uint    `json:"pack_count" format:"uint" example:"1"`
+	Weight    float64 `json:"weight,omitempty" example:"2500"`
+}
+
+// LineItem represents a quantity of a product in an order.
+type LineItem struct {
+	SKU        string  `json:"sku" example:"tv"`
+	Quantity   uint    `json:"quantity" format:"uint" example:"3"`
+	UnitWeight float64 `json:"unit_weight,omitempty" example:"12.5"`
+	UnitVolume float64 `json:"unit_volume,omitempty" example:"0.15"`
 }
 
 // Pack represents a pack of items.
 type Pack struct {
-	Box      uint `json:"box" format:"uint" example:"50"`
-	Quantity uint `json:"quantity" format:"uint" example:"3"`
+	Box      uint      `json:"box" format:"uint" example:"50"`
+	Quantity uint      `json:"quantity" format:"uint" example:"3"`
+	Fill     *PackFill `json:"fill,omitempty"`
+}
+
+// PackFill represents how much of a box each pack takes up.
+type PackFill struct {
+	Items       uint    `json:"items" format:"uint" example:"40"`
+	Weight      float64 `json:"weight" example:"20"`
+	Volume      float64 `json:"volume" example:"0.08"`
+	WeightRatio float64 `json:"weight_ratio" example:"1"`
+	VolumeRatio float64 `json:"volume_ratio" example:"0.8"`
 }
 
 // PackResponse represents a response to a pack request.
+// For an order of several products Lines hold packs of each line item and the totals sum them up.
 type PackResponse struct {
-	Packs []Pack `json:"packs,omitempty"`
+	Packs     []Pack      `json:"packs,omitempty"`
+	Lines     []LinePacks `json:"lines,omitempty"`
+	Items     uint        `json:"items" format:"uint" example:"543"`
+	Shipped   uint        `json:"shipped" format:"uint" example:"750"`
+	Overshoot uint        `json:"overshoot" format:"uint" example:"207"`
+	// Backordered is the number of ordered items the policy leaves unshipped.
+	Backordered uint    `json:"backordered" format:"uint" example:"0"`
+	PackCount   uint    `json:"pack_count" format:"uint" example:"2"`
+	Cost        float64 `json:"cost" example:"1.05"`
+	Strategy    string  `json:"strategy,omitempty" example:"exact"`
+	// Interrupted is set when solving ran out of time and the packing is the best found by then.
+	Interrupted bool `json:"interrupted,omitempty" example:"false"`
+	// Shipments split the packs within the shipment limits, returned only when limits are set.
+	Shipments []Shipment `json:"shipments,omitempty"`
+	// Explanation is returned when the request asks for it with explain=true query parameter.
+	Explanation *Explanation `json:"explanation,omitempty"`
+}
+
+// Explanation represents why a packing was chosen: the best packings of totals the order may ship,
+// ranked by the objective, and the decision.
+type Explanation struct {
+	Objective  string      `json:"objective" example:"packs"`
+	Strategy   string      `json:"strategy" example:"exact"`
+	Considered uint        `json:"considered" format:"uint" example:"20"`
+	Candidates []Candidate `json:"candidates"`
+	Decision   string      `json:"decision" example:"Ships 12250 items, the fewest of 20 reachable totals not less than the order (overshoot 249); no packing of 12250 items uses fewer than 4 packs."`
+}
+
+// Candidate represents a packing considered for an order.
+type Candidate struct {
+	Packs     []Pack  `json:"packs"`
+	Shipped   uint    `json:"shipped" format:"uint" example:"12500"`
+	Overshoot uint    `json:"overshoot" format:"uint" example:"499"`
+	PackCount uint    `json:"pack_count" format:"uint" example:"4"`
+	Cost      float64 `json:"cost" example:"0"`
+	Chosen    bool    `json:"chosen" example:"false"`
+	Reason    string  `json:"reason,omitempty" example:"ships 250 more items"`
+}
+
+// LinePacks represents packs of a line item.
+type LinePacks struct {
+	SKU         string  `json:"sku" example:"tv"`
+	Family      string  `json:"family" example:"large"`
+	Packs       []Pack  `json:"packs,omitempty"`
+	Items       uint    `json:"items" format:"uint" example:"3"`
+	Shipped     uint    `json:"shipped" format:"uint" example:"4"`
+	Overshoot   uint    `json:"overshoot" format:"uint" example:"1"`
+	Backordered uint    `json:"backordered" format:"uint" example:"0"`
+	PackCount   uint    `json:"pack_count" format:"uint" example:"1"`
+	Cost        float64 `json:"cost" example:"2.5"`
+	Strategy    string  `json:"strategy" example:"exact"`
+	// Shipments split the packs within the shipment limits, returned only when limits are set.
+	Shipments []Shipment `json:"shipments,omitempty"`
+	// Explanation is returned when the request asks for it with explain=true query parameter.
+	Explanation *Explanation `json:"explanation,omitempty"`
+}
+
+// NestedPackResponse represents a packing of an order in nested packaging levels.
+type NestedPackResponse struct {
+	Items uint `json:"items" format:"uint" example:"12001"`
+	// Levels hold packs of each level, the box level first. Items of a level are packs of the level below.
+	Levels []LevelPacks `json:"levels"`
+	// Packs are the packs of the outermost level with their contents.
+	Packs []NestedPack `json:"packs"`
+}
+
+// LevelPacks represents packs of a packaging level.
+type LevelPacks struct {
+	Level string `json:"level" example:"carton"`
+	PackResponse
+}
+
+// NestedPack represents a number of identical packs of a packaging level with the packs each holds.
+type NestedPack struct {
+	Level    string       `json:"level" example:"pallet"`
+	Box      uint         `json:"box" format:"uint" example:"20"`
+	Quantity uint         `json:"quantity" format:"uint" example:"1"`
+	Contents []NestedPack `json:"contents,omitempty"`
+}
+
+// AlternativesResponse represents the best distinct packings of an order, the best first.
+type AlternativesResponse struct {
+	Alternatives []PackResponse `json:"alternatives"`
+}
+
+// MixedPackResponse represents a response to a mixed pack request.
+type MixedPackResponse struct {
+	Boxes     []MixedBox `json:"boxes"`
+	Items     uint       `json:"items" format:"uint" example:"42"`
+	PackCount uint       `json:"pack_count" format:"uint" example:"1"`
+	Cost      float64    `json:"cost" example:"0.65"`
+}
+
+// MixedBox represents a box shared by items of several products.
+type MixedBox struct {
+	Box      uint           `json:"box" format:"uint" example:"50"`
+	Contents []LineQuantity `json:"contents"`
+	Fill     PackFill       `json:"fill"`
+}
+
+// LineQuantity represents a number of items of a product.
+type LineQuantity struct {
+	SKU      string `json:"sku" example:"apple"`
+	Quantity uint   `json:"quantity" format:"uint" example:"15"`
+}
+
+// GeometryPackRequest represents a request to pack items by their dimensions.
+type GeometryPackRequest struct {
+	Items []GeometryItem `json:"items"`
+}
+
+// GeometryItem represents a quantity of units of the same dimensions.
+// Rotation is one of "any" (default), "upright" (height stays vertical) or "none".
+type GeometryItem struct {
+	ID       string `json:"id" example:"tv"`
+	Length   uint   `json:"length" format:"uint" example:"1200"`
+	Width    uint   `json:"width" format:"uint" example:"200"`
+	Height   uint   `json:"height" format:"uint" example:"750"`
+	Quantity uint   `json:"quantity" format:"uint" example:"2"`
+	Rotation string `json:"rotation,omitempty" example:"upright"`
+}
+
+// GeometryPackResponse represents a response to a geometry pack request.
+type GeometryPackResponse struct {
+	Boxes     []GeometryBox `json:"boxes"`
+	Units     uint          `json:"units" format:"uint" example:"2"`
+	PackCount uint          `json:"pack_count" format:"uint" example:"1"`
+}
+
+// GeometryBox represents a box with placed units.
+type GeometryBox struct {
+	Box        string      `json:"box" example:"large"`
+	Length     uint        `json:"length" format:"uint" example:"1300"`
+	Width      uint        `json:"width" format:"uint" example:"500"`
+	Height     uint        `json:"height" format:"uint" example:"800"`
+	Placements []Placement `json:"placements"`
+	Fill       float64     `json:"fill" example:"0.69"`
+}
+
+// Placement represents a unit placed in a box: the corner nearest to the box origin
+// and dimensions of the unit as it is oriented.
+type Placement struct {
+	Item   string `json:"item" example:"tv"`
+	X      uint   `json:"x" format:"uint" example:"0"`
+	Y      uint   `json:"y" format:"uint" example:"200"`
+	Z      uint   `json:"z" format:"uint" example:"0"`
+	Length uint   `json:"length" format:"uint" example:"1200"`
+	Width  uint   `json:"width" format:"uint" example:"200"`
+	Height uint   `json:"height" format:"uint" example:"750"`
+}
+
+// StockLevel represents a number of boxes of the same size in stock.
+type StockLevel struct {
+	Box      uint `json:"box" format:"uint" example:"250"`
+	Quantity uint `json:"quantity" format:"uint" example:"100"`
+}
+
+// BoxAnalysis represents how the box set packs orders.
+type BoxAnalysis struct {
+	Sizes []uint `json:"sizes" format:"uint" example:"250,500,1000,2000,5000"`
+	// GCD is the greatest common divisor of sizes, orders that are not its multiples are never packed exactly.
+	GCD uint `json:"gcd" format:"uint" example:"250"`
+	// Frobenius is the largest multiple of gcd that cannot be packed exactly, zero when every multiple can.
+	// It is left out when the set is too large to compute it.
+	Frobenius *uint `json:"frobenius,omitempty" format:"uint" example:"0"`
+	// Dominated are sizes the optimal solver never uses.
+	Dominated []uint   `json:"dominated" format:"uint"`
+	Warnings  []string `json:"warnings"`
+}
+
+// BoxDefinition represents a box of the box set.
+type BoxDefinition struct {
+	Size      uint    `json:"size" format:"uint" example:"250"`
+	Cost      float64 `json:"cost,omitempty" example:"0.4"`
+	MaxWeight float64 `json:"max_weight,omitempty" example:"20"`
+	MaxVolume float64 `json:"max_volume,omitempty" example:"0.05"`
+	// MinQuantity and MaxQuantity bound the number of boxes of the size per order, zero means no bound.
+	MinQuantity uint `json:"min_quantity,omitempty" format:"uint" example:"0"`
+	MaxQuantity uint `json:"max_quantity,omitempty" format:"uint" example:"1"`
+	// MinOrder and MaxOrder bound the orders the box is used for, zero means no bound.
+	MinOrder uint `json:"min_order,omitempty" format:"uint" example:"1000"`
+	MaxOrder uint `json:"max_order,omitempty" format:"uint" example:"0"`
+}
+
+// BoxSet represents the box set orders are packed with and the one it replaced, if any.
+type BoxSet struct {
+	Boxes    []BoxDefinition `json:"boxes"`
+	Previous []BoxDefinition `json:"previous,omitempty"`
+}
+
+// ProfilesResponse represents names of box profiles.
+type ProfilesResponse struct {
+	Profiles []string `json:"profiles" example:"default,east"`
+	Default  string   `json:"default" example:"default"`
+}
+
+// Inventory represents the stock of boxes.
+// Box sizes without a stock level are in unlimited supply.
+type Inventory struct {
+	Stock []StockLevel `json:"stock"`
 }
 
 // HTTPError represents an HTTP error.
@@ -31,12 +284,28 @@ type HTTPError interface {
 	Message() string
 }
 
-func newHTTPError(ctx context.Context, code int, msg string) HTTPError {
+func newHTTPError(ctx context.Context, code int, err error) HTTPError {
+	msg := err.Error()
+
 	switch code {
 	case http.StatusBadRequest:
 		return newBadRequestError(msg)
+	case http.StatusUnauthorized:
+		return newUnauthorizedError(msg)
+	case http.StatusForbidden:
+		return newForbiddenError(msg)
+	case http.StatusNotFound:
+		return newNotFoundError(msg)
 	case http.StatusMethodNotAllowed:
 		return newMethodNotAllowedError(msg)
+	case http.StatusConflict:
+		return newConflictError(msg)
+	case http.StatusUnprocessableEntity:
+		return newUnprocessableEntityError(err)
+	case http.StatusServiceUnavailable:
+		return newServiceUnavailableError(msg)
+	case statusClientClosedRequest:
+		return newClientClosedRequestError(msg)
 	case http.StatusInternalServerError:
 		return newInternalServerError(msg)
 	default:
@@ -85,6 +354,66 @@ func (e internalServerError) Message() string {
 	return e.Msg
 }
 
+type unauthorizedError struct {
+	Code int    `json:"code" example:"401"`
+	Msg  string `json:"message" example:"Unauthorized"`
+}
+
+func newUnauthorizedError(msg string) HTTPError {
+	return unauthorizedError{
+		Code: http.StatusUnauthorized,
+		Msg:  msg,
+	}
+}
+
+func (e unauthorizedError) StatusCode() int {
+	return e.Code
+}
+
+func (e unauthorizedError) Message() string {
+	return e.Msg
+}
+
+type forbiddenError struct {
+	Code int    `json:"code" example:"403"`
+	Msg  string `json:"message" example:"Forbidden"`
+}
+
+func newForbiddenError(msg string) HTTPError {
+	return forbiddenError{
+		Code: http.StatusForbidden,
+		Msg:  msg,
+	}
+}
+
+func (e forbiddenError) StatusCode() int {
+	return e.Code
+}
+
+func (e forbiddenError) Message() string {
+	return e.Msg
+}
+
+type notFoundError struct {
+	Code int    `json:"code" example:"404"`
+	Msg  string `json:"message" example:"Not found"`
+}
+
+func newNotFoundError(msg string) HTTPError {
+	return notFoundError{
+		Code: http.StatusNotFound,
+		Msg:  msg,
+	}
+}
+
+func (e notFoundError) StatusCode() int {
+	return e.Code
+}
+
+func (e notFoundError) Message() string {
+	return e.Msg
+}
+
 type methodNotAllowedError struct {
 	Code int    `json:"code" example:"405"`
 	Msg  string `json:"message" example:"Method not allowed"`
@@ -104,3 +433,110 @@ func (e methodNotAllowedError) StatusCode() int {
 func (e methodNotAllowedError) Message() string {
 	return e.Msg
 }
+
+type conflictError struct {
+	Code int    `json:"code" example:"409"`
+	Msg  string `json:"message" example:"Conflict"`
+}
+
+func newConflictError(msg string) HTTPError {
+	return conflictError{
+		Code: http.StatusConflict,
+		Msg:  msg,
+	}
+}
+
+func (e conflictError) StatusCode() int {
+	return e.Code
+}
+
+func (e conflictError) Message() string {
+	return e.Msg
+}
+
+type unprocessableEntityError struct {
+	Code int    `json:"code" example:"422"`
+	Msg  string `json:"message" example:"Unprocessable entity"`
+	// Items is the number of ordered items that have no exact fit.
+	Items uint `json:"items,omitempty" format:"uint" example:"10999"`
+	// Below is the largest total under the order boxes hold, omitted when they hold none.
+	Below uint `json:"below,omitempty" format:"uint" example:"10750"`
+	// Above is the smallest total over the order boxes hold, omitted when they hold none.
+	Above uint `json:"above,omitempty" format:"uint" example:"11000"`
+	// Box is the size of the least filled box of a packing that breaks the fill limits.
+	Box uint `json:"box,omitempty" format:"uint" example:"250"`
+	// Fill is the share of the least filled box the items in it take.
+	Fill float64 `json:"fill,omitempty" example:"0.4"`
+	// Waste is the share of the shipped capacity left empty.
+	Waste float64 `json:"waste,omitempty" example:"0.04"`
+}
+
+func newUnprocessableEntityError(err error) HTTPError {
+	e := unprocessableEntityError{
+		Code: http.StatusUnprocessableEntity,
+		Msg:  err.Error(),
+	}
+
+	var fe *packer.ExactFitError
+	if errors.As(err, &fe) {
+		e.Items, e.Below, e.Above = fe.Items, fe.Below, fe.Above
+	}
+
+	var le *packer.FillLimitError
+	if errors.As(err, &le) {
+		e.Box, e.Fill, e.Waste = le.Box, le.Fill, le.Waste
+	}
+
+	return e
+}
+
+func (e unprocessableEntityError) StatusCode() int {
+	return e.Code
+}
+
+func (e unprocessableEntityError) Message() string {
+	return e.Msg
+}
+
+type serviceUnavailableError struct {
+	Code int    `json:"code" example:"503"`
+	Msg  string `json:"message" example:"Service unavailable"`
+}
+
+func newServiceUnavailableError(msg string) HTTPError {
+	return serviceUnavailableError{
+		Code: http.StatusServiceUnavailable,
+		Msg:  msg,
+	}
+}
+
+func (e serviceUnavailableError) StatusCode() int {
+	return e.Code
+}
+
+func (e serviceUnavailableError) Message() string {
+	return e.Msg
+}
+
+// statusClientClosedRequest is the nginx status of a request the client closed before the response.
+const statusClientClosedRequest = 499
+
+type clientClosedRequestError struct {
+	Code int    `json:"code" example:"499"`
+	Msg  string `json:"message" example:"Client closed request"`
+}
+
+func newClientClosedRequestError(msg string) HTTPError {
+	return clientClosedRequestError{
+		Code: statusClientClosedRequest,
+		Msg:  msg,
+	}
+}
+
+func (e clientClosedRequestError) StatusCode() int {
+	return e.Code
+}
+
+func (e clientClosedRequestError) Message() string {
+	return e.Msg
+}
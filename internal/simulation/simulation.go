@@ -0,0 +1,271 @@
+// Package simulation replays order streams through packing strategies and box sets to compare them.
+package simulation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/obalunenko/orderpacker/internal/packer"
+)
+
+// MaxOrders limits the number of orders of a simulation, as outcomes of every order are kept to compare strategies.
+const MaxOrders = 1_000_000
+
+// maxExamples limits the number of orders a disagreement lists.
+const maxExamples = 5
+
+// BoxSet is a named set of boxes to simulate.
+type BoxSet struct {
+	Name  string
+	Boxes []packer.Box
+}
+
+// Options configure a simulation.
+type Options struct {
+	BoxSets []BoxSet
+	// Strategies are names of registered solvers, all of them if empty.
+	Strategies       []string
+	Objective        packer.Objective
+	OvershootPenalty float64
+}
+
+// Latency holds percentiles of the time orders took to pack.
+type Latency struct {
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// Result is how a strategy packed the stream with a box set.
+type Result struct {
+	BoxSet   string
+	Strategy string
+	Orders   uint
+	// Failed is the number of orders the strategy could not pack, they are left out of the totals.
+	Failed  uint
+	Items   uint
+	Shipped uint
+	// Waste is the number of shipped items above orders.
+	Waste   uint
+	Packs   uint
+	Cost    float64
+	Latency Latency
+}
+
+// Disagreement counts orders two strategies packed differently with a box set.
+type Disagreement struct {
+	BoxSet string
+	A, B   string
+	// Orders is the number of orders packed differently.
+	Orders uint
+	// Worse and Better are the numbers of orders B packed worse or better than A by the objective,
+	// the rest are packed differently but as well.
+	Worse  uint
+	Better uint
+	// Examples are the first orders packed differently.
+	Examples []uint
+}
+
+// Report is the outcome of a simulation.
+type Report struct {
+	Orders        uint
+	Results       []Result
+	Disagreements []Disagreement
+}
+
+// Synthetic returns a stream of orders with sizes drawn uniformly from the range, the same for the same seed.
+func Synthetic(n int, minItems, maxItems uint, seed uint64) ([]uint, error) {
+	if n <= 0 || n > MaxOrders {
+		return nil, fmt.Errorf("number of orders %d is out of range [1, %d]", n, MaxOrders)
+	}
+
+	if minItems == 0 || minItems > maxItems {
+		return nil, fmt.Errorf("invalid range of order sizes from %d to %d items", minItems, maxItems)
+	}
+
+	r := rand.New(rand.NewPCG(seed, seed))
+
+	orders := make([]uint, n)
+
+	for i := range orders {
+		orders[i] = minItems + uint(r.Uint64N(uint64(maxItems-minItems)+1))
+	}
+
+	return orders, nil
+}
+
+// outcome is how an order was packed, ok is false if it failed.
+type outcome struct {
+	res packer.PackResult
+	ok  bool
+}
+
+// Run packs every order of the stream with every strategy and box set and compares the strategies.
+// Orders are packed one by one as the service packs them, so latencies include the whole PackOrder call.
+func Run(ctx context.Context, orders []uint, opts Options) (Report, error) {
+	if len(orders) == 0 {
+		return Report{}, errors.New("no orders to simulate")
+	}
+
+	if len(orders) > MaxOrders {
+		return Report{}, fmt.Errorf("%d orders to simulate are more than %d", len(orders), MaxOrders)
+	}
+
+	if len(opts.BoxSets) == 0 {
+		return Report{}, errors.New("no box sets to simulate")
+	}
+
+	strategies := opts.Strategies
+	if len(strategies) == 0 {
+		strategies = packer.Strategies()
+	}
+
+	objective := opts.Objective
+	if objective == "" {
+		objective = packer.DefaultObjective
+	}
+
+	rep := Report{Orders: uint(len(orders))}
+
+	for _, set := range opts.BoxSets {
+		outcomes := make([][]outcome, len(strategies))
+
+		for i, strategy := range strategies {
+			p, err := packer.NewPacker(ctx,
+				packer.WithBoxSet(set.Boxes),
+				packer.WithStrategy(strategy),
+				packer.WithObjective(objective),
+				packer.WithOvershootPenalty(opts.OvershootPenalty),
+			)
+			if err != nil {
+				return Report{}, fmt.Errorf("failed to create packer of %s boxes: %w", set.Name, err)
+			}
+
+			res, out, err := run(ctx, p, orders)
+			if err != nil {
+				return Report{}, err
+			}
+
+			res.BoxSet, res.Strategy = set.Name, strategy
+
+			rep.Results = append(rep.Results, res)
+			outcomes[i] = out
+		}
+
+		for i := range strategies {
+			for j := i + 1; j < len(strategies); j++ {
+				d := compare(orders, outcomes[i], outcomes[j], objective)
+				if d.Orders == 0 {
+					continue
+				}
+
+				d.BoxSet, d.A, d.B = set.Name, strategies[i], strategies[j]
+
+				rep.Disagreements = append(rep.Disagreements, d)
+			}
+		}
+	}
+
+	return rep, nil
+}
+
+func run(ctx context.Context, p *packer.Packer, orders []uint) (Result, []outcome, error) {
+	var res Result
+
+	out := make([]outcome, len(orders))
+	latencies := make([]time.Duration, 0, len(orders))
+
+	for i, items := range orders {
+		if err := ctx.Err(); err != nil {
+			return Result{}, nil, err
+		}
+
+		start := time.Now()
+		pr, err := p.PackOrder(ctx, items)
+		latencies = append(latencies, time.Since(start))
+
+		res.Orders++
+
+		if err != nil {
+			res.Failed++
+
+			continue
+		}
+
+		out[i] = outcome{res: pr, ok: true}
+
+		res.Items += pr.Items
+		res.Shipped += pr.Shipped
+		res.Waste += pr.Overshoot
+		res.Packs += pr.PackCount
+		res.Cost += pr.Cost
+	}
+
+	res.Latency = latencyOf(latencies)
+
+	return res, out, nil
+}
+
+// latencyOf returns nearest rank percentiles of latencies.
+func latencyOf(latencies []time.Duration) Latency {
+	slices.Sort(latencies)
+
+	rank := func(p float64) time.Duration {
+		i := int(math.Ceil(p*float64(len(latencies)))) - 1
+
+		return latencies[max(i, 0)]
+	}
+
+	return Latency{
+		P50: rank(0.5),
+		P90: rank(0.9),
+		P99: rank(0.99),
+		Max: latencies[len(latencies)-1],
+	}
+}
+
+// compare counts orders both strategies packed, but differently.
+func compare(orders []uint, a, b []outcome, objective packer.Objective) Disagreement {
+	var d Disagreement
+
+	for i := range orders {
+		if !a[i].ok || !b[i].ok || packer.SamePacks(a[i].res.Packs, b[i].res.Packs) {
+			continue
+		}
+
+		d.Orders++
+
+		switch {
+		case better(a[i].res, b[i].res, objective):
+			d.Worse++
+		case better(b[i].res, a[i].res, objective):
+			d.Better++
+		}
+
+		if len(d.Examples) < maxExamples {
+			d.Examples = append(d.Examples, orders[i])
+		}
+	}
+
+	return d
+}
+
+// better reports whether x packs an order better than y by the objective.
+// Costs of results already include the overshoot penalty.
+func better(x, y packer.PackResult, objective packer.Objective) bool {
+	if objective == packer.ObjectiveCost && math.Abs(x.Cost-y.Cost) > packer.CostEpsilon {
+		return x.Cost < y.Cost
+	}
+
+	if x.Shipped != y.Shipped {
+		return x.Shipped < y.Shipped
+	}
+
+	return x.PackCount < y.PackCount
+}
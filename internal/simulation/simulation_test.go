@@ -0,0 +1,119 @@
+package simulation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obalunenko/orderpacker/internal/packer"
+	"github.com/obalunenko/orderpacker/internal/testlogger"
+)
+
+func TestRun(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	orders := []uint{23, 62, 100, 62}
+
+	got, err := Run(ctx, orders, Options{
+		BoxSets: []BoxSet{
+			{Name: "custom", Boxes: packer.SizedBoxes([]uint{23, 31, 53})},
+			{Name: "single", Boxes: packer.SizedBoxes([]uint{10})},
+		},
+		Strategies: []string{packer.StrategyExact, packer.StrategyGreedy},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, uint(4), got.Orders)
+
+	want := []Result{
+		{BoxSet: "custom", Strategy: packer.StrategyExact, Orders: 4, Items: 247, Shipped: 247, Waste: 0, Packs: 9},
+		// Greedy ships 62 items in 53+23 and 100 items in 53+31+23.
+		{BoxSet: "custom", Strategy: packer.StrategyGreedy, Orders: 4, Items: 247, Shipped: 282, Waste: 35, Packs: 8},
+		{BoxSet: "single", Strategy: packer.StrategyExact, Orders: 4, Items: 247, Shipped: 270, Waste: 23, Packs: 27},
+		{BoxSet: "single", Strategy: packer.StrategyGreedy, Orders: 4, Items: 247, Shipped: 270, Waste: 23, Packs: 27},
+	}
+
+	require.Len(t, got.Results, len(want))
+
+	for i, res := range got.Results {
+		assert.LessOrEqual(t, res.Latency.P50, res.Latency.P90)
+		assert.LessOrEqual(t, res.Latency.P90, res.Latency.P99)
+		assert.LessOrEqual(t, res.Latency.P99, res.Latency.Max)
+
+		res.Latency = Latency{}
+
+		assert.Equal(t, want[i], res)
+	}
+
+	assert.Equal(t, []Disagreement{
+		{BoxSet: "custom", A: packer.StrategyExact, B: packer.StrategyGreedy, Orders: 3, Worse: 3, Examples: []uint{62, 100, 62}},
+	}, got.Disagreements)
+}
+
+func TestRun_cost(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	got, err := Run(ctx, []uint{30}, Options{
+		BoxSets:          []BoxSet{{Name: "priced", Boxes: []packer.Box{{Size: 32, Cost: 3}, {Size: 40, Cost: 1}}}},
+		Strategies:       []string{packer.StrategyExact, packer.StrategyGreedy},
+		Objective:        packer.ObjectiveCost,
+		OvershootPenalty: 0.2,
+	})
+	require.NoError(t, err)
+
+	// Exact ships 30 items in 40 at 1+10*0.2, greedy in 32 at 3+2*0.2.
+	require.Len(t, got.Results, 2)
+	assert.InDelta(t, 3.0, got.Results[0].Cost, 1e-9)
+	assert.InDelta(t, 3.4, got.Results[1].Cost, 1e-9)
+
+	assert.Equal(t, []Disagreement{
+		{BoxSet: "priced", A: packer.StrategyExact, B: packer.StrategyGreedy, Orders: 1, Worse: 1, Examples: []uint{30}},
+	}, got.Disagreements)
+}
+
+func TestRun_failed(t *testing.T) {
+	ctx := testlogger.New(context.Background())
+
+	got, err := Run(ctx, []uint{10, 1 << 40}, Options{
+		BoxSets:    []BoxSet{{Name: "coprime", Boxes: packer.SizedBoxes([]uint{999983, 1000003})}},
+		Strategies: []string{packer.StrategyExact},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, got.Results, 1)
+	assert.Equal(t, uint(2), got.Results[0].Orders)
+	assert.Equal(t, uint(1), got.Results[0].Failed)
+	assert.Equal(t, uint(10), got.Results[0].Items)
+}
+
+func TestSynthetic(t *testing.T) {
+	a, err := Synthetic(1000, 10, 20, 42)
+	require.NoError(t, err)
+
+	b, err := Synthetic(1000, 10, 20, 42)
+	require.NoError(t, err)
+
+	assert.Equal(t, a, b)
+
+	seen := make(map[uint]bool)
+
+	for _, items := range a {
+		require.GreaterOrEqual(t, items, uint(10))
+		require.LessOrEqual(t, items, uint(20))
+
+		seen[items] = true
+	}
+
+	assert.Len(t, seen, 11)
+
+	_, err = Synthetic(0, 10, 20, 42)
+	assert.Error(t, err)
+
+	_, err = Synthetic(MaxOrders+1, 10, 20, 42)
+	assert.Error(t, err)
+
+	_, err = Synthetic(10, 20, 10, 42)
+	assert.Error(t, err)
+}